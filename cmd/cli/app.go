@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/cassette"
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/trace"
+	"github.com/akhilsharma90/terraform-assistant/pkg/tui"
+)
+
+// LLM completes prompts with a model.
+type LLM interface {
+	// Complete fits the segments into the context window of the model served by the deployment
+	// and returns the completion of the subcommand's instructions followed by the segments.
+	Complete(ctx context.Context, segments []prompt.Segment, deploymentName string, subcommand string) (string, error)
+}
+
+// FS is the working directory the templates are read from and stored in. Names are slash separated
+// and relative to the working directory.
+type FS interface {
+	fs.FS
+	// WriteFile writes the file, replacing it when it exists.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	// MkdirAll creates the directory and its parents.
+	MkdirAll(name string, perm fs.FileMode) error
+}
+
+// Prompter asks the user for decisions.
+type Prompter interface {
+	// Select asks the user to pick one of the items. With an add label, the user can type another answer instead.
+	Select(label string, items []string, addLabel string) (string, error)
+	// Confirm asks the user to confirm with y or N. It returns false when the user declined.
+	Confirm(label string) (bool, error)
+	// Input asks the user to type an answer.
+	Input(label string) (string, error)
+	// Edit lets the user edit the contents of the named file and returns the edited contents.
+	Edit(name string, contents string) (string, error)
+	// Review shows the files in the full-screen terminal UI. It returns ErrNoTerminal when the terminal cannot show it.
+	Review(r tui.Review) (tui.Result, error)
+}
+
+// App runs the commands. The model, Terraform, the working directory and the user are reached through
+// its fields, so they can be replaced, for example with fakes in tests or when embedding the commands.
+type App struct {
+	// Config is the configuration, which the global flags override.
+	Config Config
+
+	// LLM completes the prompts. When nil, an OpenAI or Azure OpenAI client is created from the config.
+	LLM LLM
+	// Ops runs Terraform. When nil, the terraform binary of the config is run in the working directory.
+	Ops terraform.Ops
+	// FS is the working directory. When nil, it is the working directory of the config on disk.
+	FS FS
+	// Prompter asks the user.
+	Prompter Prompter
+	// In is where the chat reads the prompts and commands from. When nil, it reads the terminal.
+	In io.Reader
+	// Out receives the output of commands, such as the workspaces and the diffs.
+	Out io.Writer
+	// Log receives the messages for the user, such as the templates about to be stored.
+	Log *log.Logger
+
+	// ops runs Terraform for the current command, traced.
+	ops terraform.Ops
+	// logLevel is the level of logger, debug with the debug flag.
+	logLevel *slog.LevelVar
+	// logger logs the model, validation and terraform steps.
+	logger *slog.Logger
+	// tracer writes the trace file, nil without the trace file flag.
+	tracer *trace.Tracer
+	// recorder records or replays the model API requests, nil without the cassette flag.
+	recorder *cassette.Recorder
+}
+
+// NewApp returns an app configured by the environment, which prompts on the terminal and prints to stdout and stderr.
+func NewApp() *App {
+	logLevel := new(slog.LevelVar)
+
+	return &App{
+		Config:   ConfigFromEnv(),
+		Prompter: terminalPrompter{},
+		Out:      os.Stdout,
+		Log:      log.Default(),
+		logLevel: logLevel,
+		logger:   slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})),
+	}
+}
+
+// llm returns the model client, created from the config unless one was given.
+func (a *App) llm() (LLM, error) {
+	if a.LLM != nil {
+		return a.LLM, nil
+	}
+
+	return a.newOAIClients()
+}
+
+// workdir returns the working directory.
+func (a *App) workdir() FS {
+	if a.FS != nil {
+		return a.FS
+	}
+
+	return DirFS(a.Config.WorkingDir)
+}
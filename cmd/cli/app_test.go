@@ -0,0 +1,648 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/akhilsharma90/terraform-assistant/cmd/cli"
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/tui"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLLM answers with scripted completions in order and keeps the prompts.
+type fakeLLM struct {
+	completions []string
+	prompts     []string
+}
+
+func (f *fakeLLM) Complete(_ context.Context, segments []prompt.Segment, _ string, subcommand string) (string, error) {
+	f.prompts = append(f.prompts, subcommand+prompt.Render(segments))
+
+	if len(f.completions) == 0 {
+		return "", io.EOF
+	}
+
+	com := f.completions[0]
+	f.completions = f.completions[1:]
+
+	return com, nil
+}
+
+// fakeOps keeps the operations and plans that were run, in the default workspace and a staging and production one.
+// Apply fails with applyErr when it is set.
+type fakeOps struct {
+	current  string
+	calls    []string
+	applyErr error
+	state    *tfjson.State
+	drift    *tfjson.Plan
+	// graph is the output of terraform graph, which fails when it is empty.
+	graph string
+	// plans are the files every plan was run with, and targets the targets they were run with.
+	plans   []map[string][]byte
+	targets [][]string
+	// plan is the output of every plan, No changes. when it is empty.
+	plan string
+}
+
+func (f *fakeOps) Apply(_ context.Context, targets ...string) error {
+	f.calls = append(f.calls, strings.Join(append([]string{"apply"}, targets...), " "))
+
+	return f.applyErr
+}
+
+func (f *fakeOps) Init(_ context.Context, _ terraform.InitOptions) error {
+	f.calls = append(f.calls, "init")
+
+	return nil
+}
+
+func (f *fakeOps) GenerateConfig(_ context.Context, _ string) (string, error) {
+	return "", terraform.ErrGenerateUnsupported
+}
+
+func (f *fakeOps) Plan(_ context.Context, files map[string][]byte, targets ...string) (string, error) {
+	f.plans = append(f.plans, files)
+	f.targets = append(f.targets, targets)
+
+	if f.plan == "" {
+		return "No changes.", nil
+	}
+
+	return f.plan, nil
+}
+
+func (f *fakeOps) Graph(_ context.Context) (string, error) {
+	if f.graph == "" {
+		return "", errors.New("no configuration")
+	}
+
+	return f.graph, nil
+}
+
+func (f *fakeOps) RefreshPlan(_ context.Context) (*tfjson.Plan, error) {
+	return f.drift, nil
+}
+
+func (f *fakeOps) State(_ context.Context) (*tfjson.State, error) {
+	return f.state, nil
+}
+
+func (f *fakeOps) StateMove(_ context.Context, from string, to string) (string, error) {
+	f.calls = append(f.calls, "state mv "+from+" "+to)
+
+	return "terraform.tfstate.backup", nil
+}
+
+func (f *fakeOps) StateRemove(_ context.Context, address string) (string, error) {
+	f.calls = append(f.calls, "state rm "+address)
+
+	return "terraform.tfstate.backup", nil
+}
+
+func (f *fakeOps) Workspaces(_ context.Context) ([]string, string, error) {
+	return []string{"default", "production", "staging"}, f.workspace(), nil
+}
+
+func (f *fakeOps) Workspace(_ context.Context) (string, error) {
+	return f.workspace(), nil
+}
+
+// workspace returns the current workspace, default until one is selected.
+func (f *fakeOps) workspace() string {
+	if f.current == "" {
+		return terraform.DefaultWorkspace
+	}
+
+	return f.current
+}
+
+func (f *fakeOps) SelectWorkspace(_ context.Context, name string) error {
+	f.calls = append(f.calls, "select "+name)
+	f.current = name
+
+	return nil
+}
+
+func (f *fakeOps) NewWorkspace(_ context.Context, name string) error {
+	f.calls = append(f.calls, "new "+name)
+
+	return nil
+}
+
+func (f *fakeOps) DeleteWorkspace(_ context.Context, name string) error {
+	f.calls = append(f.calls, "delete "+name)
+
+	return nil
+}
+
+// fakePrompter answers the selects and inputs with scripted answers in order.
+type fakePrompter struct {
+	answers []string
+	labels  []string
+}
+
+func (f *fakePrompter) answer(label string) string {
+	f.labels = append(f.labels, label)
+
+	if len(f.answers) == 0 {
+		return ""
+	}
+
+	answer := f.answers[0]
+	f.answers = f.answers[1:]
+
+	return answer
+}
+
+func (f *fakePrompter) Select(label string, _ []string, _ string) (string, error) {
+	return f.answer(label), nil
+}
+
+func (f *fakePrompter) Confirm(label string) (bool, error) {
+	return f.answer(label) == "y", nil
+}
+
+func (f *fakePrompter) Input(label string) (string, error) {
+	return f.answer(label), nil
+}
+
+func (f *fakePrompter) Edit(name string, _ string) (string, error) {
+	return f.answer(name), nil
+}
+
+func (f *fakePrompter) Review(_ tui.Review) (tui.Result, error) {
+	return tui.Result{}, cli.ErrNoTerminal
+}
+
+// memFS is a working directory in memory.
+type memFS struct {
+	fstest.MapFS
+}
+
+func (m memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.MapFS[name] = &fstest.MapFile{Data: data, Mode: perm}
+
+	return nil
+}
+
+func (m memFS) MkdirAll(_ string, _ fs.FileMode) error {
+	return nil
+}
+
+// newApp returns an app with fakes, asking the prompter when answers are given.
+func newApp(t *testing.T, llm *fakeLLM, prompter *fakePrompter) (*cli.App, *fakeOps, memFS, *bytes.Buffer) {
+	t.Helper()
+
+	ops, files, out := &fakeOps{}, memFS{MapFS: fstest.MapFS{}}, &bytes.Buffer{}
+
+	app := cli.NewApp()
+	app.Config = cli.Config{
+		DeploymentName:      "gpt-4o",
+		RequireConfirmation: len(prompter.answers) > 0,
+		WorkingDir:          t.TempDir(),
+	}
+	app.LLM = llm
+	app.Ops = ops
+	app.FS = files
+	app.Prompter = prompter
+	app.Out = out
+	app.Log = log.New(io.Discard, "", 0)
+
+	return app, ops, files, out
+}
+
+// executeApp runs the command of the app.
+func executeApp(app *cli.App, args ...string) error {
+	cmd := app.Command()
+	cmd.SetArgs(args)
+
+	return cmd.Execute()
+}
+
+func TestAppRun(t *testing.T) {
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf"}}
+	app, ops, files, _ := newApp(t, llm, &fakePrompter{})
+
+	require.NoError(t, executeApp(app, "create an s3 bucket named logs"))
+
+	require.Contains(t, files.MapFS, "bucket.tf")
+	assert.Equal(t, bucket, string(files.MapFS["bucket.tf"].Data))
+	assert.Equal(t, []string{"apply"}, ops.calls)
+	assert.Contains(t, llm.prompts[0], "create an s3 bucket named logs")
+}
+
+func TestAppRunReprompt(t *testing.T) {
+	private := strings.Replace(bucket, "}", "  acl    = \"private\"\n}", 1)
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf", private, "bucket.tf"}}
+	prompter := &fakePrompter{answers: []string{"make it private", "Apply"}}
+	app, ops, files, _ := newApp(t, llm, prompter)
+	app.Config.TUI = true
+
+	require.NoError(t, executeApp(app, "create an s3 bucket named logs"))
+
+	require.Len(t, llm.prompts, 4)
+	assert.Contains(t, llm.prompts[2], "make it private")
+	assert.Equal(t, private, string(files.MapFS["bucket.tf"].Data))
+	assert.Equal(t, []string{"apply"}, ops.calls)
+}
+
+func TestAppRunDontApply(t *testing.T) {
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf"}}
+	app, ops, files, _ := newApp(t, llm, &fakePrompter{answers: []string{"Don't Apply"}})
+
+	require.NoError(t, executeApp(app, "create an s3 bucket named logs"))
+
+	assert.Empty(t, files.MapFS)
+	assert.Empty(t, ops.calls)
+}
+
+func TestAppRunInterrupted(t *testing.T) {
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf"}}
+	app, ops, files, _ := newApp(t, llm, &fakePrompter{})
+	ops.applyErr = &terraform.InterruptedError{
+		Summary: terraform.ApplySummary{
+			Applied:    []string{"aws_s3_bucket.logs"},
+			Unfinished: []string{"aws_s3_bucket_policy.logs"},
+			Pending:    []string{"aws_s3_bucket_versioning.logs"},
+		},
+		Err: context.Canceled,
+	}
+
+	var logged bytes.Buffer
+
+	app.Log = log.New(&logged, "", 0)
+
+	err := executeApp(app, "create an s3 bucket named logs")
+	require.ErrorIs(t, err, context.Canceled)
+
+	assert.Contains(t, files.MapFS, "bucket.tf")
+	assert.Contains(t, logged.String(), "✔ aws_s3_bucket.logs applied")
+	assert.Contains(t, logged.String(), "✘ aws_s3_bucket_policy.logs not finished")
+	assert.Contains(t, logged.String(), "· aws_s3_bucket_versioning.logs not applied")
+}
+
+func TestAppWorkspaceList(t *testing.T) {
+	app, ops, _, out := newApp(t, &fakeLLM{}, &fakePrompter{})
+	ops.current = "staging"
+
+	require.NoError(t, executeApp(app, "workspace", "list"))
+
+	assert.Equal(t, "  default\n  production\n* staging\n", out.String())
+}
+
+func TestAppWorkspaceDeleteProtected(t *testing.T) {
+	prompter := &fakePrompter{answers: []string{"prod"}}
+	app, ops, _, _ := newApp(t, &fakeLLM{}, prompter)
+
+	require.NoError(t, executeApp(app, "workspace", "delete", "production"))
+
+	assert.Empty(t, ops.calls)
+	require.Len(t, prompter.labels, 1)
+	assert.Contains(t, prompter.labels[0], "production")
+}
+
+func TestAppRunWorkspace(t *testing.T) {
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf"}}
+	app, ops, _, _ := newApp(t, llm, &fakePrompter{})
+	app.Config.Workspace = "staging"
+
+	require.NoError(t, executeApp(app, "create an s3 bucket named logs"))
+
+	// The working directory is left in the workspace it was in
+	assert.Equal(t, []string{"select staging", "apply", "select default"}, ops.calls)
+	assert.Equal(t, terraform.DefaultWorkspace, ops.current)
+}
+
+func TestConfigWorkspace(t *testing.T) {
+	// Jenkins sets WORKSPACE to the directory of the job
+	t.Setenv("WORKSPACE", "/var/lib/jenkins/workspace/infra")
+	t.Setenv("TF_WORKSPACE", "staging")
+
+	assert.Equal(t, "staging", cli.ConfigFromEnv().Workspace)
+}
+
+func TestAppWorkspacePrompt(t *testing.T) {
+	app, ops, _, _ := newApp(t, &fakeLLM{completions: []string{"terraform workspace select staging"}}, &fakePrompter{})
+
+	require.NoError(t, executeApp(app, "workspace", "switch to staging"))
+
+	assert.Equal(t, []string{"select staging"}, ops.calls)
+}
+
+func TestAppFmtCheck(t *testing.T) {
+	app, _, files, out := newApp(t, &fakeLLM{}, &fakePrompter{})
+	files.MapFS["main.tf"] = &fstest.MapFile{Data: []byte("resource \"aws_s3_bucket\" \"logs\" {\nbucket = \"logs\"\n}\n")}
+
+	err := executeApp(app, "fmt", "--check")
+	require.ErrorContains(t, err, "1 of 1 files need formatting")
+	assert.Contains(t, out.String(), `+  bucket = "logs"`)
+
+	require.NoError(t, executeApp(app, "fmt"))
+	assert.Equal(t, bucket, string(files.MapFS["main.tf"].Data))
+}
+
+func TestAppInit(t *testing.T) {
+	provider := "provider \"aws\" {\n  region = \"us-east-2\"\n}\n"
+	app, ops, files, _ := newApp(t, &fakeLLM{completions: []string{provider}}, &fakePrompter{})
+
+	require.NoError(t, executeApp(app, "init", "create aws provider in ohio"))
+
+	assert.Equal(t, provider, string(files.MapFS["provider.tf"].Data))
+	assert.Equal(t, []string{"init"}, ops.calls)
+}
+
+// tfState is a state with two instances and a database with a password.
+const tfState = `{"format_version": "1.0", "values": {"root_module": {"resources": [
+  {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web", "values": {"instance_type": "t2.micro"}},
+  {"address": "aws_instance.api", "mode": "managed", "type": "aws_instance", "name": "api", "values": {"instance_type": "t3.large"}},
+  {"address": "aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main",
+   "values": {"instance_class": "db.t3.micro", "password": "hunter22"}, "sensitive_values": {"password": true}}
+]}}}`
+
+// withState sets the state of the fake terraform.
+func withState(t *testing.T, ops *fakeOps) {
+	t.Helper()
+
+	ops.state = &tfjson.State{}
+	require.NoError(t, json.Unmarshal([]byte(tfState), ops.state))
+}
+
+func TestAppAskState(t *testing.T) {
+	query := `{"type": "aws_instance", "where": [{"attribute": "instance_type", "op": "eq", "value": "t2.micro"}], "attributes": ["instance_type"]}`
+	llm := &fakeLLM{completions: []string{query, "Only aws_instance.web is a t2.micro."}}
+	app, ops, _, out := newApp(t, llm, &fakePrompter{})
+	withState(t, ops)
+
+	require.NoError(t, executeApp(app, "ask-state", "which instances are t2.micro?"))
+
+	assert.Equal(t, "aws_instance.web\n  instance_type = \"t2.micro\"\n\nOnly aws_instance.web is a t2.micro.\n", out.String())
+	require.Len(t, llm.prompts, 2)
+	assert.Contains(t, llm.prompts[0], "aws_db_instance: instance_class, password")
+	assert.Contains(t, llm.prompts[1], `"address":"aws_instance.web"`)
+
+	for _, p := range llm.prompts {
+		assert.NotContains(t, p, "hunter22")
+	}
+}
+
+func TestAppAskStateQuery(t *testing.T) {
+	llm := &fakeLLM{}
+	app, ops, _, out := newApp(t, llm, &fakePrompter{})
+	withState(t, ops)
+
+	require.NoError(t, executeApp(app, "ask-state", "--json", "--query", `{"type": "aws_db_instance"}`))
+
+	assert.Empty(t, llm.prompts)
+	assert.JSONEq(t, `[{"address": "aws_db_instance.main", "type": "aws_db_instance", "values": {"instance_class": "db.t3.micro", "password": "(sensitive)"}}]`, out.String())
+}
+
+func TestAppRunRenames(t *testing.T) {
+	renamed := strings.Replace(bucket, `"logs" {`, `"audit_logs" {`, 1)
+	llm := &fakeLLM{completions: []string{renamed, "bucket.tf"}}
+	app, ops, files, _ := newApp(t, llm, &fakePrompter{})
+	files.MapFS["bucket.tf"] = &fstest.MapFile{Data: []byte(bucket)}
+
+	require.NoError(t, executeApp(app, "rename the logs bucket to audit_logs"))
+
+	require.Contains(t, files.MapFS, "moved.tf")
+	assert.Equal(t, "moved {\n  from = aws_s3_bucket.logs\n  to   = aws_s3_bucket.audit_logs\n}\n", string(files.MapFS["moved.tf"].Data))
+	assert.Equal(t, []string{"apply"}, ops.calls)
+}
+
+func TestAppRunBlastRadius(t *testing.T) {
+	renamed := strings.Replace(bucket, `bucket = "logs"`, `bucket = "app-logs"`, 1)
+	llm := &fakeLLM{completions: []string{renamed, "bucket.tf"}}
+	prompter := &fakePrompter{answers: []string{"Apply"}}
+	app, ops, files, _ := newApp(t, llm, prompter)
+	ops.graph = `digraph G {
+  "module.cdn.aws_cloudfront_distribution.this" -> "aws_s3_bucket.logs";
+}`
+	files.MapFS["bucket.tf"] = &fstest.MapFile{Data: []byte(bucket)}
+	files.MapFS["outputs.tf"] = &fstest.MapFile{Data: []byte("output \"logs_arn\" {\n  value = aws_s3_bucket.logs.arn\n}\n")}
+
+	var logged bytes.Buffer
+
+	app.Log = log.New(&logged, "", 0)
+
+	require.NoError(t, executeApp(app, "rename the logs bucket to app-logs"))
+
+	assert.Contains(t, logged.String(), "💥 Blast radius: changing aws_s3_bucket.logs may update or replace:\n  ~ module.cdn\n  ~ output.logs_arn\n")
+	assert.Equal(t, []string{"apply"}, ops.calls)
+}
+
+func TestAppRunTargeted(t *testing.T) {
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf"}}
+	app, ops, files, _ := newApp(t, llm, &fakePrompter{})
+	app.Config.Targeted = true
+	ops.plan = `  # aws_s3_bucket.logs will be created
+  + resource "aws_s3_bucket" "logs" {}
+
+  # aws_instance.web will be updated in-place
+  ~ resource "aws_instance" "web" {}
+`
+
+	var logged bytes.Buffer
+
+	app.Log = log.New(&logged, "", 0)
+
+	require.NoError(t, executeApp(app, "create an s3 bucket named logs"))
+
+	// The pending change of the web instance is left out
+	require.Len(t, ops.plans, 1)
+	assert.Empty(t, ops.targets[0])
+	assert.Contains(t, logged.String(), "⚠️ Applying only aws_s3_bucket.logs. These pending changes of the working directory are left for a later apply:\n  - aws_instance.web\n")
+	assert.Contains(t, files.MapFS, "bucket.tf")
+	assert.Equal(t, []string{"apply aws_s3_bucket.logs"}, ops.calls)
+}
+
+func TestAppRunTargetedNothing(t *testing.T) {
+	llm := &fakeLLM{completions: []string{"variable \"region\" {\n  default = \"us-east-1\"\n}\n", "variables.tf"}}
+	app, ops, files, _ := newApp(t, llm, &fakePrompter{})
+	app.Config.Targeted = true
+
+	err := executeApp(app, "add a region variable")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no resources, data sources or modules to target")
+
+	// Nothing is stored or applied, an untargeted apply would apply every pending change
+	assert.NotContains(t, files.MapFS, "variables.tf")
+	assert.Empty(t, ops.calls)
+}
+
+func TestAppStateMoved(t *testing.T) {
+	app, ops, files, _ := newApp(t, &fakeLLM{}, &fakePrompter{})
+	withState(t, ops)
+	files.MapFS["main.tf"] = &fstest.MapFile{Data: []byte(`resource "aws_instance" "web" {}
+resource "aws_instance" "frontend" {}
+resource "aws_db_instance" "main" {}
+`)}
+
+	require.NoError(t, executeApp(app, "state", "moved"))
+
+	moved := "moved {\n  from = aws_instance.api\n  to   = aws_instance.frontend\n}\n"
+	require.Contains(t, files.MapFS, "moved.tf")
+	assert.Equal(t, moved, string(files.MapFS["moved.tf"].Data))
+	require.Len(t, ops.plans, 1)
+	assert.Equal(t, moved, string(ops.plans[0]["moved.tf"]))
+
+	// The moves are declared now
+	ops.plans = nil
+	require.NoError(t, executeApp(app, "state", "moved"))
+	assert.Empty(t, ops.plans)
+}
+
+func TestAppStateMove(t *testing.T) {
+	prompter := &fakePrompter{answers: []string{"production", "y"}}
+	app, ops, _, _ := newApp(t, &fakeLLM{}, prompter)
+	ops.current = "production"
+
+	require.NoError(t, executeApp(app, "state", "mv", "aws_instance.web", "aws_instance.frontend"))
+
+	assert.Equal(t, []string{"state mv aws_instance.web aws_instance.frontend"}, ops.calls)
+	require.Len(t, ops.plans, 1)
+	assert.Contains(t, string(ops.plans[0]["terraform-assistant-state.tf"]), "from = aws_instance.web")
+	assert.Equal(t, "Move aws_instance.web to aws_instance.frontend in the state", prompter.labels[1])
+}
+
+func TestAppStateRemove(t *testing.T) {
+	app, ops, _, _ := newApp(t, &fakeLLM{}, &fakePrompter{answers: []string{"n"}})
+
+	require.NoError(t, executeApp(app, "state", "rm", "aws_instance.legacy"))
+	assert.Empty(t, ops.calls)
+	assert.Contains(t, string(ops.plans[0]["terraform-assistant-state.tf"]), "destroy = false")
+
+	app, ops, _, _ = newApp(t, &fakeLLM{}, &fakePrompter{})
+
+	require.NoError(t, executeApp(app, "state", "rm", "module.db"))
+	assert.Equal(t, []string{"state rm module.db"}, ops.calls)
+
+	require.ErrorContains(t, executeApp(app, "state", "rm", "not an address"), "invalid resource address")
+}
+
+// tfDrift is a refresh-only plan where an instance type changed and a bucket was deleted.
+const tfDrift = `{"format_version": "1.2", "resource_drift": [
+  {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web", "change": {"actions": ["update"],
+   "before": {"instance_type": "t2.micro", "user_data": "old"}, "after": {"instance_type": "t3.micro", "user_data": "new"},
+   "before_sensitive": {"user_data": true}, "after_sensitive": {"user_data": true}}},
+  {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs", "change": {"actions": ["delete"],
+   "before": {"bucket": "logs"}, "after": null}}
+]}`
+
+func TestAppDrift(t *testing.T) {
+	suggestions := `[
+  {"address": "aws_instance.web", "attribute": "instance_type", "resolution": "adopt", "change": "instance_type = \"t3.micro\"", "reason": "The instance was resized on purpose."},
+  {"address": "aws_s3_bucket.logs", "attribute": "", "resolution": "revert", "reason": "The configuration still declares the bucket."}
+]`
+	llm := &fakeLLM{completions: []string{suggestions}}
+	app, ops, files, out := newApp(t, llm, &fakePrompter{})
+	files.MapFS["main.tf"] = &fstest.MapFile{Data: []byte(bucket)}
+	files.MapFS["web.tf"] = &fstest.MapFile{Data: []byte("resource \"aws_instance\" \"web\" {\n  instance_type = \"t2.micro\"\n  user_data     = \"#!/bin/sh\"\n}\n")}
+	ops.drift = &tfjson.Plan{}
+	require.NoError(t, json.Unmarshal([]byte(tfDrift), ops.drift))
+
+	require.NoError(t, executeApp(app, "drift"))
+
+	assert.Equal(t, `2 resources changed outside Terraform.
+
+aws_instance.web changed
+  instance_type: "t2.micro" → "t3.micro"
+    ✎ Adopt it in the configuration:
+        instance_type = "t3.micro"
+      The instance was resized on purpose.
+  user_data: "(sensitive)" → "(sensitive)"
+
+aws_s3_bucket.logs was deleted
+    ↺ The next apply creates it again.
+      The configuration still declares the bucket.
+`, out.String())
+
+	require.Len(t, llm.prompts, 1)
+	assert.Contains(t, llm.prompts[0], `resource "aws_s3_bucket" "logs"`)
+	assert.Contains(t, llm.prompts[0], `instance_type = "t2.micro"`)
+	assert.NotContains(t, llm.prompts[0], "old")
+	assert.NotContains(t, llm.prompts[0], "#!/bin/sh")
+}
+
+func TestAppDriftJSON(t *testing.T) {
+	llm := &fakeLLM{}
+	app, ops, _, out := newApp(t, llm, &fakePrompter{})
+	ops.drift = &tfjson.Plan{}
+
+	require.NoError(t, executeApp(app, "drift", "--json"))
+	assert.Equal(t, "[]\n", out.String())
+
+	out.Reset()
+	require.NoError(t, json.Unmarshal([]byte(tfDrift), ops.drift))
+
+	require.NoError(t, executeApp(app, "drift", "--json", "--no-suggest"))
+
+	assert.Empty(t, llm.prompts)
+	assert.JSONEq(t, `[
+  {"address": "aws_instance.web", "action": "update", "attribute": "instance_type", "state": "t2.micro", "actual": "t3.micro"},
+  {"address": "aws_instance.web", "action": "update", "attribute": "user_data", "state": "(sensitive)", "actual": "(sensitive)"},
+  {"address": "aws_s3_bucket.logs", "action": "delete"}
+]`, out.String())
+}
+
+func TestAppDriftSuggestionsFail(t *testing.T) {
+	llm := &fakeLLM{completions: []string{"Sorry, I cannot help with that."}}
+	app, ops, _, out := newApp(t, llm, &fakePrompter{})
+	ops.drift = &tfjson.Plan{}
+	require.NoError(t, json.Unmarshal([]byte(tfDrift), ops.drift))
+
+	var logged bytes.Buffer
+
+	app.Log = log.New(&logged, "", 0)
+
+	// The drift is reported without suggestions, and found
+	err := executeApp(app, "drift", "--json", "--detailed-exitcode")
+	require.ErrorIs(t, err, cli.ErrDrift)
+
+	assert.Contains(t, logged.String(), "⚠️ Reporting the drift without suggestions: no JSON array")
+
+	var items []map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &items))
+	require.Len(t, items, 3)
+	assert.NotContains(t, items[0], "resolution")
+
+	ops.drift = &tfjson.Plan{}
+	require.NoError(t, executeApp(app, "drift", "--json", "--detailed-exitcode"))
+}
+
+func TestAppRunVerify(t *testing.T) {
+	verdict := `{"matches": false, "unrequested": ["aws_iam_role.extra: no role was asked for"], "missing": [], "suspicious_destroys": [], "summary": "The plan adds a role."}`
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf", verdict}}
+	prompter := &fakePrompter{answers: []string{"Apply"}}
+	app, ops, files, _ := newApp(t, llm, prompter)
+	app.Config.Verify = true
+
+	var logged bytes.Buffer
+
+	app.Log = log.New(&logged, "", 0)
+
+	require.NoError(t, executeApp(app, "create an s3 bucket named logs"))
+
+	require.Len(t, llm.prompts, 3)
+	assert.Contains(t, llm.prompts[2], "The plan:\nNo changes.")
+	assert.Contains(t, llm.prompts[2], "create an s3 bucket named logs")
+	require.Len(t, ops.plans, 1)
+	assert.Contains(t, ops.plans[0], "bucket.tf")
+
+	// The verdict comes right before the action prompt
+	assert.Contains(t, logged.String(), "⚠️ The plan may not match the request. The plan adds a role.\n  + Not requested: aws_iam_role.extra: no role was asked for\n")
+	assert.Contains(t, files.MapFS, "bucket.tf")
+	assert.Equal(t, []string{"apply"}, ops.calls)
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/state"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	stateQuerySubCommand   = "You are a Terraform state query generator, only generate a single JSON query selecting the resources of the state that answer the question."
+	stateSummarySubCommand = "You are a Terraform state analyst, only answer the question in a few sentences from the matching resources of the state, which are all the resources the question is about."
+)
+
+// maxSummarized is the number of matching resources sent to the model to summarize.
+const maxSummarized = 50
+
+var errEmptyState = errors.New("the state has no resources")
+
+// stateMatch is a resource matching a query, with the attributes the query selects.
+type stateMatch struct {
+	Address string         `json:"address"`
+	Type    string         `json:"type"`
+	Values  map[string]any `json:"values,omitempty"`
+}
+
+// addAskState creates and returns a new Cobra command for the "ask-state" subcommand.
+// This command answers questions about the resources in the state.
+func (a *App) addAskState() *cobra.Command {
+	var (
+		// query is a query given instead of asking the model for one.
+		query string
+		// asJSON prints the matching resources as JSON, without the summary of the model.
+		asJSON bool
+	)
+
+	askStateCmd := &cobra.Command{
+		Use:   "ask-state <question>",
+		Short: "Answer questions about the resources in the Terraform state",
+		Long: `Answer questions about the resources in the Terraform state.
+
+The model turns the question into a query, which is run over the state without the model, and summarizes
+the matching resources. Sensitive values are redacted before anything is sent to the model.
+
+` + state.QueryHelp,
+		Example: `  # Ask about the state
+  terraform-ai ask-state "which instances are t2.micro?"
+  terraform-ai ask-state "what depends on the main VPC?"
+
+  # Run a query without the model
+  terraform-ai ask-state --json --query '{"type": "aws_instance", "attributes": ["instance_type"]}'`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return a.askStateCommand(args, query, asJSON)
+		},
+	}
+
+	askStateCmd.Flags().StringVar(&query, "query", "", "A query to run instead of asking the model for one.")
+	askStateCmd.Flags().BoolVar(&asJSON, "json", false, "Print the matching resources as JSON, without a summary.")
+
+	return askStateCmd
+}
+
+// askStateCommand handles the "ask-state" command.
+func (a *App) askStateCommand(args []string, query string, asJSON bool) error {
+	if len(args) == 0 && query == "" {
+		return errors.Wrap(errLength, "question or query must be provided")
+	}
+
+	return a.askState(strings.Join(args, " "), query, asJSON)
+}
+
+// askState answers the question about the state. The model writes the query unless one is given
+// and summarizes the result unless JSON is asked for.
+func (a *App) askState(question string, query string, asJSON bool) error {
+	ctx, cancel := a.interruptContext()
+	defer cancel()
+
+	restore, err := a.targetWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	tfState, err := a.ops.State(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading state: %w", err)
+	}
+
+	resources := state.Resources(tfState)
+	if len(resources) == 0 {
+		return errEmptyState
+	}
+
+	// The model is needed for the query and the summary only, a query alone works without a key
+	var llm LLM
+	if query == "" || (!asJSON && question != "") {
+		if llm, err = a.llm(); err != nil {
+			return fmt.Errorf("error creating new OAI client: %w", err)
+		}
+	}
+
+	if query == "" {
+		query, err = llm.Complete(ctx, stateQuerySegments(question, resources), a.Config.DeploymentName, stateQuerySubCommand)
+		if err != nil {
+			return fmt.Errorf("error completing state query: %w", err)
+		}
+	}
+
+	q, err := state.ParseQuery(query)
+	if err != nil {
+		return err
+	}
+
+	a.Log.Printf("🦄 Query: %s\n", q)
+
+	matches := make([]stateMatch, 0)
+	for _, r := range q.Run(resources) {
+		matches = append(matches, stateMatch{Address: r.Address, Type: r.Type, Values: q.Select(r)})
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(a.Out)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(matches)
+	}
+
+	if len(matches) == 0 {
+		fmt.Fprintln(a.Out, "No resources match.")
+
+		return nil
+	}
+
+	a.printMatches(matches, len(q.Attributes) > 0)
+
+	if llm == nil {
+		return nil
+	}
+
+	summary, err := llm.Complete(ctx, stateSummarySegments(question, matches), a.Config.DeploymentName, stateSummarySubCommand)
+	if err != nil {
+		return fmt.Errorf("error completing state summary: %w", err)
+	}
+
+	fmt.Fprintf(a.Out, "\n%s\n", strings.TrimSpace(summary))
+
+	return nil
+}
+
+// printMatches prints the addresses of the matches, with their attributes when the query selects some.
+func (a *App) printMatches(matches []stateMatch, attributes bool) {
+	for _, m := range matches {
+		fmt.Fprintln(a.Out, m.Address)
+
+		if !attributes {
+			continue
+		}
+
+		names := make([]string, 0, len(m.Values))
+		for name := range m.Values {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			value, _ := json.Marshal(m.Values[name])
+			fmt.Fprintf(a.Out, "  %s = %s\n", name, value)
+		}
+	}
+}
+
+// stateQuerySegments asks for a query answering the question, with the resource types of the state and their attributes.
+func stateQuerySegments(question string, resources []state.Resource) []prompt.Segment {
+	types := state.Types(resources)
+
+	names := make([]string, 0, len(types))
+	for t := range types {
+		names = append(names, t)
+	}
+
+	sort.Strings(names)
+
+	var b strings.Builder
+
+	b.WriteString("The state has these resource types with these attributes:\n")
+
+	for _, t := range names {
+		fmt.Fprintf(&b, "%s: %s\n", t, strings.Join(types[t], ", "))
+	}
+
+	addresses := make([]string, 0, len(resources))
+	for _, r := range resources {
+		addresses = append(addresses, r.Address)
+	}
+
+	return []prompt.Segment{
+		prompt.Required(state.QueryHelp),
+		{Priority: prompt.PriorityContext, Text: b.String()},
+		{Priority: prompt.PriorityInventory, Text: "The resources of the state are: " + strings.Join(addresses, ", ")},
+		prompt.Required("Question: " + question),
+	}
+}
+
+// stateSummarySegments asks for the answer to the question from the matching resources.
+func stateSummarySegments(question string, matches []stateMatch) []prompt.Segment {
+	more := ""
+	if len(matches) > maxSummarized {
+		more = fmt.Sprintf("\nand %d more resources like these.", len(matches)-maxSummarized)
+		matches = matches[:maxSummarized]
+	}
+
+	b, _ := json.Marshal(matches)
+
+	return []prompt.Segment{
+		prompt.Required("Question: " + question),
+		prompt.Required(fmt.Sprintf("Matching resources: %s%s", b, more)),
+	}
+}
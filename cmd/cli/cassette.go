@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/cassette"
+)
+
+// openCassette opens the cassette given with the cassette flag, in the cassette mode.
+func (a *App) openCassette() error {
+	a.recorder = nil
+
+	if a.Config.CassetteFile == "" {
+		return nil
+	}
+
+	mode, err := cassette.ParseMode(a.Config.CassetteMode)
+	if err != nil {
+		return err
+	}
+
+	if a.recorder, err = cassette.New(a.Config.CassetteFile, mode, nil); err != nil {
+		return fmt.Errorf("error opening cassette: %w", err)
+	}
+
+	return nil
+}
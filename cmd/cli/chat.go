@@ -0,0 +1,491 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/conventions"
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/session"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/utils"
+	"github.com/chzyer/readline"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Constant string for the chat subcommand description
+const chatSubCommand = "You are a Terraform HCL generator, only generate the complete valid Terraform HCL without provider templates."
+
+const chatHelp = `Describe the infrastructure you want, or refine the pending template. Commands:
+  /plan          run terraform plan with the pending changes
+  /apply         store the pending changes and run terraform apply
+  /diff          show the pending changes as a diff against the working directory
+  /undo          revert the last change
+  /files         list the pending files
+  /model [name]  show or switch the model
+  /save          save the session to resume it later
+  /exit          leave the chat, again to lose the pending changes that are not saved`
+
+// Error for unknown chat commands
+var errChatCommand = errors.New("unknown command, type /help for the commands")
+
+// chat is an interactive conversation that generates pending changes to the working directory.
+type chat struct {
+	*App
+	llm     LLM
+	conv    *conventions.Conventions
+	session *session.Session
+	dir     string
+	// restoreWorkspace selects the workspace the working directory was in before the chat.
+	restoreWorkspace func()
+	// leaving is set when the user asked to leave with pending changes, and was warned about them.
+	leaving bool
+}
+
+// addChat creates and returns a new Cobra command for the "chat" subcommand.
+func (a *App) addChat() *cobra.Command {
+	// id is the ID of a saved session to resume.
+	var id string
+
+	chatCmd := &cobra.Command{
+		Use:   "chat",
+		Short: "Design infrastructure iteratively in an interactive chat",
+		Long:  "Design infrastructure iteratively in an interactive chat.\n\n" + chatHelp,
+		Example: `  # Start a new chat
+  terraform-ai chat
+
+  # Resume a saved session
+  terraform-ai chat --session 20260101-093000-1a2b
+
+  # Save sessions next to the project
+  terraform-ai chat --sessions-dir .sessions`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return a.chatCommand(id)
+		},
+	}
+
+	chatCmd.Flags().StringVar(&id, "session", "", "The ID of a saved session to resume.")
+	_ = chatCmd.RegisterFlagCompletionFunc("session", a.completeSessions)
+
+	return chatCmd
+}
+
+// chatCommand starts or resumes a chat session and reads prompts and commands until the user leaves.
+func (a *App) chatCommand(id string) error {
+	ctx, cancel := a.interruptContext()
+	c, err := a.newChat(ctx, id)
+	cancel()
+
+	if err != nil {
+		return err
+	}
+	defer c.restoreWorkspace()
+
+	config := &readline.Config{
+		Prompt:          "🦄 > ",
+		HistoryFile:     c.historyFile(),
+		AutoComplete:    chatCompleter(),
+		InterruptPrompt: "^C",
+		EOFPrompt:       "/exit",
+	}
+
+	if a.In != nil {
+		config.Stdin = io.NopCloser(a.In)
+		config.Stdout = a.Out
+		config.FuncIsTerminal = func() bool { return false }
+	}
+
+	rl, err := readline.NewEx(config)
+	if err != nil {
+		return fmt.Errorf("error starting chat: %w", err)
+	}
+	defer rl.Close()
+
+	fmt.Fprintln(a.Out, chatHelp)
+
+	for {
+		line, err := rl.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			if line == "" && c.leave() {
+				return nil
+			}
+
+			continue
+		}
+
+		if errors.Is(err, io.EOF) {
+			if c.leave() {
+				return nil
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("error reading prompt: %w", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		quit, err := c.handle(line)
+		if err != nil {
+			a.Log.Printf("⚠️ %s\n", err)
+		}
+
+		if quit {
+			return nil
+		}
+	}
+}
+
+// handle runs the slash command or the chat turn of the line, which an interrupt stops.
+// It reports true when the user leaves the chat.
+func (c *chat) handle(line string) (bool, error) {
+	ctx, cancel := c.interruptContext()
+	defer cancel()
+
+	// Leaving again has to come right after the warning
+	if command := strings.Fields(line)[0]; command != "/exit" && command != "/quit" {
+		c.leaving = false
+	}
+
+	if strings.HasPrefix(line, "/") {
+		return c.command(ctx, line)
+	}
+
+	return false, c.turn(ctx, line)
+}
+
+// leave reports whether the user may leave the chat. With pending changes, the first time it
+// warns that they are lost and the user has to leave again, right after, to lose them.
+func (c *chat) leave() bool {
+	if len(c.session.Pending) == 0 || c.leaving {
+		return true
+	}
+
+	c.leaving = true
+	c.Log.Printf("⚠️ %d pending files are not applied, /apply or /save them, or leave again to lose them.\n", len(c.session.Pending))
+
+	return false
+}
+
+// newChat creates the model client and analyzes the workspace, resuming the saved session when an ID is given.
+// The chat runs in the workspace of the workspace flag until its restoreWorkspace is called.
+func (a *App) newChat(ctx context.Context, id string) (*chat, error) {
+	llm, err := a.llm()
+	if err != nil {
+		return nil, fmt.Errorf("error creating new OAI client: %w", err)
+	}
+
+	conv, err := conventions.Analyze(a.Config.WorkingDir)
+	if err != nil {
+		return nil, fmt.Errorf("error analyzing workspace conventions: %w", err)
+	}
+
+	dir, err := a.sessionDir()
+	if err != nil {
+		return nil, err
+	}
+
+	s := session.New(a.Config.DeploymentName)
+
+	if id != "" {
+		if s, err = session.Load(dir, id); err != nil {
+			return nil, err
+		}
+
+		a.Log.Printf("Resumed session %s with %d pending files.\n", s.ID, len(s.Pending))
+	}
+
+	restore, err := a.targetWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chat{App: a, llm: llm, conv: conv, session: s, dir: dir, restoreWorkspace: restore}, nil
+}
+
+// turn sends the prompt to the model, together with the conversation and the pending template,
+// and stages the template it returns with the blast radius of everything pending.
+func (c *chat) turn(ctx context.Context, line string) error {
+	segments := append(c.inventorySegments(), c.moduleSegments()...)
+	segments = append(segments, c.workspaceSegments(ctx)...)
+	segments = append(segments, conventionSegments(c.conv)...)
+
+	for _, m := range c.session.Messages {
+		if m.Role == session.RoleUser {
+			segments = append(segments, prompt.Segment{Priority: prompt.PriorityHistory, Text: "Earlier request: " + m.Content})
+		}
+	}
+
+	name := c.session.Main
+	if name != "" {
+		segments = append(segments, prompt.Required("Update this template:\n"+c.session.Pending[name]))
+	}
+
+	segments = append(segments, prompt.Required(line))
+
+	com, err := c.llm.Complete(ctx, segments, c.session.Model, chatSubCommand)
+	if err != nil {
+		return fmt.Errorf("error completing chat: %w", err)
+	}
+
+	if err = c.checkTemplate(com); err != nil {
+		return fmt.Errorf("error checking template: %w", err)
+	}
+
+	// Fix what can be fixed, prompts would fight the chat for the terminal
+	if violations := c.conv.Check(com); len(violations) > 0 {
+		if com, err = conventions.Fix(com, violations); err != nil {
+			return fmt.Errorf("error fixing conventions: %w", err)
+		}
+
+		for _, v := range c.conv.Check(com) {
+			c.Log.Printf("⚠️ %s\n", v)
+		}
+	}
+
+	if name == "" {
+		name, err = c.llm.Complete(ctx, requestSegments([]string{line}, nil), c.session.Model, nameSubCommand)
+		if err != nil {
+			return fmt.Errorf("error completing name command: %w", err)
+		}
+
+		name = utils.GetName(name)
+	}
+
+	files, err := c.generatedFiles(name, com)
+	if err != nil {
+		return err
+	}
+
+	staged := make(map[string]string, len(files))
+	for fileName, contents := range files {
+		staged[fileName] = string(contents)
+	}
+
+	c.session.Stage(name, staged)
+	c.session.AddMessage(session.RoleUser, line)
+	c.session.AddMessage(session.RoleAssistant, string(files[name]))
+
+	c.logFiles(name, files)
+	c.logImpact(ctx, c.pendingFiles())
+	fmt.Fprintln(c.Out, "Pending until /apply, /undo reverts it.")
+
+	return nil
+}
+
+// command runs a slash command. It reports true when the user leaves the chat.
+func (c *chat) command(ctx context.Context, line string) (bool, error) {
+	fields := strings.Fields(line)
+
+	switch fields[0] {
+	case "/exit", "/quit":
+		return c.leave(), nil
+	case "/help":
+		fmt.Fprintln(c.Out, chatHelp)
+	case "/plan":
+		fmt.Fprintln(c.Out, "Planning...")
+
+		files := c.pendingFiles()
+
+		plan, err := c.ops.Plan(ctx, files, c.targets(files)...)
+		if err != nil {
+			return false, err
+		}
+
+		fmt.Fprintln(c.Out, plan)
+	case "/apply":
+		return false, c.apply(ctx)
+	case "/diff":
+		return false, c.diff()
+	case "/undo":
+		if !c.session.Undo() {
+			fmt.Fprintln(c.Out, "Nothing to undo.")
+		} else {
+			fmt.Fprintf(c.Out, "Reverted the last change, %d pending files.\n", len(c.session.Pending))
+		}
+	case "/files":
+		c.files()
+	case "/model":
+		return false, c.model(fields[1:])
+	case "/save":
+		if err := session.Save(c.dir, c.session); err != nil {
+			return false, err
+		}
+
+		fmt.Fprintf(c.Out, "Saved session %s, resume it with: chat --session %s\n", c.session.ID, c.session.ID)
+	default:
+		return false, errors.Wrap(errChatCommand, fields[0])
+	}
+
+	return false, nil
+}
+
+// apply stores the pending files and applies them.
+func (c *chat) apply(ctx context.Context) error {
+	if len(c.session.Pending) == 0 {
+		fmt.Fprintln(c.Out, "Nothing to apply.")
+
+		return nil
+	}
+
+	files := c.pendingFiles()
+	for name, contents := range files {
+		if utils.EndsWithTf(name) {
+			if err := c.checkTemplate(string(contents)); err != nil {
+				return fmt.Errorf("error checking %s: %w", name, err)
+			}
+		}
+	}
+
+	files, err := c.proposeMoves(files)
+	if err != nil {
+		return err
+	}
+
+	targets, err := c.applyTargets(ctx, files)
+	if err != nil {
+		return err
+	}
+
+	ok, err := c.confirmApply(ctx)
+	if err != nil || !ok {
+		return err
+	}
+
+	if err = c.storeFiles(files); err != nil {
+		return err
+	}
+
+	// The files are stored, so they are no longer pending even when the apply fails
+	c.session.Commit()
+
+	if err = c.ops.Apply(ctx, targets...); err != nil {
+		return c.applyError(err)
+	}
+
+	fmt.Fprintf(c.Out, "Applied %d files.\n", len(files))
+
+	return nil
+}
+
+// diff prints the pending files as a diff against the working directory.
+func (c *chat) diff() error {
+	if len(c.session.Pending) == 0 {
+		fmt.Fprintln(c.Out, "No pending changes.")
+
+		return nil
+	}
+
+	for _, name := range c.session.PendingNames() {
+		current, err := fs.ReadFile(c.workdir(), filepath.ToSlash(name))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error reading %s: %w", name, err)
+		}
+
+		diff, err := terraform.Diff(name, name+" (pending)", string(current), c.session.Pending[name])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(c.Out, diff)
+	}
+
+	return nil
+}
+
+// files lists the pending files and whether they are new or change a file of the working directory.
+func (c *chat) files() {
+	if len(c.session.Pending) == 0 {
+		fmt.Fprintln(c.Out, "No pending changes.")
+
+		return
+	}
+
+	for _, name := range c.session.PendingNames() {
+		status := "modified"
+		if _, err := fs.Stat(c.workdir(), filepath.ToSlash(name)); errors.Is(err, fs.ErrNotExist) {
+			status = "new"
+		}
+
+		fmt.Fprintf(c.Out, "  %-9s %s\n", status, name)
+	}
+}
+
+// model shows the model of the session or switches to the given one.
+func (c *chat) model(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(c.Out, c.session.Model)
+
+		return nil
+	}
+
+	registry, err := newModelRegistry(c.Config)
+	if err != nil {
+		return err
+	}
+
+	model, err := registry.Lookup(args[0])
+	if err != nil {
+		return fmt.Errorf("error looking up model: %w", err)
+	}
+
+	c.session.Model = args[0]
+	fmt.Fprintf(c.Out, "Switched to %s (%d tokens context window).\n", args[0], model.ContextWindow)
+
+	return nil
+}
+
+// pendingFiles returns the pending files as the contents to store.
+func (c *chat) pendingFiles() map[string][]byte {
+	files := make(map[string][]byte, len(c.session.Pending))
+	for name, contents := range c.session.Pending {
+		files[name] = []byte(contents)
+	}
+
+	return files
+}
+
+// sessionDir returns the directory the sessions are saved in, from the sessions dir flag or the default.
+func (a *App) sessionDir() (string, error) {
+	if a.Config.SessionsDir != "" {
+		return a.Config.SessionsDir, nil
+	}
+
+	return session.DefaultDir()
+}
+
+// historyFile returns the file the prompts are kept in across chats, in the sessions directory.
+func (c *chat) historyFile() string {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return ""
+	}
+
+	return filepath.Join(c.dir, "chat_history")
+}
+
+// chatCompleter completes the slash commands.
+func chatCompleter() *readline.PrefixCompleter {
+	return readline.NewPrefixCompleter(
+		readline.PcItem("/plan"),
+		readline.PcItem("/apply"),
+		readline.PcItem("/diff"),
+		readline.PcItem("/undo"),
+		readline.PcItem("/files"),
+		readline.PcItem("/model"),
+		readline.PcItem("/save"),
+		readline.PcItem("/help"),
+		readline.PcItem("/exit"),
+	)
+}
@@ -0,0 +1,129 @@
+package cli_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/cmd/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chatApp returns an app with fakes whose chat reads the lines, with its sessions in a temporary directory.
+// It returns the messages logged for the user too.
+func chatApp(t *testing.T, llm *fakeLLM, lines ...string) (*cli.App, *fakeOps, memFS, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+
+	app, ops, files, out := newApp(t, llm, &fakePrompter{})
+	app.Config.SessionsDir = t.TempDir()
+	app.In = strings.NewReader(strings.Join(lines, "\n") + "\n")
+
+	var logged bytes.Buffer
+
+	app.Log = log.New(&logged, "", 0)
+
+	return app, ops, files, out, &logged
+}
+
+func TestAppChatStageUndoApply(t *testing.T) {
+	private := strings.Replace(bucket, "}", "  acl    = \"private\"\n}", 1)
+	versioned := strings.Replace(bucket, "}", "  versioning {\n    enabled = true\n  }\n}", 1)
+
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf", private, versioned}}
+	app, ops, files, out, _ := chatApp(t, llm,
+		"create an s3 bucket named logs",
+		"make it private",
+		"/undo",
+		"enable versioning",
+		"/files",
+		"/diff",
+		"/apply",
+		"/files",
+	)
+
+	require.NoError(t, executeApp(app, "chat"))
+
+	// The undone request is not sent again, the template it reverted to is
+	require.Len(t, llm.prompts, 4)
+	assert.Contains(t, llm.prompts[2], "Earlier request: create an s3 bucket named logs")
+	assert.NotContains(t, llm.prompts[3], "make it private")
+	assert.Contains(t, llm.prompts[3], "Update this template:\n"+bucket)
+
+	assert.Contains(t, out.String(), "Reverted the last change, 1 pending files.")
+	assert.Contains(t, out.String(), "  new       bucket.tf\n")
+	assert.Contains(t, out.String(), "+++ bucket.tf (pending)")
+	assert.Contains(t, out.String(), "+  versioning {")
+	assert.Contains(t, out.String(), "Applied 1 files.")
+	assert.Contains(t, out.String(), "No pending changes.")
+
+	require.Contains(t, files.MapFS, "bucket.tf")
+	assert.Equal(t, versioned, string(files.MapFS["bucket.tf"].Data))
+	assert.Equal(t, []string{"apply"}, ops.calls)
+}
+
+func TestAppChatCommands(t *testing.T) {
+	app, ops, _, out, logged := chatApp(t, &fakeLLM{},
+		"/help",
+		"/model",
+		"/model gpt-4o-mini",
+		"/model",
+		"/undo",
+		"/files",
+		"/diff",
+		"/apply",
+		"/bogus",
+		"/save",
+	)
+
+	require.NoError(t, executeApp(app, "chat"))
+
+	assert.Contains(t, out.String(), "/plan          run terraform plan with the pending changes")
+	assert.Contains(t, out.String(), "gpt-4o\n")
+	assert.Contains(t, out.String(), "Switched to gpt-4o-mini (128000 tokens context window).")
+	assert.Contains(t, out.String(), "gpt-4o-mini\n")
+	assert.Contains(t, out.String(), "Nothing to undo.")
+	assert.Contains(t, out.String(), "No pending changes.")
+	assert.Contains(t, out.String(), "Nothing to apply.")
+	assert.Contains(t, logged.String(), "/bogus: unknown command, type /help for the commands")
+	assert.Empty(t, ops.calls)
+
+	// The session is saved with the switched model, next to the prompts
+	ids, err := os.ReadDir(app.Config.SessionsDir)
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	assert.Equal(t, "chat_history", ids[1].Name())
+
+	saved, err := os.ReadFile(filepath.Join(app.Config.SessionsDir, ids[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), `"model": "gpt-4o-mini"`)
+	assert.Contains(t, out.String(), "Saved session "+strings.TrimSuffix(ids[0].Name(), ".json"))
+}
+
+func TestAppChatPlan(t *testing.T) {
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf"}}
+	app, ops, _, out, _ := chatApp(t, llm, "/plan", "create an s3 bucket named logs", "/plan", "/exit", "/exit")
+	ops.plan = "Plan: 1 to add, 0 to change, 0 to destroy."
+
+	require.NoError(t, executeApp(app, "chat"))
+
+	require.Len(t, ops.plans, 2)
+	assert.Empty(t, ops.plans[0])
+	assert.Equal(t, bucket, string(ops.plans[1]["bucket.tf"]))
+	assert.Contains(t, out.String(), "Plan: 1 to add, 0 to change, 0 to destroy.")
+}
+
+func TestAppChatLeavePending(t *testing.T) {
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf"}}
+	app, ops, files, _, logged := chatApp(t, llm, "create an s3 bucket named logs", "/exit", "/files", "/exit", "/exit")
+
+	require.NoError(t, executeApp(app, "chat"))
+
+	// Leaving again right after the warning leaves, another command in between warns again
+	assert.Equal(t, 2, strings.Count(logged.String(), "1 pending files are not applied"))
+	assert.Empty(t, files.MapFS)
+	assert.Empty(t, ops.calls)
+}
@@ -0,0 +1,122 @@
+package cli_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/cmd/cli"
+	"github.com/akhilsharma90/terraform-assistant/pkg/cassette"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update records the cassettes against the Azure OpenAI endpoint in AZURE_OPENAI_ENDPOINT with OPENAI_API_KEY.
+var update = flag.Bool("update", false, "record the cassettes instead of replaying them")
+
+// fakeTerraform writes a terraform that knows one workspace and records the arguments of every other command.
+func fakeTerraform(t *testing.T) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake terraform is a shell script")
+	}
+
+	script := `#!/bin/sh
+case "$1" in
+version)
+	echo '{"terraform_version":"1.6.0","platform":"linux_amd64","provider_selections":{},"terraform_outdated":false}'
+	;;
+workspace)
+	[ "$2" = list ] && echo "* default"
+	[ "$2" = show ] && echo default
+	;;
+*)
+	echo "$@" >> "$(dirname "$0")/args"
+	;;
+esac
+exit 0
+`
+
+	execPath := filepath.Join(t.TempDir(), "terraform")
+	require.NoError(t, os.WriteFile(execPath, []byte(script), 0o700))
+
+	return execPath
+}
+
+// fakeArgs returns the commands the fake terraform ran.
+func fakeArgs(t *testing.T, execPath string) []string {
+	t.Helper()
+
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(execPath), "args"))
+	require.NoError(t, err)
+
+	return strings.Split(strings.TrimSpace(string(args)), "\n")
+}
+
+// execute runs the command in dir against the cassette, without confirmations.
+func execute(t *testing.T, dir string, execPath string, name string, args ...string) error {
+	t.Helper()
+
+	endpoint, key, mode := "https://example.openai.azure.com", "test-key", cassette.Replay
+	if *update {
+		endpoint, key, mode = os.Getenv("AZURE_OPENAI_ENDPOINT"), os.Getenv("OPENAI_API_KEY"), cassette.Record
+	}
+
+	cmd := cli.RootCmd()
+	cmd.SetArgs(append([]string{
+		"--working-dir", dir,
+		"--exec-dir", execPath,
+		"--azure-openai-endpoint", endpoint,
+		"--openai-api-key", key,
+		"--openai-deployment-name", "gpt-4o",
+		"--require-confirmation=false",
+		"--workspace=",
+		"--cassette", filepath.Join("testdata", name+".json"),
+		"--cassette-mode", string(mode),
+	}, args...))
+
+	return cmd.Execute()
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	execPath := fakeTerraform(t)
+
+	require.NoError(t, execute(t, dir, execPath, "run", "create an s3 bucket named logs"))
+
+	contents, err := os.ReadFile(filepath.Join(dir, "bucket.tf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), `resource "aws_s3_bucket" "logs"`)
+
+	assert.Equal(t, []string{"apply -no-color -auto-approve -input=false -lock=true -parallelism=10 -refresh=true -json"}, fakeArgs(t, execPath))
+}
+
+func TestRunPromptChanged(t *testing.T) {
+	if *update {
+		t.Skip("nothing to replay")
+	}
+
+	// A prompt that was not recorded fails instead of reaching the network
+	err := execute(t, t.TempDir(), fakeTerraform(t), "run", "create an s3 bucket named audit")
+	assert.ErrorIs(t, err, cassette.ErrNoInteraction)
+}
+
+func TestInit(t *testing.T) {
+	dir := t.TempDir()
+	execPath := fakeTerraform(t)
+
+	require.NoError(t, execute(t, dir, execPath, "init", "init", "create aws provider in ohio"))
+
+	contents, err := os.ReadFile(filepath.Join(dir, "provider.tf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), `region = "us-east-2"`)
+	assert.Contains(t, string(contents), `source  = "hashicorp/aws"`)
+
+	args := fakeArgs(t, execPath)
+	require.Len(t, args, 1)
+	assert.True(t, strings.HasPrefix(args[0], "init "), args[0])
+}
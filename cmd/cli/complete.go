@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/session"
+	"github.com/spf13/cobra"
+)
+
+// completionFunc completes the value of a flag or argument.
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// addCompletion creates and returns a new Cobra command for the "completion" subcommand.
+func addCompletion() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion bash|zsh|fish",
+		Short: "Generate the shell completion script",
+		Long: `Generate the completion script for bash, zsh or fish.
+
+Besides commands and flags, it completes model names, chat session IDs, workspace names
+and the .tf files of the working directory.`,
+		Example: `  # Load completions in the current bash session
+  source <(terraform-ai completion bash)
+
+  # Load completions for every zsh session
+  terraform-ai completion zsh > "${fpath[1]}/_terraform-ai"
+
+  # Load completions for every fish session
+  terraform-ai completion fish > ~/.config/fish/completions/terraform-ai.fish`,
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		// Generating the script does not need Terraform
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(os.Stdout, true)
+			case "zsh":
+				return root.GenZshCompletion(os.Stdout)
+			default:
+				return root.GenFishCompletion(os.Stdout, true)
+			}
+		},
+	}
+}
+
+// registerFlagCompletions completes the values of the global flags.
+func (a *App) registerFlagCompletions(cmd *cobra.Command) {
+	completions := map[string]completionFunc{
+		"openai-deployment-name": a.completeModels,
+		"workspace":              a.completeWorkspaces,
+		"models-file":            completeExtensions("json"),
+		"working-dir":            completeDirs,
+		"exec-dir":               completeDirs,
+		"sessions-dir":           completeDirs,
+	}
+
+	for name, complete := range completions {
+		// The flags are registered just before, so this cannot fail
+		_ = cmd.RegisterFlagCompletionFunc(name, complete)
+	}
+}
+
+// completeModels completes the models of the registry, including the mapped Azure deployments.
+func (a *App) completeModels(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	registry, err := newModelRegistry(a.Config)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return registry.Names(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSessions completes the IDs of the saved chat sessions.
+func (a *App) completeSessions(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	dir, err := a.sessionDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	ids, err := session.List(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeWorkspaces completes the workspaces of the working directory.
+func (a *App) completeWorkspaces(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// Completion runs without the pre-run hooks, so Terraform is not set up yet
+	ops, err := a.terraform()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	workspaces, _, err := ops.Workspaces(context.Background())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return workspaces, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTfFiles completes the .tf files of the working directory that are not given yet.
+func (a *App) completeTfFiles(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	paths, err := fs.Glob(a.workdir(), "*.tf")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	names := make([]string, 0, len(paths))
+
+	for _, name := range paths {
+		if !slices.Contains(args, name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeExtensions completes the files with the given extensions.
+func completeExtensions(extensions ...string) completionFunc {
+	return func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return extensions, cobra.ShellCompDirectiveFilterFileExt
+	}
+}
+
+// completeDirs completes directories.
+func completeDirs(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
@@ -3,106 +3,185 @@ package cli
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
+	"time"
 
 	openai "github.com/PullRequestInc/go-gpt3"
 	azureopenai "github.com/akhilsharma90/terraform-assistant/pkg/gpt3"
+	"github.com/akhilsharma90/terraform-assistant/pkg/models"
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/tokenizer"
+	"github.com/akhilsharma90/terraform-assistant/pkg/trace"
 	"github.com/pkg/errors"
-	gptEncoder "github.com/samber/go-gpt-3-encoder"
 )
 
-// Constant for user role
-const userRole = "user"
-
-var (
-	// Map to hold the maximum tokens allowed for different GPT models
-	maxTokensMap = map[string]int{
-		"code-davinci-002":   8001,
-		"text-davinci-003":   4097,
-		"gpt-3.5-turbo-0301": 4096,
-		"gpt-3.5-turbo":      4096,
-		"gpt-35-turbo-0301":  4096, // for azure
-		"gpt-4-0314":         8192,
-		"gpt-4-32k-0314":     8192,
-	}
-	// Error for invalid max tokens
-	errToken = errors.New("invalid max tokens")
+const (
+	// Constant for user role
+	userRole = "user"
+
+	// minCompletionTokens is the number of tokens kept free for the completion when trimming the prompt
+	minCompletionTokens = 1024
 )
 
-// Struct to hold the clients for Azure and OpenAI
+// Error for invalid max tokens
+var errToken = errors.New("invalid max tokens")
+
+// Struct to hold the clients for Azure and OpenAI and the registry of models they serve
 type oaiClients struct {
 	azureClient  azureopenai.Client
 	openAIClient openai.Client
+	models       *models.Registry
+	config       Config
+	logger       *slog.Logger
+	tracer       *trace.Tracer
+}
+
+// newModelRegistry builds the model registry from the built-in models,
+// the optional models file and the Azure deployment mapping.
+func newModelRegistry(config Config) (*models.Registry, error) {
+	registry := models.Default()
+
+	if config.ModelsFile != "" {
+		if err := registry.LoadFile(config.ModelsFile); err != nil {
+			return nil, fmt.Errorf("error loading models file: %w", err)
+		}
+	}
+
+	deployments, err := models.ParseDeploymentMap(config.AzureOpenAIMap)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing azure openai map: %w", err)
+	}
+
+	for deployment, model := range deployments {
+		if err := registry.MapDeployment(deployment, model); err != nil {
+			return nil, fmt.Errorf("error mapping azure deployment: %w", err)
+		}
+	}
+
+	return registry, nil
 }
 
 // Function to create new OpenAI and Azure clients
-func newOAIClients() (oaiClients, error) {
+func (a *App) newOAIClients() (*oaiClients, error) {
 	var (
 		oaiClient   openai.Client
 		azureClient azureopenai.Client
 		err         error
 	)
 
-	if azureOpenAIEndpoint == nil || *azureOpenAIEndpoint == "" {
-		// Create a new OpenAI client
-		oaiClient = openai.NewClient(*openAIAPIKey)
+	// Check if the OpenAI API key is provided, commands that don't call the model work without it
+	if a.Config.APIKey == "" {
+		return nil, errors.New("please provide an OpenAI key")
+	}
+
+	registry, err := newModelRegistry(a.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Config.AzureOpenAIEndpoint == "" {
+		// Create a new OpenAI client, logging, tracing or recording its requests when asked to
+		var options []openai.ClientOption
+		if a.Config.OpenAIBaseURL != "" {
+			options = append(options, openai.WithBaseURL(a.Config.OpenAIBaseURL))
+		}
+
+		if hc := a.httpClient(); hc != nil {
+			options = append(options, openai.WithHTTPClient(hc))
+		}
+
+		oaiClient = openai.NewClient(a.Config.APIKey, options...)
 	} else {
 		// Validate the deployment name
 		re := regexp.MustCompile(`^[a-zA-Z0-9]+([_-]?[a-zA-Z0-9]+)*$`)
-		if !re.MatchString(*openAIDeploymentName) {
-			return oaiClients{}, errors.New("azure openai deployment can only include alphanumeric characters, '_,-', and can't end with '_' or '-'")
+		if !re.MatchString(a.Config.DeploymentName) {
+			return nil, errors.New("azure openai deployment can only include alphanumeric characters, '_,-', and can't end with '_' or '-'")
 		}
 
-		// Create a new Azure client
-		azureClient, err = azureopenai.NewClient(*azureOpenAIEndpoint, *openAIAPIKey, *openAIDeploymentName)
+		// Create a new Azure client, logging, tracing or recording its requests when asked to
+		var options []azureopenai.ClientOption
+		if hc := a.httpClient(); hc != nil {
+			options = append(options, azureopenai.WithHTTPClient(hc))
+		}
+
+		azureClient, err = azureopenai.NewClient(a.Config.AzureOpenAIEndpoint, a.Config.APIKey, a.Config.DeploymentName, options...)
 		if err != nil {
-			return oaiClients{}, fmt.Errorf("error create Azure client: %w", err)
+			return nil, fmt.Errorf("error create Azure client: %w", err)
 		}
 	}
 
 	// Create a new oaiClients struct with the created clients
-	clients := oaiClients{
+	clients := &oaiClients{
 		azureClient:  azureClient,
 		openAIClient: oaiClient,
+		models:       registry,
+		config:       a.Config,
+		logger:       a.logger,
+		tracer:       a.tracer,
 	}
 
 	return clients, nil
 }
 
-// completion is a function that generates completions for a given prompt and deployment configuration.
-// It uses the provided OpenAI and Azure clients to make API calls for completion generation.
-func completion(ctx context.Context, client oaiClients, prompts []string, deploymentName string, subcommand string) (string, error) {
+// Complete implements LLM. It generates completions for the given prompt segments and deployment configuration.
+// It trims the lowest priority segments when the prompt does not fit in the model's context window
+// and uses the OpenAI or Azure client to make API calls for completion generation.
+func (c *oaiClients) Complete(ctx context.Context, segments []prompt.Segment, deploymentName string, subcommand string) (string, error) {
 	// Set the temperature for completion generation
-	temp := float32(*temperature)
+	temp := float32(c.config.Temperature)
 
-	// Calculate the maximum tokens allowed for the given deployment name
-	maxTokens, err := calculateMaxTokens(prompts, deploymentName)
+	// Look up the model served by the given deployment name
+	model, err := c.models.Lookup(deploymentName)
 	if err != nil {
-		return "", fmt.Errorf("error calculate max token: %w", err)
+		return "", fmt.Errorf("error looking up model: %w", err)
+	}
+
+	// Load the tokenizer used by the model
+	tk, err := tokenizer.New(model.Encoding)
+	if err != nil {
+		return "", fmt.Errorf("error loading tokenizer: %w", err)
+	}
+
+	// Drop the lowest priority segments until the prompt leaves room for the completion
+	budget := c.contextWindow(model) - promptTokens(tk, model, subcommand) - reservedTokens(model)
+
+	segments, err = prompt.Fit(segments, budget, tk.Count)
+	if err != nil {
+		return "", fmt.Errorf("error fitting prompt: %w", err)
 	}
 
 	// Build the prompt string
-	var prompt strings.Builder
-	_, err = fmt.Fprint(&prompt, subcommand)
+	var text strings.Builder
+	_, err = fmt.Fprint(&text, subcommand, prompt.Render(segments))
 	if err != nil {
 		return "", fmt.Errorf("error prompt string builder: %w", err)
 	}
 
-	// Append each prompt to the prompt string
-	for _, p := range prompts {
-		_, err = fmt.Fprintf(&prompt, "%s\n", p)
-		if err != nil {
-			return "", fmt.Errorf("error range prompt: %w", err)
-		}
+	// Calculate the maximum tokens allowed for the model
+	maxTokens, err := c.calculateMaxTokens(tk, model, text.String())
+	if err != nil {
+		return "", fmt.Errorf("error calculate max token: %w", err)
 	}
 
+	start := time.Now()
+	resp, err := c.sendCompletion(ctx, model, text, maxTokens, temp)
+	c.recordCompletion(deploymentName, model, tk, subcommand, text.String(), resp, start, err)
+
+	return resp, err
+}
+
+// sendCompletion sends the prompt to the completion or chat completion API the model is served from,
+// of OpenAI or Azure OpenAI.
+func (c *oaiClients) sendCompletion(ctx context.Context, model models.Model, text strings.Builder, maxTokens *int, temp float32) (string, error) {
 	// Check if Azure OpenAI endpoint is not set
-	if azureOpenAIEndpoint == nil || *azureOpenAIEndpoint == "" {
-		// Check if the deployment name is GPT Turbo or GPT-4
-		if isGptTurbo(deploymentName) || isGpt4(deploymentName) {
+	if c.config.AzureOpenAIEndpoint == "" {
+		// Check if the model is served from the chat completion API
+		if model.API == models.ChatAPI {
 			// Generate completion using OpenAI GptChat completion API
-			resp, err := client.openaiGptChatCompletion(ctx, prompt, maxTokens, temp)
+			resp, err := c.openaiGptChatCompletion(ctx, text, maxTokens, temp)
 			if err != nil {
 				return "", fmt.Errorf("error openai GptChat completion: %w", err)
 			}
@@ -111,7 +190,7 @@ func completion(ctx context.Context, client oaiClients, prompts []string, deploy
 		}
 
 		// Generate completion using OpenAI Gpt completion API
-		resp, err := client.openaiGptCompletion(ctx, prompt, maxTokens, temp)
+		resp, err := c.openaiGptCompletion(ctx, text, maxTokens, temp)
 		if err != nil {
 			return "", fmt.Errorf("error openai Gpt completion: %w", err)
 		}
@@ -119,10 +198,10 @@ func completion(ctx context.Context, client oaiClients, prompts []string, deploy
 		return resp, nil
 	}
 
-	// Check if the deployment name is GPT Turbo 3.5 or GPT-4
-	if isGptTurbo35(deploymentName) || isGpt4(deploymentName) {
+	// Check if the model is served from the chat completion API
+	if model.API == models.ChatAPI {
 		// Generate completion using Azure GptChat completion API
-		resp, err := client.azureGptChatCompletion(ctx, prompt, maxTokens, temp)
+		resp, err := c.azureGptChatCompletion(ctx, text, maxTokens, temp)
 		if err != nil {
 			return "", fmt.Errorf("error azure GptChat completion: %w", err)
 		}
@@ -131,7 +210,7 @@ func completion(ctx context.Context, client oaiClients, prompts []string, deploy
 	}
 
 	// Generate completion using Azure Gpt completion API
-	resp, err := client.azureGptCompletion(ctx, prompt, maxTokens, temp)
+	resp, err := c.azureGptCompletion(ctx, text, maxTokens, temp)
 	if err != nil {
 		return "", fmt.Errorf("error azure Gpt completion: %w", err)
 	}
@@ -139,55 +218,85 @@ func completion(ctx context.Context, client oaiClients, prompts []string, deploy
 	return resp, nil
 }
 
-// isGptTurbo is a function that checks deployment names.
-func isGptTurbo(deploymentName string) bool {
-	return deploymentName == "gpt-3.5-turbo-0301" || deploymentName == "gpt-3.5-turbo"
-}
+// contextWindow returns the number of tokens shared by prompt and completion,
+// which can be overridden with the max tokens flag.
+func (c *oaiClients) contextWindow(model models.Model) int {
+	if c.config.MaxTokens > 0 {
+		return c.config.MaxTokens
+	}
 
-// isGptTurbo35 is a function that checks deployment names.
-func isGptTurbo35(deploymentName string) bool {
-	return deploymentName == "gpt-35-turbo-0301" || deploymentName == "gpt-35-turbo"
+	return model.ContextWindow
 }
 
-// isGpt4 is a function that checks deployment names.
-func isGpt4(deploymentName string) bool {
-	return deploymentName == "gpt-4-0314" || deploymentName == "gpt-4-32k-0314"
+// reservedTokens returns the number of tokens kept free for the completion when trimming the prompt.
+func reservedTokens(model models.Model) int {
+	if model.MaxOutputTokens < minCompletionTokens {
+		return model.MaxOutputTokens
+	}
+
+	return minCompletionTokens
 }
 
-// calculateMaxTokens is a function that calculates the maximum tokens allowed for a given deployment name.
-func calculateMaxTokens(prompts []string, deploymentName string) (*int, error) {
-	// Get the maximum tokens allowed for the deploymentName from the maxTokensMap
-	maxTokensFinal, ok := maxTokensMap[deploymentName]
-	if !ok {
-		return nil, errors.Wrapf(errToken, "deploymentName %q not found in max tokens map", deploymentName)
+// promptTokens returns the number of tokens the text uses when sent to the model,
+// including the chat message framing for chat models.
+func promptTokens(tk tokenizer.Tokenizer, model models.Model, text string) int {
+	if model.API == models.ChatAPI {
+		return tokenizer.CountMessages(tk, model.Name, []tokenizer.Message{{Role: userRole, Content: text}})
 	}
 
-	// If a custom maxTokens value is provided, override the value from the map
-	if *maxTokens > 0 {
-		maxTokensFinal = *maxTokens
+	return tk.Count(text)
+}
+
+// calculateMaxTokens is a function that calculates the maximum tokens the model can generate for the given prompt.
+func (c *oaiClients) calculateMaxTokens(tk tokenizer.Tokenizer, model models.Model, text string) (*int, error) {
+	maxTokensFinal := c.contextWindow(model)
+	totalTokens := promptTokens(tk, model, text)
+
+	// Calculate the remaining tokens by subtracting the total tokens from the maximum tokens allowed
+	remainingTokens := maxTokensFinal - totalTokens
+	if remainingTokens <= 0 {
+		return nil, errors.Wrapf(errToken, "prompt uses %d tokens but model %q allows %d", totalTokens, model.Name, maxTokensFinal)
 	}
 
-	// Create a new gptEncoder
-	encoder, err := gptEncoder.NewEncoder()
-	if err != nil {
-		return nil, fmt.Errorf("error encode gpt: %w", err)
+	// The model cannot generate more than its max output tokens in one response
+	if remainingTokens > model.MaxOutputTokens {
+		remainingTokens = model.MaxOutputTokens
 	}
 
-	// Start at 100 since the encoder at times doesn't get it exactly correct
-	totalTokens := 100
+	return &remainingTokens, nil
+}
 
-	// Encode each prompt and calculate the total number of tokens
-	for _, prompt := range prompts {
-		tokens, err := encoder.Encode(prompt)
-		if err != nil {
-			return nil, fmt.Errorf("error encode prompt: %w", err)
+// requestSegments turns the user's request and reprompts into prompt segments.
+// The request and the latest reprompt are required, earlier reprompts are history
+// that can be dropped when the prompt does not fit.
+func requestSegments(request []string, reprompts []string) []prompt.Segment {
+	segments := make([]prompt.Segment, 0, len(request)+len(reprompts))
+	for _, r := range request {
+		segments = append(segments, prompt.Required(r))
+	}
+
+	for i, r := range reprompts {
+		priority := prompt.PriorityHistory
+		if i == len(reprompts)-1 {
+			priority = prompt.PriorityRequired
 		}
 
-		totalTokens += len(tokens)
+		segments = append(segments, prompt.Segment{Priority: priority, Text: r})
 	}
 
-	// Calculate the remaining tokens by subtracting the total tokens from the maximum tokens allowed
-	remainingTokens := maxTokensFinal - totalTokens
+	return segments
+}
 
-	return &remainingTokens, nil
+// inventorySegments lists the resources already declared in the working directory
+// so the model does not generate duplicates.
+func (a *App) inventorySegments() []prompt.Segment {
+	addresses, err := terraform.Inventory(a.Config.WorkingDir)
+	if err != nil || len(addresses) == 0 {
+		return nil
+	}
+
+	return []prompt.Segment{{
+		Priority: prompt.PriorityInventory,
+		Text:     "The workspace already declares: " + strings.Join(addresses, ", "),
+	}}
 }
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"strconv"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/cassette"
+	"github.com/spf13/pflag"
+	"github.com/walles/env"
+)
+
+// Config is the configuration of the commands, set with the global flags. Its defaults come from the environment.
+type Config struct {
+	// DeploymentName is the name of the deployment used for the model in the OpenAI service.
+	DeploymentName string
+	// MaxTokens overrides the context window in the model registry when set.
+	MaxTokens int
+	// ModelsFile is the path of a JSON file with additional models and Azure deployment mappings.
+	ModelsFile string
+	// AzureOpenAIMap maps Azure deployment names to the models they serve.
+	AzureOpenAIMap string
+	// APIKey is the API key for the OpenAI service. This is required by the commands that call the model.
+	APIKey string
+	// AzureOpenAIEndpoint is the endpoint for the Azure OpenAI service. If set, it is used instead of OpenAI.
+	AzureOpenAIEndpoint string
+	// OpenAIBaseURL is the base URL of the OpenAI API, for proxies and compatible servers.
+	OpenAIBaseURL string
+	// RequireConfirmation specifies whether to ask the user before anything is stored or run.
+	RequireConfirmation bool
+	// TUI specifies whether to review generated templates in the full-screen terminal UI when the terminal supports it.
+	TUI bool
+	// Temperature is the temperature of the model, between 0 and 1.
+	Temperature float64
+	// Verify specifies whether to check the plan of generated templates against the request before they are applied.
+	Verify bool
+	// Targeted specifies whether to plan and apply only the resources of the templates that are written,
+	// leaving the other pending changes of the working directory.
+	Targeted bool
+	// Parameterize specifies whether to lift hard-coded values of generated templates into variables and outputs.
+	Parameterize bool
+	// Workspace is the Terraform workspace commands are run in. Defaults to the current workspace.
+	Workspace string
+	// SessionsDir is the directory chat sessions are saved in. Defaults to a directory in the user config dir.
+	SessionsDir string
+	// Debug specifies whether to log the redacted model requests and responses, their timings and the terraform commands.
+	Debug bool
+	// TraceFile is the path of a JSON lines file every model call, validation step and terraform invocation is appended to.
+	TraceFile string
+	// CassetteFile is the path of a cassette the model API requests are recorded to or replayed from.
+	CassetteFile string
+	// CassetteMode is whether the cassette is recorded or replayed.
+	CassetteMode string
+	// WorkingDir is the path of the project that you want to run.
+	WorkingDir string
+	// ExecDir is the path of Terraform.
+	ExecDir string
+}
+
+// ConfigFromEnv returns the configuration set by the environment variables, with the defaults for the others.
+func ConfigFromEnv() Config {
+	return Config{
+		DeploymentName:      env.GetOr("OPENAI_DEPLOYMENT_NAME", env.String, "text-davinci-003"),
+		MaxTokens:           env.GetOr("MAX_TOKENS", strconv.Atoi, 0),
+		ModelsFile:          env.GetOr("MODELS_FILE", env.String, ""),
+		AzureOpenAIMap:      env.GetOr("AZURE_OPENAI_MAP", env.String, ""),
+		APIKey:              env.GetOr("OPENAI_API_KEY", env.String, ""),
+		AzureOpenAIEndpoint: env.GetOr("AZURE_OPENAI_ENDPOINT", env.String, ""),
+		OpenAIBaseURL:       env.GetOr("OPENAI_BASE_URL", env.String, ""),
+		RequireConfirmation: env.GetOr("REQUIRE_CONFIRMATION", strconv.ParseBool, true),
+		TUI:                 env.GetOr("TUI", strconv.ParseBool, true),
+		Temperature:         env.GetOr("TEMPERATURE", env.WithBitSize(strconv.ParseFloat, 64), 0.0),
+		Verify:              env.GetOr("VERIFY", strconv.ParseBool, true),
+		Targeted:            env.GetOr("TARGETED", strconv.ParseBool, false),
+		Parameterize:        env.GetOr("PARAMETERIZE", strconv.ParseBool, false),
+		Workspace:           env.GetOr("TF_WORKSPACE", env.String, ""),
+		SessionsDir:         env.GetOr("SESSIONS_DIR", env.String, ""),
+		Debug:               env.GetOr("DEBUG", strconv.ParseBool, false),
+		TraceFile:           env.GetOr("TRACE_FILE", env.String, ""),
+		CassetteFile:        env.GetOr("CASSETTE", env.String, ""),
+		CassetteMode:        env.GetOr("CASSETTE_MODE", env.String, string(cassette.Replay)),
+		WorkingDir:          env.GetOr("WORKING_DIR", env.String, ""),
+		ExecDir:             env.GetOr("EXEC_DIR", env.String, ""),
+	}
+}
+
+// flags returns the global flags of every command, bound to the config. The values it holds are their defaults.
+func (c *Config) flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("terraform-ai", pflag.ContinueOnError)
+
+	flags.StringVar(&c.DeploymentName, "openai-deployment-name", c.DeploymentName, "The deployment name used for the model in OpenAI service.")
+	flags.IntVar(&c.MaxTokens, "max-tokens", c.MaxTokens, "The max token will overwrite the context window in the model registry.")
+	flags.StringVar(&c.ModelsFile, "models-file", c.ModelsFile, "The path of a JSON file with additional models and Azure deployment mappings.")
+	flags.StringVar(&c.AzureOpenAIMap, "azure-openai-map", c.AzureOpenAIMap, "The mapping from Azure OpenAI deployment names to model names, as comma separated deployment=model pairs.")
+	flags.StringVar(&c.APIKey, "openai-api-key", c.APIKey, "The API key for the OpenAI service. This is required.")
+	flags.StringVar(&c.AzureOpenAIEndpoint, "azure-openai-endpoint", c.AzureOpenAIEndpoint, "The endpoint for Azure OpenAI service. If provided, Azure OpenAI service will be used instead of OpenAI service.")
+	flags.StringVar(&c.OpenAIBaseURL, "openai-base-url", c.OpenAIBaseURL, "The base URL of the OpenAI API, for proxies and OpenAI compatible servers. Defaults to https://api.openai.com/v1.")
+	flags.BoolVar(&c.RequireConfirmation, "require-confirmation", c.RequireConfirmation, "Whether to require confirmation before executing the command. Defaults to true.")
+	flags.BoolVar(&c.TUI, "tui", c.TUI, "Whether to review generated templates in a full-screen terminal UI when stdin and stdout are terminals. Defaults to true.")
+	flags.Float64Var(&c.Temperature, "temperature", c.Temperature, "The temperature to use for the model. Range is between 0 and 1. Set closer to 0 if your want output to be more deterministic but less creative. Defaults to 0.0.")
+	flags.BoolVar(&c.Verify, "verify", c.Verify, "Whether to plan generated templates and have the model check the plan against the request when they are reviewed. Defaults to true.")
+	flags.BoolVar(&c.Targeted, "targeted", c.Targeted, "Whether to plan and apply only the resources, data sources and modules of the written templates, leaving other pending changes of the working directory. Defaults to false.")
+	flags.BoolVar(&c.Parameterize, "parameterize", c.Parameterize, "Whether to lift hard-coded values of generated templates into variables and outputs. Defaults to false.")
+	flags.StringVar(&c.Workspace, "workspace", c.Workspace, "The Terraform workspace to run in. Defaults to the current workspace.")
+	flags.StringVar(&c.SessionsDir, "sessions-dir", c.SessionsDir, "The directory chat sessions are saved in. Defaults to terraform-assistant/sessions in the user config directory.")
+	flags.BoolVar(&c.Debug, "debug", c.Debug, "Whether to log the redacted model requests and responses, their timings and the terraform commands to stderr. Defaults to false.")
+	flags.StringVar(&c.TraceFile, "trace-file", c.TraceFile, "The path of a JSON lines file every model call, validation step and terraform invocation is appended to, with secrets redacted.")
+	flags.StringVar(&c.CassetteFile, "cassette", c.CassetteFile, "The path of a cassette file the model API requests and responses are recorded to or replayed from, for deterministic tests without network access.")
+	flags.StringVar(&c.CassetteMode, "cassette-mode", c.CassetteMode, "Whether to record the cassette or replay it, record or replay. Defaults to replay.")
+	flags.StringVar(&c.WorkingDir, "working-dir", c.WorkingDir, "The path of project that you want to run.")
+	flags.StringVar(&c.ExecDir, "exec-dir", c.ExecDir, "The path of Terraform.")
+
+	return flags
+}
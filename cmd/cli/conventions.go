@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/conventions"
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/pkg/errors"
+)
+
+const (
+	autoFix = "Auto-fix"
+	ignore  = "Ignore"
+)
+
+// conventionSegments turns the workspace conventions into constraints for the model.
+func conventionSegments(conv *conventions.Conventions) []prompt.Segment {
+	constraints := conv.Constraints()
+	if len(constraints) == 0 {
+		return nil
+	}
+
+	return []prompt.Segment{{
+		Priority: prompt.PriorityContext,
+		Text:     "Follow the conventions of the existing workspace:\n- " + strings.Join(constraints, "\n- "),
+	}}
+}
+
+// checkConventions checks the generated template against the workspace conventions.
+// When there are violations the user can auto-fix them, reprompt the model or ignore them.
+// It returns the template to use and, when the user chose to reprompt, the reprompt text.
+func (a *App) checkConventions(conv *conventions.Conventions, com string) (string, string, error) {
+	violations := conv.Check(com)
+	if len(violations) == 0 {
+		a.recordValidation("conventions", com, nil)
+
+		return com, "", nil
+	}
+
+	fixable := false
+	lines := make([]string, 0, len(violations))
+
+	for _, v := range violations {
+		fixable = fixable || v.Fixable()
+		lines = append(lines, v.String())
+	}
+
+	a.recordValidation("conventions", com, errors.New(strings.Join(lines, "; ")))
+
+	a.Log.Printf("\n⚠️ The template does not follow the workspace conventions:\n- %s\n", strings.Join(lines, "\n- "))
+
+	action, err := a.conventionsActionPrompt(fixable)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch action {
+	case autoFix:
+		fixed, err := conventions.Fix(com, violations)
+		if err != nil {
+			return "", "", fmt.Errorf("error fixing conventions: %w", err)
+		}
+
+		return fixed, "", nil
+	case reprompt:
+		return com, "The template must not have these convention violations:\n- " + strings.Join(lines, "\n- "), nil
+	default:
+		return com, "", nil
+	}
+}
+
+// conventionsActionPrompt asks the user what to do about convention violations.
+// Without confirmation the violations are fixed when possible.
+func (a *App) conventionsActionPrompt(fixable bool) (string, error) {
+	if !a.Config.RequireConfirmation {
+		if fixable {
+			return autoFix, nil
+		}
+
+		return ignore, nil
+	}
+
+	items := []string{reprompt, ignore}
+	if fixable {
+		items = append([]string{autoFix}, items...)
+	}
+
+	result, err := a.Prompter.Select("How would you like to handle the convention violations?", items, "")
+	if err != nil {
+		return ignore, err
+	}
+
+	return result, nil
+}
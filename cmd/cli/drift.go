@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/state"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Constant string for the drift subcommand description
+const driftSubCommand = "You are a Terraform drift analyst, only generate a JSON array with one suggestion for every drifted attribute and every deleted resource."
+
+const (
+	// adopt changes the configuration to the actual value.
+	adopt = "adopt"
+	// revert leaves the configuration, the next apply restores the configured value.
+	revert = "revert"
+)
+
+// driftHelp describes the suggestions to the model.
+const driftHelp = `Every suggestion is a JSON object with these fields:
+  "address": the address of the resource
+  "attribute": the drifted attribute, empty for a deleted resource
+  "resolution": "adopt" when the configuration should change to the actual value, "revert" when the next apply should restore the configured value
+  "change": for adopt, the Terraform HCL that replaces the attribute, or the block, in the configuration
+  "reason": one sentence on why, saying whether the configuration sets the attribute
+An attribute the configuration does not set is not reverted by the next apply, adopt it or explain that terraform ignores it.`
+
+var errDriftSuggestions = errors.New("invalid drift suggestions")
+
+// ErrDrift is returned by drift with --detailed-exitcode when resources changed outside terraform.
+var ErrDrift = errors.New("drift found")
+
+// driftExitCode is the exit code of drift with --detailed-exitcode when there is drift, like terraform plan -detailed-exitcode.
+const driftExitCode = 2
+
+// driftItem is a drifted attribute, or a deleted resource, with what to do about it.
+type driftItem struct {
+	Address string `json:"address"`
+	// Action is update when the resource changed and delete when it is gone.
+	Action    string `json:"action"`
+	Attribute string `json:"attribute,omitempty"`
+	State     any    `json:"state,omitempty"`
+	Actual    any    `json:"actual,omitempty"`
+	// Resolution is adopt or revert, empty when the model suggested nothing.
+	Resolution string `json:"resolution,omitempty"`
+	Change     string `json:"change,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// driftSuggestion is the suggestion of the model for a drifted attribute or deleted resource.
+type driftSuggestion struct {
+	Address    string `json:"address"`
+	Attribute  string `json:"attribute"`
+	Resolution string `json:"resolution"`
+	Change     string `json:"change"`
+	Reason     string `json:"reason"`
+}
+
+// addDrift creates and returns a new Cobra command for the "drift" subcommand.
+// This command reports the resources that changed outside terraform and what to do about them.
+func (a *App) addDrift() *cobra.Command {
+	var (
+		// asJSON prints the report as JSON, for scheduled jobs.
+		asJSON bool
+		// noSuggest leaves out the suggestions of the model.
+		noSuggest bool
+		// detailedExitCode exits with driftExitCode when there is drift.
+		detailedExitCode bool
+	)
+
+	driftCmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report the resources that changed outside Terraform and how to reconcile them",
+		Long: `Report the resources that changed outside Terraform and how to reconcile them.
+
+A refresh-only plan compares the state with the real infrastructure without changing either. For every
+drifted attribute the model suggests adopting the actual value in the configuration, or confirms that
+the next apply reverts it. The values terraform marks as sensitive are redacted before anything is sent
+to the model, and so are the literal values of the configuration for them and for attributes named like
+secrets, such as password or token.`,
+		Example: `  # Report the drift with suggestions
+  terraform-ai drift
+
+  # Report it as JSON from a scheduled job, which exits with 2 when there is drift
+  terraform-ai drift --json --detailed-exitcode`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return a.drift(asJSON, noSuggest, detailedExitCode)
+		},
+	}
+
+	driftCmd.Flags().BoolVar(&asJSON, "json", false, "Print the report as JSON.")
+	driftCmd.Flags().BoolVar(&noSuggest, "no-suggest", false, "Report the drift without asking the model for suggestions.")
+	driftCmd.Flags().BoolVar(&detailedExitCode, "detailed-exitcode", false, "Exit with 0 without drift, 1 on errors and 2 with drift, like terraform plan -detailed-exitcode.")
+
+	return driftCmd
+}
+
+// drift reports the drift of the current workspace, with the suggestions of the model unless noSuggest.
+// Suggestions that fail only warn, the drift is reported without them. With detailedExitCode, drift
+// is reported with ErrDrift.
+func (a *App) drift(asJSON bool, noSuggest bool, detailedExitCode bool) error {
+	ctx, cancel := a.interruptContext()
+	defer cancel()
+
+	restore, err := a.targetWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	if !asJSON {
+		a.Log.Println("🦄 Comparing the state with the real infrastructure...")
+	}
+
+	plan, err := a.ops.RefreshPlan(ctx)
+	if err != nil {
+		return fmt.Errorf("error planning refresh: %w", err)
+	}
+
+	items := driftItems(state.Drifted(plan))
+
+	if len(items) > 0 && !noSuggest {
+		if err = a.suggestDrift(ctx, items); err != nil {
+			a.Log.Printf("⚠️ Reporting the drift without suggestions: %s\n", err)
+		}
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(a.Out)
+		enc.SetIndent("", "  ")
+
+		if err = enc.Encode(items); err != nil {
+			return err
+		}
+	} else {
+		a.printDrift(items)
+	}
+
+	if detailedExitCode && len(items) > 0 {
+		return ErrDrift
+	}
+
+	return nil
+}
+
+// suggestDrift asks the model what to do about every item, with the configuration of the drifted resources.
+func (a *App) suggestDrift(ctx context.Context, items []driftItem) error {
+	llm, err := a.llm()
+	if err != nil {
+		return fmt.Errorf("error creating new OAI client: %w", err)
+	}
+
+	files, err := a.workspaceFiles()
+	if err != nil {
+		return err
+	}
+
+	com, err := llm.Complete(ctx, driftSegments(items, terraform.Blocks(files)), a.Config.DeploymentName, driftSubCommand)
+	if err != nil {
+		return fmt.Errorf("error completing drift suggestions: %w", err)
+	}
+
+	suggestions, err := parseDriftSuggestions(com)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		for _, s := range suggestions {
+			if s.Address == items[i].Address && s.Attribute == items[i].Attribute {
+				items[i].Resolution, items[i].Change, items[i].Reason = s.Resolution, strings.TrimSpace(s.Change), s.Reason
+
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// printDrift prints the report: every drifted resource with its attributes and what to do about them.
+func (a *App) printDrift(items []driftItem) {
+	if len(items) == 0 {
+		fmt.Fprintln(a.Out, "No drift, the infrastructure matches the state.")
+
+		return
+	}
+
+	var (
+		last      string
+		resources int
+	)
+
+	for _, item := range items {
+		if item.Address != last {
+			resources++
+		}
+
+		last = item.Address
+	}
+
+	fmt.Fprintf(a.Out, "%d resources changed outside Terraform.\n", resources)
+
+	last = ""
+
+	for _, item := range items {
+		if item.Address != last {
+			if item.Action == "delete" {
+				fmt.Fprintf(a.Out, "\n%s was deleted\n", item.Address)
+			} else {
+				fmt.Fprintf(a.Out, "\n%s changed\n", item.Address)
+			}
+
+			last = item.Address
+		}
+
+		if item.Attribute != "" {
+			fmt.Fprintf(a.Out, "  %s: %s → %s\n", item.Attribute, driftValue(item.State), driftValue(item.Actual))
+		}
+
+		switch item.Resolution {
+		case adopt:
+			fmt.Fprintln(a.Out, "    ✎ Adopt it in the configuration:")
+
+			for _, line := range strings.Split(item.Change, "\n") {
+				fmt.Fprintf(a.Out, "        %s\n", line)
+			}
+		case revert:
+			if item.Action == "delete" {
+				fmt.Fprintln(a.Out, "    ↺ The next apply creates it again.")
+			} else {
+				fmt.Fprintln(a.Out, "    ↺ The next apply reverts it.")
+			}
+		}
+
+		if item.Reason != "" {
+			fmt.Fprintf(a.Out, "      %s\n", item.Reason)
+		}
+	}
+}
+
+// driftItems lists the drifted attributes of the resources, and the resources that are gone.
+func driftItems(drifts []state.Drift) []driftItem {
+	items := make([]driftItem, 0, len(drifts))
+
+	for _, d := range drifts {
+		if len(d.Attributes) == 0 {
+			items = append(items, driftItem{Address: d.Address, Action: d.Action})
+		}
+
+		for _, attr := range d.Attributes {
+			items = append(items, driftItem{Address: d.Address, Action: d.Action, Attribute: attr.Attribute, State: attr.State, Actual: attr.Actual})
+		}
+	}
+
+	return items
+}
+
+// driftSegments asks for a suggestion for every item, with the configuration of the drifted resources,
+// redacted like the drift is, see terraform.RedactBlock.
+func driftSegments(items []driftItem, blocks map[string]string) []prompt.Segment {
+	b, _ := json.Marshal(items)
+
+	var config strings.Builder
+
+	seen := map[string]bool{}
+
+	for _, item := range items {
+		address := state.ConfigAddress(item.Address)
+		if block, ok := blocks[address]; ok && !seen[address] {
+			fmt.Fprintf(&config, "%s\n\n", terraform.RedactBlock(block, sensitiveAttributes(items, item.Address)))
+		}
+
+		seen[address] = true
+	}
+
+	segments := []prompt.Segment{prompt.Required(driftHelp)}
+
+	if config.Len() > 0 {
+		segments = append(segments, prompt.Segment{
+			Priority: prompt.PriorityContext,
+			Text:     "The configuration of the drifted resources:\n" + config.String(),
+		})
+	}
+
+	return append(segments, prompt.Required(fmt.Sprintf("The drift, where state is the value terraform knows and actual the real one: %s", b)))
+}
+
+// sensitiveAttributes returns the names of the top-level attributes of the resource that have drifted
+// sensitive values.
+func sensitiveAttributes(items []driftItem, address string) []string {
+	var names []string
+
+	for _, item := range items {
+		if item.Address == address && (item.State == state.Redacted || item.Actual == state.Redacted) {
+			name, _, _ := strings.Cut(item.Attribute, ".")
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// parseDriftSuggestions reads the suggestions from the model's answer, which may be surrounded by other text.
+func parseDriftSuggestions(com string) ([]driftSuggestion, error) {
+	start, end := strings.Index(com, "["), strings.LastIndex(com, "]")
+	if start < 0 || end < start {
+		return nil, errors.Wrapf(errDriftSuggestions, "no JSON array in %q", com)
+	}
+
+	var suggestions []driftSuggestion
+	if err := json.Unmarshal([]byte(com[start:end+1]), &suggestions); err != nil {
+		return nil, errors.Wrap(errDriftSuggestions, err.Error())
+	}
+
+	valid := suggestions[:0]
+
+	for _, s := range suggestions {
+		if s.Resolution == adopt || s.Resolution == revert {
+			valid = append(valid, s)
+		}
+	}
+
+	return valid, nil
+}
+
+// driftValue renders a value of the report, (not set) when it is missing.
+func driftValue(v any) string {
+	if v == nil {
+		return "(not set)"
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
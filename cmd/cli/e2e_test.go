@@ -0,0 +1,227 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	openai "github.com/PullRequestInc/go-gpt3"
+	"github.com/akhilsharma90/terraform-assistant/cmd/cli"
+	azureopenai "github.com/akhilsharma90/terraform-assistant/pkg/gpt3"
+	"github.com/akhilsharma90/terraform-assistant/pkg/openaitest"
+	"github.com/akhilsharma90/terraform-assistant/pkg/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bucket = `resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+`
+
+// executeServer runs the command in dir against the mock server, through the OpenAI API or the Azure OpenAI API.
+func executeServer(t *testing.T, server *openaitest.Server, flavor openaitest.Flavor, dir string, execPath string, model string, args ...string) error {
+	t.Helper()
+
+	endpoint, baseURL := server.URL, ""
+	if flavor == openaitest.OpenAI {
+		endpoint, baseURL = "", server.BaseURL()
+	}
+
+	cmd := cli.RootCmd()
+	cmd.SetArgs(append([]string{
+		"--working-dir", dir,
+		"--exec-dir", execPath,
+		"--azure-openai-endpoint=" + endpoint,
+		"--openai-base-url=" + baseURL,
+		"--openai-api-key", server.Key,
+		"--openai-deployment-name", model,
+		"--require-confirmation=false",
+		"--workspace=",
+		"--cassette=",
+	}, args...))
+
+	return cmd.Execute()
+}
+
+// newServer starts a mock server that requires the test key.
+func newServer(t *testing.T, replies ...openaitest.Reply) *openaitest.Server {
+	t.Helper()
+
+	server := openaitest.NewServer(t, replies...)
+	server.Key = "test-key"
+
+	return server
+}
+
+// expectPrompt checks that the prompt contains the text.
+func expectPrompt(text string) func(openaitest.Request) error {
+	return func(r openaitest.Request) error {
+		if !strings.Contains(r.Prompt, text) {
+			return fmt.Errorf("prompt does not contain %q: %s", text, r.Prompt)
+		}
+
+		return nil
+	}
+}
+
+func TestRunServer(t *testing.T) {
+	tests := []struct {
+		name   string
+		flavor openaitest.Flavor
+		model  string
+		api    openaitest.API
+	}{
+		{name: "openai chat", flavor: openaitest.OpenAI, model: "gpt-4o", api: openaitest.Chat},
+		{name: "openai completions", flavor: openaitest.OpenAI, model: "text-davinci-003", api: openaitest.Completions},
+		{name: "azure chat", flavor: openaitest.Azure, model: "gpt-4o", api: openaitest.Chat},
+		{name: "azure completions", flavor: openaitest.Azure, model: "text-davinci-003", api: openaitest.Completions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			execPath := fakeTerraform(t)
+
+			server := newServer(t,
+				openaitest.Reply{Content: bucket, Expect: expectPrompt("create an s3 bucket named logs")},
+				openaitest.Reply{Content: "bucket.tf"},
+			)
+
+			require.NoError(t, executeServer(t, server, tt.flavor, dir, execPath, tt.model, "create an s3 bucket named logs"))
+
+			contents, err := os.ReadFile(filepath.Join(dir, "bucket.tf"))
+			require.NoError(t, err)
+			assert.Equal(t, bucket, string(contents))
+
+			assert.Equal(t, []string{"apply -no-color -auto-approve -input=false -lock=true -parallelism=10 -refresh=true -json"}, fakeArgs(t, execPath))
+
+			for _, r := range server.Requests() {
+				assert.Equal(t, tt.flavor, r.Flavor)
+				assert.Equal(t, tt.api, r.API)
+
+				if tt.flavor == openaitest.Azure {
+					assert.Equal(t, tt.model, r.Deployment)
+					assert.Equal(t, "test-key", r.Header.Get("api-key"))
+				} else {
+					assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+				}
+			}
+		})
+	}
+}
+
+func TestRunServerTrace(t *testing.T) {
+	dir := t.TempDir()
+	traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+	server := newServer(t, openaitest.Reply{Content: bucket}, openaitest.Reply{Content: "bucket.tf"})
+
+	require.NoError(t, executeServer(t, server, openaitest.OpenAI, dir, fakeTerraform(t), "gpt-4o", "--trace-file", traceFile, "create an s3 bucket named logs"))
+
+	contents, err := os.ReadFile(traceFile)
+	require.NoError(t, err)
+
+	var completions []trace.Event
+
+	for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+		var event trace.Event
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+
+		if event.Kind == trace.KindModel {
+			completions = append(completions, event)
+		}
+	}
+
+	// The tokens and the cost of every completion, at the pricing of gpt-4o
+	require.Len(t, completions, 2)
+
+	attrs := completions[0].Attrs
+	inputTokens, outputTokens := attrs["prompt_tokens"].(float64), attrs["completion_tokens"].(float64)
+	assert.Positive(t, inputTokens)
+	assert.Positive(t, outputTokens)
+	assert.InDelta(t, (inputTokens*0.0025+outputTokens*0.01)/1000, attrs["cost_usd"], 1e-12)
+}
+
+func TestRunServerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		reply  openaitest.Reply
+		status int
+	}{
+		{name: "rate limited", reply: openaitest.Error(http.StatusTooManyRequests), status: http.StatusTooManyRequests},
+		{name: "server error", reply: openaitest.Error(http.StatusInternalServerError), status: http.StatusInternalServerError},
+		{name: "malformed", reply: openaitest.Reply{Malformed: true}},
+	}
+
+	for _, flavor := range []openaitest.Flavor{openaitest.OpenAI, openaitest.Azure} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", flavor, tt.name), func(t *testing.T) {
+				dir := t.TempDir()
+				execPath := fakeTerraform(t)
+				server := newServer(t, tt.reply)
+
+				err := executeServer(t, server, flavor, dir, execPath, "gpt-4o", "create an s3 bucket named logs")
+				require.Error(t, err)
+
+				switch {
+				case tt.status == 0:
+					assert.ErrorContains(t, err, "invalid json response")
+				case flavor == openaitest.OpenAI:
+					var apiErr openai.APIError
+					require.ErrorAs(t, err, &apiErr)
+					assert.Equal(t, tt.status, apiErr.StatusCode)
+				default:
+					var apiErr azureopenai.APIError
+					require.ErrorAs(t, err, &apiErr)
+					assert.Equal(t, tt.status, apiErr.StatusCode)
+				}
+
+				// Nothing is stored or applied when the model fails
+				entries, err := os.ReadDir(dir)
+				require.NoError(t, err)
+				assert.Empty(t, entries)
+
+				_, err = os.Stat(filepath.Join(filepath.Dir(execPath), "args"))
+				assert.ErrorIs(t, err, os.ErrNotExist)
+			})
+		}
+	}
+}
+
+func TestInitServer(t *testing.T) {
+	provider := `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-2"
+}
+`
+
+	for _, flavor := range []openaitest.Flavor{openaitest.OpenAI, openaitest.Azure} {
+		t.Run(string(flavor), func(t *testing.T) {
+			dir := t.TempDir()
+			execPath := fakeTerraform(t)
+			server := newServer(t, openaitest.Reply{Content: provider, Expect: expectPrompt("create aws provider in ohio")})
+
+			require.NoError(t, executeServer(t, server, flavor, dir, execPath, "gpt-4o", "init", "create aws provider in ohio"))
+
+			contents, err := os.ReadFile(filepath.Join(dir, "provider.tf"))
+			require.NoError(t, err)
+			assert.Equal(t, provider, string(contents))
+
+			args := fakeArgs(t, execPath)
+			require.Len(t, args, 1)
+			assert.True(t, strings.HasPrefix(args[0], "init "), args[0])
+		})
+	}
+}
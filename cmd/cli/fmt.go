@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Error for templates that are not formatted
+var errUnformatted = errors.New("templates are not formatted")
+
+// addFmt creates and returns a new Cobra command for the "fmt" subcommand.
+// This command formats templates in-process, so no terraform binary is needed.
+func (a *App) addFmt() *cobra.Command {
+	// check specifies whether fmt reports diffs instead of rewriting files.
+	var check bool
+
+	fmtCmd := &cobra.Command{
+		Use:   "fmt [files...]",
+		Short: "Format the Terraform templates of the working directory",
+		Example: `  # Format every template
+  terraform-ai fmt
+
+  # Fail with a diff when main.tf is not formatted, for CI
+  terraform-ai fmt --check main.tf`,
+		ValidArgsFunction: a.completeTfFiles,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return a.fmtCommand(args, check)
+		},
+	}
+
+	fmtCmd.Flags().BoolVar(&check, "check", false, "Report the templates that are not formatted with a diff instead of rewriting them.")
+
+	return fmtCmd
+}
+
+// fmtCommand formats the given files, or every .tf file in the working directory.
+// With --check it prints a diff for every file that is not formatted and fails.
+func (a *App) fmtCommand(args []string, check bool) error {
+	files, err := a.workspaceFiles()
+	if err != nil {
+		return err
+	}
+
+	names := args
+	if len(names) == 0 {
+		for name := range files {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+	}
+
+	unformatted := map[string][]byte{}
+
+	for _, name := range names {
+		contents, ok := files[filepath.Base(name)]
+		if !ok {
+			return fmt.Errorf("error formatting %s: file not found in working directory", name)
+		}
+
+		diff, err := terraform.FormatDiff(filepath.Base(name), string(contents))
+		if err != nil {
+			return err
+		}
+
+		if diff == "" {
+			continue
+		}
+
+		if check {
+			fmt.Fprint(a.Out, diff)
+		}
+
+		unformatted[filepath.Base(name)] = contents
+	}
+
+	if check {
+		if len(unformatted) > 0 {
+			return errors.Wrapf(errUnformatted, "%d of %d files need formatting", len(unformatted), len(names))
+		}
+
+		return nil
+	}
+
+	formatFiles(unformatted)
+
+	for _, name := range sortedNames(unformatted) {
+		a.Log.Printf("Formatted %s\n", name)
+	}
+
+	return a.storeFiles(unformatted)
+}
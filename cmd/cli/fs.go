@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// dirFS is a directory on disk.
+type dirFS struct {
+	fs.FS
+	dir string
+}
+
+// DirFS returns the directory on disk as the working directory of an app.
+func DirFS(dir string) FS {
+	// os.DirFS of an empty dir is rooted at /, the current directory is meant
+	if dir == "" {
+		dir = "."
+	}
+
+	return dirFS{FS: os.DirFS(dir), dir: dir}
+}
+
+// WriteFile implements FS.
+func (d dirFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(filepath.Join(d.dir, filepath.FromSlash(name)), data, perm)
+}
+
+// MkdirAll implements FS.
+func (d dirFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(filepath.Join(d.dir, filepath.FromSlash(name)), perm)
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+)
+
+// blastRadius returns a function that lists the blocks the files change in the working directory and
+// the resources, modules and outputs depending on them, for the user to see before applying. The references
+// of the templates are combined with terraform graph, which is read once, the first time an existing block
+// changes; nothing but the templates can depend on an added one. Without the graph, for example before
+// init, only the references are used. The function may be called concurrently, as the terminal UI does.
+func (a *App) blastRadius(ctx context.Context) func(files map[string][]byte) []string {
+	var (
+		mu      sync.Mutex
+		dot     string
+		graphed bool
+	)
+
+	return func(files map[string][]byte) []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		existing, err := a.workspaceFiles()
+		if err != nil {
+			return []string{fmt.Sprintf("⚠️ The blast radius could not be computed: %s", err)}
+		}
+
+		updated := withRootFiles(existing, files)
+
+		impact := terraform.BlastRadius(existing, updated, dot)
+		if len(impact.Added) == 0 && len(impact.Changed) == 0 {
+			return nil
+		}
+
+		if len(impact.Changed) > 0 && !graphed {
+			graphed = true
+
+			if dot, err = a.ops.Graph(ctx); err == nil {
+				impact = terraform.BlastRadius(existing, updated, dot)
+			}
+		}
+
+		return impactLines(impact)
+	}
+}
+
+// logImpact prints the blast radius of the files, see blastRadius.
+func (a *App) logImpact(ctx context.Context, files map[string][]byte) {
+	a.logLines(a.blastRadius(ctx)(files))
+}
+
+// logLines prints the lines after a blank line, nothing when there are none.
+func (a *App) logLines(lines []string) {
+	if len(lines) > 0 {
+		a.Log.Printf("\n%s\n", strings.Join(lines, "\n"))
+	}
+}
+
+// impactLines renders the impact, starting with a line that names the added and changed blocks.
+func impactLines(impact terraform.Impact) []string {
+	changed := strings.Join(append(append([]string{}, impact.Changed...), impact.Added...), ", ")
+	if len(impact.Affected) == 0 {
+		return []string{fmt.Sprintf("💥 Blast radius: nothing else depends on %s.", changed)}
+	}
+
+	lines := []string{fmt.Sprintf("💥 Blast radius: changing %s may update or replace:", changed)}
+
+	for _, address := range impact.Affected {
+		lines = append(lines, "  ~ "+address)
+	}
+
+	return lines
+}
+
+// withRootFiles returns the files of the working directory with the files of the root module among
+// the given ones. Nested files, such as modules, are left out.
+func withRootFiles(existing map[string][]byte, files map[string][]byte) map[string][]byte {
+	updated := maps.Clone(existing)
+
+	for name, contents := range files {
+		if !strings.ContainsAny(name, `/\`) {
+			updated[name] = contents
+		}
+	}
+
+	return updated
+}
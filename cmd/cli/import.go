@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	importSubCommand       = "You are a Terraform import generator, only generate valid Terraform 1.5+ import blocks with a resource address in to and the cloud ID in id for the described existing resources."
+	importConfigSubCommand = "You are a Terraform HCL generator, only generate valid Terraform HCL resource blocks matching these import blocks, without import blocks and provider templates."
+	cleanupSubCommand      = "You are a Terraform HCL editor, only return the given generated Terraform HCL without computed attributes and attributes set to their default values, keeping all other values unchanged."
+)
+
+// addImport creates and returns a new Cobra command for the "import" subcommand.
+// This command imports existing cloud resources with import blocks and generated config.
+func (a *App) addImport() *cobra.Command {
+	// name is the resource name used for the import address instead of one derived from the ID.
+	var name string
+
+	importCmd := &cobra.Command{
+		Use:   "import <resource type> <id> | <description>",
+		Short: "Import existing resources with import blocks and generated config",
+		Example: `  # Import a resource by type and ID
+  terraform-ai import aws_s3_bucket my-logs-bucket
+
+  # Choose the resource name
+  terraform-ai import aws_instance i-0abc123 --name web
+
+  # Describe the resources and let the model find the import blocks
+  terraform-ai import "the vpc vpc-0abc123 and its subnets"`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return a.importCommand(args, name)
+		},
+	}
+
+	importCmd.Flags().StringVar(&name, "name", "", "The resource name to import to. Defaults to a name derived from the ID.")
+
+	return importCmd
+}
+
+// importCommand handles the "import" command, given a resource type and ID or a description of the resources.
+func (a *App) importCommand(args []string, name string) error {
+	if len(args) == 0 {
+		return errors.Wrap(errLength, "resource type and id or description must be provided")
+	}
+
+	return a.importResources(args, name)
+}
+
+// importResources generates import blocks for the resources and the config matching them.
+// The config is generated by terraform plan -generate-config-out when Terraform supports it and by
+// the model otherwise. Generated config is cleaned up by the model when an OpenAI key is set.
+func (a *App) importResources(args []string, importName string) error {
+	ctx, cancel := a.interruptContext()
+	defer cancel()
+
+	restore, err := a.targetWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	// The model is only needed for descriptions and cleaning up, so importing by ID works without a key
+	llm, clientErr := a.llm()
+
+	imports, err := a.importBlocks(ctx, llm, clientErr, args, importName)
+	if err != nil {
+		return err
+	}
+
+	if err = a.checkImportTargets(imports); err != nil {
+		return err
+	}
+
+	blocks := terraform.ImportBlocks(imports)
+
+	config, err := a.ops.GenerateConfig(ctx, blocks)
+
+	switch {
+	case errors.Is(err, terraform.ErrGenerateUnsupported):
+		if clientErr != nil {
+			return fmt.Errorf("error generating config: %w", err)
+		}
+
+		a.Log.Printf("⚠️ %s, the model will write the config instead.\n", err)
+
+		config, err = llm.Complete(ctx, []prompt.Segment{prompt.Required(blocks)}, a.Config.DeploymentName, importConfigSubCommand)
+		if err != nil {
+			return fmt.Errorf("error completing import config: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("error generating config: %w", err)
+	}
+
+	config, err = terraform.CleanGeneratedConfig(config)
+	if err != nil {
+		return fmt.Errorf("error cleaning generated config: %w", err)
+	}
+
+	var (
+		action, com string
+		reprompts   []string
+		files       map[string][]byte
+	)
+
+	name := importFileName(imports)
+
+	for action != apply {
+		if action != "" {
+			reprompts = append(reprompts, action)
+		}
+
+		com = config
+
+		// Let the model drop the computed and default attributes terraform writes out
+		if clientErr == nil {
+			com, err = a.cleanupConfig(ctx, llm, config, reprompts)
+			if err != nil {
+				return err
+			}
+		} else if action != "" {
+			return fmt.Errorf("error reprompting: %w", clientErr)
+		}
+
+		files = map[string][]byte{name: []byte(terraform.Format(blocks + "\n" + com))}
+
+		action, files, err = a.reviewFiles(ctx, "🦄 Import blocks and generated config", name, files, nil)
+		if err != nil {
+			return err
+		}
+
+		if action == dontApply {
+			return nil
+		}
+	}
+
+	if err = a.checkTemplate(string(files[name])); err != nil {
+		return fmt.Errorf("error checking template: %w", err)
+	}
+
+	targets, err := a.applyTargets(ctx, files)
+	if err != nil {
+		return err
+	}
+
+	ok, err := a.confirmApply(ctx)
+	if err != nil || !ok {
+		return err
+	}
+
+	if err = a.storeFiles(files); err != nil {
+		return err
+	}
+
+	// Applying the import blocks brings the resources into the state
+	if err = a.ops.Apply(ctx, targets...); err != nil {
+		return a.applyError(err)
+	}
+
+	return nil
+}
+
+// importBlocks returns the imports for a resource type and ID, or asks the model for the imports of a description.
+func (a *App) importBlocks(ctx context.Context, llm LLM, clientErr error, args []string, importName string) ([]terraform.Import, error) {
+	if len(args) == 2 && terraform.IsResourceType(args[0]) {
+		name := importName
+		if name == "" {
+			name = terraform.ResourceName(args[1])
+		}
+
+		return []terraform.Import{{To: args[0] + "." + name, ID: args[1]}}, nil
+	}
+
+	if clientErr != nil {
+		return nil, fmt.Errorf("error creating new OAI client: %w", clientErr)
+	}
+
+	com, err := llm.Complete(ctx, append(a.inventorySegments(), requestSegments(args, nil)...), a.Config.DeploymentName, importSubCommand)
+	if err != nil {
+		return nil, fmt.Errorf("error completing import command: %w", err)
+	}
+
+	imports, err := terraform.ParseImports(com)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing import blocks: %w", err)
+	}
+
+	return imports, nil
+}
+
+// checkImportTargets makes sure none of the import addresses is already declared in the working directory.
+func (a *App) checkImportTargets(imports []terraform.Import) error {
+	addresses, err := terraform.Inventory(a.Config.WorkingDir)
+	if err != nil {
+		return fmt.Errorf("error reading workspace inventory: %w", err)
+	}
+
+	declared := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		declared[address] = true
+	}
+
+	for _, imp := range imports {
+		if declared[imp.To] {
+			return fmt.Errorf("error importing %s: the resource is already declared in the working directory", imp.To)
+		}
+	}
+
+	return nil
+}
+
+// cleanupConfig asks the model to clean up the generated config, keeping the generated config when
+// the model returns an invalid template.
+func (a *App) cleanupConfig(ctx context.Context, llm LLM, config string, reprompts []string) (string, error) {
+	com, err := llm.Complete(ctx, requestSegments([]string{config}, reprompts), a.Config.DeploymentName, cleanupSubCommand)
+	if err != nil {
+		return "", fmt.Errorf("error completing cleanup command: %w", err)
+	}
+
+	if err = a.checkTemplate(com); err != nil {
+		a.Log.Printf("⚠️ The cleaned up config is not valid, keeping the generated config: %s\n", err)
+
+		return config, nil
+	}
+
+	return com, nil
+}
+
+// importFileName names the file for the imports after the first imported resource.
+func importFileName(imports []terraform.Import) string {
+	address := imports[0].To
+	if i := strings.LastIndex(address, "["); i >= 0 {
+		address = address[:i]
+	}
+
+	return "import_" + strings.ReplaceAll(strings.TrimPrefix(address, "module."), ".", "_") + ".tf"
+}
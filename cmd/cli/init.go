@@ -1,73 +1,146 @@
 package cli
 
 import (
-	"context"
 	"fmt"
-	"log"
-	"os"
-	"os/signal"
+	"strings"
 
+	"github.com/akhilsharma90/terraform-assistant/pkg/conventions"
 	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
-	"github.com/akhilsharma90/terraform-assistant/pkg/utils"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
-// Constant string for the init subcommand description
-const initSubCommand = "You are a Terraform HCL generator, only generate valid provider Terraform HCL templates."
+const (
+	// Constant string for the init subcommand description
+	initSubCommand = "You are a Terraform HCL generator, only generate valid provider Terraform HCL templates, with a terraform block declaring the required_providers with source and version and, when asked for, the backend."
 
-// Error for invalid length
-var errLength = errors.New("invalid length")
+	// maxSettingsRetries is the number of times the model is asked to fix invalid terraform settings
+	maxSettingsRetries = 2
+)
+
+var (
+	// Error for invalid length
+	errLength = errors.New("invalid length")
+
+	// Error for conflicting init options
+	errInitOptions = errors.New("invalid init options")
+)
 
 // addInit creates and returns a new Cobra command for the "init" subcommand.
 // This command is used to run the "terraform init" command.
-func addInit() *cobra.Command {
+func (a *App) addInit() *cobra.Command {
+	// Options passed to terraform init
+	var opts terraform.InitOptions
+
 	initCmd := &cobra.Command{
-		Use:   "init",
-		Short: "Run terraform init",
-		RunE:  initCommand,
+		Use:   "init <prompt>",
+		Short: "Generate the provider and terraform settings and run terraform init",
+		Example: `  # Generate a provider
+  terraform-ai init "create aws provider in ohio"
+
+  # Generate a backend and pass its bucket to terraform init
+  terraform-ai init "aws provider with an s3 backend" --backend-config=bucket=my-state
+
+  # Move the existing state to the new backend
+  terraform-ai init "store the state in gcs" --migrate-state`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return a.initCommand(args, opts)
+		},
 	}
 
+	initCmd.Flags().StringArrayVar(&opts.BackendConfig, "backend-config", nil, "A -backend-config value passed to terraform init, a key=value pair or the path of a backend config file. Can be repeated.")
+	initCmd.Flags().BoolVar(&opts.MigrateState, "migrate-state", false, "Copy the existing state to the new backend. Asks for confirmation.")
+	initCmd.Flags().BoolVar(&opts.Reconfigure, "reconfigure", false, "Ignore the existing backend configuration and state. Asks for confirmation.")
+
 	return initCmd
 }
 
 // initCommand is a function that handles the "init" command in the CLI.
 // The function checks if the length of the args slice is 0 and returns an error if it is.
-func initCommand(_ *cobra.Command, args []string) error {
+func (a *App) initCommand(args []string, opts terraform.InitOptions) error {
 	if len(args) == 0 {
 		return errors.Wrap(errLength, "prompt must be provided")
 	}
 
-	return initCmd(args)
+	if opts.MigrateState && opts.Reconfigure {
+		return errors.Wrap(errInitOptions, "--migrate-state and --reconfigure cannot be used together")
+	}
+
+	return a.initCmd(args, opts)
 }
 
 // initCmd initializes the command for initializing the Terraform project.
-// It creates the necessary files, checks the template, and runs Terraform init.
-func initCmd(args []string) error {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+// It creates the provider, required_providers and backend configuration, checks the template, and runs Terraform init.
+func (a *App) initCmd(args []string, opts terraform.InitOptions) error {
+	ctx, cancel := a.interruptContext()
 	defer cancel()
 
-	// Create new OAI clients
-	oaiClients, err := newOAIClients()
+	// Create the model client
+	llm, err := a.llm()
 	if err != nil {
 		return fmt.Errorf("error creating new OAI client: %w", err)
 	}
 
-	var action, com string
+	// Analyze the conventions of the existing workspace so generated code follows them
+	conv, err := conventions.Analyze(a.Config.WorkingDir)
+	if err != nil {
+		return fmt.Errorf("error analyzing workspace conventions: %w", err)
+	}
+
+	// Configuring the backend is what init is for, so an existing backend is not a convention here
+	existingBackend := conv.Backend
+	conv.Backend = ""
+
+	var (
+		action, com, fix string
+		reprompts        []string
+		retries          int
+		files            map[string][]byte
+	)
+
 	for action != apply {
-		args = append(args, action)
+		// Keep the reprompt so the next completion builds on it
+		if action != "" {
+			reprompts = append(reprompts, action)
+		}
 
 		// Get completion for the current command
-		com, err = completion(ctx, oaiClients, args, *openAIDeploymentName, initSubCommand)
+		segments := append(conventionSegments(conv), requestSegments(args, reprompts)...)
+
+		com, err = llm.Complete(ctx, segments, a.Config.DeploymentName, initSubCommand)
 		if err != nil {
 			return fmt.Errorf("error completion: %w", err)
 		}
 
-		text := fmt.Sprintf("\n🦄 Attempting to apply the following template: %s", com)
-		log.Println(text)
+		// Check the template against the workspace conventions, reprompting when the user asks to
+		com, fix, err = a.checkConventions(conv, com)
+		if err != nil {
+			return err
+		}
+
+		if fix != "" {
+			action = fix
+
+			continue
+		}
+
+		// Ask the model to fix invalid backend and required_providers blocks
+		if err = a.validateSettings(com); err != nil {
+			if retries == maxSettingsRetries {
+				return fmt.Errorf("error validating template: %w", err)
+			}
+
+			retries++
+			action = fmt.Sprintf("The template is invalid, fix it: %s", err)
 
-		// Prompt user for action
-		action, err = userActionPrompt()
+			continue
+		}
+
+		// Format the template so what is shown is exactly what is stored
+		com = terraform.Format(com)
+
+		// Let the user review the template
+		action, files, err = a.reviewFiles(ctx, "🦄 Provider and terraform settings", providerFile, map[string][]byte{providerFile: []byte(com)}, nil)
 		if err != nil {
 			return err
 		}
@@ -75,22 +148,64 @@ func initCmd(args []string) error {
 		if action == dontApply {
 			return nil
 		}
+
+		com = string(files[providerFile])
 	}
 
-	// Check the template
-	if err = terraform.CheckTemplate(com); err != nil {
+	// Check the template, the user may have edited it
+	if err = a.checkTemplate(com); err != nil {
 		return fmt.Errorf("error checking template: %w", err)
 	}
 
-	// Store the template in a file
-	if err = utils.StoreFile("provider.tf", com); err != nil {
-		return fmt.Errorf("error storing file: %w", err)
+	if err = a.validateSettings(com); err != nil {
+		return fmt.Errorf("error validating template: %w", err)
+	}
+
+	ok, err := a.checkBackendChange(existingBackend, terraform.Backend(com), opts)
+	if err != nil || !ok {
+		return err
+	}
+
+	// Store the template in the working directory
+	if err = a.storeFiles(map[string][]byte{providerFile: []byte(com)}); err != nil {
+		return err
 	}
 
 	// Run Terraform init
-	if err = ops.Init(); err != nil {
+	if err = a.ops.Init(ctx, opts); err != nil {
+		if errors.Is(err, terraform.ErrBackendChanged) {
+			return fmt.Errorf("error running terraform init, rerun with --migrate-state to copy the existing state or --reconfigure to start without it: %w", err)
+		}
+
 		return fmt.Errorf("error running terraform init: %w", err)
 	}
 
 	return nil
 }
+
+// checkBackendChange makes sure the backend is declared once and asks for confirmation
+// before the state is migrated or the backend is reconfigured. It returns false when the user declined.
+func (a *App) checkBackendChange(existing string, backend string, opts terraform.InitOptions) (bool, error) {
+	if backend != "" {
+		files, err := a.workspaceFiles()
+		if err != nil {
+			return false, err
+		}
+
+		for _, name := range sortedNames(files) {
+			if name != providerFile && terraform.Backend(string(files[name])) != "" {
+				return false, fmt.Errorf("error configuring backend: %s already declares a backend, remove it first", name)
+			}
+		}
+
+		if existing != "" && existing != backend && !opts.MigrateState && !opts.Reconfigure {
+			a.Log.Printf("⚠️ The backend changes from %q to %q, terraform init needs --migrate-state or --reconfigure.\n", existing, backend)
+		}
+	}
+
+	if !opts.MigrateState && !opts.Reconfigure {
+		return true, nil
+	}
+
+	return a.confirm(fmt.Sprintf("Run terraform init %s", strings.Join(opts.Flags(), " ")))
+}
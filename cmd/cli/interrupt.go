@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/pkg/errors"
+)
+
+// interruptedExitCode is the exit code of a process stopped by an interrupt.
+const interruptedExitCode = 130
+
+// interruptContext returns a context that is cancelled on the first interrupt. Terraform gets the
+// interrupt and stops gracefully, finishing the changes in progress and releasing the state lock.
+// The second interrupt kills terraform, and the command returns the error of the killed command.
+func (a *App) interruptContext() (context.Context, context.CancelFunc) {
+	ctx, kill := terraform.WithKill(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt)
+
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+
+		a.Log.Println("\n⚠️ Interrupted, stopping gracefully so terraform releases the state lock. Interrupt again to kill it.")
+		cancel()
+
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+
+		a.Log.Println("\n⚠️ Killing terraform.")
+		kill()
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// applyError wraps the error of an apply. When the apply was interrupted, it first tells
+// which resources were applied and which were not.
+func (a *App) applyError(err error) error {
+	var interrupted *terraform.InterruptedError
+	if !errors.As(err, &interrupted) {
+		return fmt.Errorf("error applying Terraform: %w", err)
+	}
+
+	summary := interrupted.Summary
+
+	if interrupted.Killed {
+		a.Log.Println("\n⚠️ Apply killed. The state may still be locked, unlock it with terraform force-unlock.")
+	} else {
+		a.Log.Println("\n⚠️ Apply interrupted, terraform stopped and released the state lock.")
+	}
+
+	if len(summary.Applied) == 0 && len(summary.Unfinished) == 0 && len(summary.Pending) == 0 {
+		a.Log.Println("No resources were changed.")
+	}
+
+	for _, address := range summary.Applied {
+		a.Log.Printf("  ✔ %s applied\n", address)
+	}
+
+	for _, address := range summary.Unfinished {
+		a.Log.Printf("  ✘ %s not finished, check it with the provider\n", address)
+	}
+
+	for _, address := range summary.Pending {
+		a.Log.Printf("  · %s not applied\n", address)
+	}
+
+	a.Log.Println("The templates are stored, every change not listed as applied is not. Run terraform plan to see them and apply again to finish.")
+
+	return fmt.Errorf("error applying Terraform: %w", err)
+}
@@ -0,0 +1,112 @@
+//go:build !windows
+
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubbornTerraform writes a terraform script whose apply records its pid, then every interrupt
+// it gets in an interrupts file next to it, and keeps applying until it is killed.
+func stubbornTerraform(t *testing.T) string {
+	t.Helper()
+
+	script := `#!/bin/sh
+dir="$(dirname "$0")"
+case "$1" in
+version)
+  echo '{"terraform_version":"1.5.7","platform":"linux_amd64","provider_selections":{},"terraform_outdated":false}'
+  ;;
+apply)
+  trap 'echo interrupted >> "$dir/interrupts"' INT
+  echo '{"type":"planned_change","change":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"create"}}'
+  echo '{"type":"apply_start","hook":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"create"}}'
+  echo $$ > "$dir/pid"
+  while :; do sleep 0.1; done
+  ;;
+esac
+`
+
+	path := filepath.Join(t.TempDir(), "terraform")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o700))
+
+	return path
+}
+
+// waitForFile waits until the file exists and returns its contents.
+func waitForFile(t *testing.T, path string) string {
+	t.Helper()
+
+	var contents []byte
+
+	require.Eventually(t, func() bool {
+		var err error
+		contents, err = os.ReadFile(path)
+
+		return err == nil && len(contents) > 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	return string(contents)
+}
+
+func TestAppRunInterruptTwice(t *testing.T) {
+	execPath := stubbornTerraform(t)
+	dir := filepath.Dir(execPath)
+
+	llm := &fakeLLM{completions: []string{bucket, "bucket.tf"}}
+	app, _, _, _ := newApp(t, llm, &fakePrompter{})
+
+	ter, err := terraform.NewTerraform(app.Config.WorkingDir, execPath)
+	require.NoError(t, err)
+
+	app.Ops = ter
+
+	var logged bytes.Buffer
+
+	app.Log = log.New(&logged, "", 0)
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- executeApp(app, "create an s3 bucket named logs")
+	}()
+
+	pid, err := strconv.Atoi(strings.TrimSpace(waitForFile(t, filepath.Join(dir, "pid"))))
+	require.NoError(t, err)
+
+	// The first interrupt reaches terraform once, which keeps applying
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+	assert.Equal(t, "interrupted\n", waitForFile(t, filepath.Join(dir, "interrupts")))
+
+	// The second one kills it, and the command returns instead of exiting
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+
+	select {
+	case err = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the command did not return after the second interrupt")
+	}
+
+	var interrupted *terraform.InterruptedError
+
+	require.True(t, errors.As(err, &interrupted), "got %v", err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, interrupted.Killed)
+	assert.Equal(t, []string{"aws_s3_bucket.logs"}, interrupted.Summary.Unfinished)
+	assert.ErrorIs(t, syscall.Kill(pid, 0), syscall.ESRCH)
+	assert.Contains(t, logged.String(), "Apply killed.")
+}
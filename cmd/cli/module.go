@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/conventions"
+	"github.com/akhilsharma90/terraform-assistant/pkg/modules"
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Constant string for the module new subcommand description
+const moduleSubCommand = "You are a Terraform module generator, only generate valid Terraform HCL for a reusable module with variable blocks with types and descriptions for its inputs, resources using them and output blocks with descriptions, without provider templates."
+
+// addModule creates and returns a new Cobra command for the "module" subcommand.
+func (a *App) addModule() *cobra.Command {
+	moduleCmd := &cobra.Command{
+		Use:     "module",
+		Short:   "Generate reusable Terraform modules",
+		Example: `  terraform-ai module new vpc "a vpc with public and private subnets in two availability zones"`,
+	}
+
+	newCmd := &cobra.Command{
+		Use:   "new <name> <prompt>",
+		Short: "Generate a module with variables, outputs, a README and an example under ./modules",
+		Example: `  # Generate ./modules/vpc
+  terraform-ai module new vpc "a vpc with public and private subnets in two availability zones"`,
+		RunE: a.moduleNewCommand,
+	}
+
+	moduleCmd.AddCommand(newCmd)
+
+	return moduleCmd
+}
+
+// moduleNewCommand handles the "module new" command.
+func (a *App) moduleNewCommand(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return errors.Wrap(errLength, "module name and prompt must be provided")
+	}
+
+	return a.moduleNew(args[0], args[1:])
+}
+
+// moduleNew generates the module called name from the prompt and stores it under the modules directory.
+func (a *App) moduleNew(name string, args []string) error {
+	ctx, cancel := a.interruptContext()
+	defer cancel()
+
+	if _, err := fs.Stat(a.workdir(), path.Join(modules.Dir, name)); err == nil {
+		return fmt.Errorf("error creating module: %s already exists", filepath.Join(modules.Dir, name))
+	}
+
+	llm, err := a.llm()
+	if err != nil {
+		return fmt.Errorf("error creating new OAI client: %w", err)
+	}
+
+	// Analyze the conventions of the existing workspace so generated code follows them
+	conv, err := conventions.Analyze(a.Config.WorkingDir)
+	if err != nil {
+		return fmt.Errorf("error analyzing workspace conventions: %w", err)
+	}
+
+	var (
+		action, com, fix string
+		reprompts        []string
+		files            map[string][]byte
+	)
+
+	for action != apply {
+		if action != "" {
+			reprompts = append(reprompts, action)
+		}
+
+		segments := append(conventionSegments(conv), requestSegments(args, reprompts)...)
+
+		com, err = llm.Complete(ctx, segments, a.Config.DeploymentName, moduleSubCommand)
+		if err != nil {
+			return fmt.Errorf("error completing module command: %w", err)
+		}
+
+		com, fix, err = a.checkConventions(conv, com)
+		if err != nil {
+			return err
+		}
+
+		if fix != "" {
+			action = fix
+
+			continue
+		}
+
+		if err = a.checkTemplate(com); err != nil {
+			return fmt.Errorf("error checking template: %w", err)
+		}
+
+		files, err = modules.New(name, strings.Join(args, " "), com)
+		if err != nil {
+			return fmt.Errorf("error creating module: %w", err)
+		}
+
+		action, files, err = a.reviewFiles(ctx, "🦄 Module "+name, filepath.Join(modules.Dir, name, "main.tf"), files, nil)
+		if err != nil {
+			return err
+		}
+
+		if action == dontApply {
+			return nil
+		}
+	}
+
+	// A new module has nothing to apply until it is called from the workspace
+	return a.storeFiles(files)
+}
+
+// moduleSegments lists the local and registry modules of the working directory, so the model
+// calls them instead of declaring the same resources again.
+func (a *App) moduleSegments() []prompt.Segment {
+	found, err := modules.Discover(a.Config.WorkingDir)
+	if err != nil || len(found) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, 0, len(found))
+	for _, m := range found {
+		descriptions = append(descriptions, m.Describe())
+	}
+
+	return []prompt.Segment{{
+		Priority: prompt.PriorityContext,
+		Text:     "Prefer calling these existing modules over declaring raw resources:\n- " + strings.Join(descriptions, "\n- "),
+	}}
+}
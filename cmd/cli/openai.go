@@ -16,7 +16,7 @@ var errResp = errors.New("invalid response")
 // openaiGptCompletion generates a GPT-3 completion using the OpenAI API.
 func (c *oaiClients) openaiGptCompletion(ctx context.Context, prompt strings.Builder, maxTokens *int, temp float32) (string, error) {
 	// Make a completion request to the OpenAI API
-	resp, err := c.openAIClient.CompletionWithEngine(ctx, *openAIDeploymentName, openai.CompletionRequest{
+	resp, err := c.openAIClient.CompletionWithEngine(ctx, c.config.DeploymentName, openai.CompletionRequest{
 		Prompt:      []string{prompt.String()},
 		MaxTokens:   maxTokens,
 		Echo:        false,
@@ -38,7 +38,7 @@ func (c *oaiClients) openaiGptCompletion(ctx context.Context, prompt strings.Bui
 
 func (c *oaiClients) openaiGptChatCompletion(ctx context.Context, prompt strings.Builder, maxTokens *int, temp float32) (string, error) {
 	resp, err := c.openAIClient.ChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: *openAIDeploymentName,
+		Model: c.config.DeploymentName,
 		Messages: []openai.ChatCompletionRequestMessage{
 			{
 				Role:    userRole,
@@ -81,7 +81,7 @@ func (c *oaiClients) azureGptCompletion(ctx context.Context, prompt strings.Buil
 
 func (c *oaiClients) azureGptChatCompletion(ctx context.Context, prompt strings.Builder, maxTokens *int, temp float32) (string, error) {
 	resp, err := c.azureClient.ChatCompletion(ctx, azureopenai.ChatCompletionRequest{
-		Model: *openAIDeploymentName,
+		Model: c.config.DeploymentName,
 		Messages: []azureopenai.ChatCompletionRequestMessage{
 			{
 				Role:    userRole,
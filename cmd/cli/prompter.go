@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/editor"
+	"github.com/akhilsharma90/terraform-assistant/pkg/tui"
+	"github.com/manifoldco/promptui"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+// ErrNoTerminal is returned by Prompter.Review when stdin or stdout is not a terminal.
+var ErrNoTerminal = errors.New("not a terminal")
+
+// terminalPrompter prompts on the terminal, with the user's editor and the terminal UI.
+type terminalPrompter struct{}
+
+// Select implements Prompter.
+func (terminalPrompter) Select(label string, items []string, addLabel string) (string, error) {
+	var (
+		result string
+		err    error
+	)
+
+	if addLabel == "" {
+		prompt := promptui.Select{
+			Label: label,
+			Items: items,
+		}
+
+		_, result, err = prompt.Run()
+	} else {
+		prompt := promptui.SelectWithAdd{
+			Label:    label,
+			Items:    items,
+			AddLabel: addLabel,
+		}
+
+		_, result, err = prompt.Run()
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error to run prompt: %w", err)
+	}
+
+	return result, nil
+}
+
+// Confirm implements Prompter.
+func (terminalPrompter) Confirm(label string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+
+	_, err := prompt.Run()
+	if errors.Is(err, promptui.ErrAbort) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("error to run prompt: %w", err)
+	}
+
+	return true, nil
+}
+
+// Input implements Prompter.
+func (terminalPrompter) Input(label string) (string, error) {
+	prompt := promptui.Prompt{
+		Label: label,
+	}
+
+	result, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("error to run prompt: %w", err)
+	}
+
+	return result, nil
+}
+
+// Edit implements Prompter.
+func (terminalPrompter) Edit(name string, contents string) (string, error) {
+	return editor.Edit(name, contents)
+}
+
+// Review implements Prompter.
+func (terminalPrompter) Review(r tui.Review) (tui.Result, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return tui.Result{}, ErrNoTerminal
+	}
+
+	return tui.Run(r)
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/refactor"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// addRefactor creates and returns a new Cobra command for the "refactor" subcommand.
+// Its subcommands rewrite the templates of the working directory without a model.
+func (a *App) addRefactor() *cobra.Command {
+	refactorCmd := &cobra.Command{
+		Use:     "refactor",
+		Short:   "Refactor the Terraform templates of the working directory",
+		Example: `  terraform-ai refactor variables`,
+	}
+
+	variablesCmd := &cobra.Command{
+		Use:   "variables [files...]",
+		Short: "Extract hard-coded values into variables and outputs",
+		Example: `  # Parameterize every template
+  terraform-ai refactor variables
+
+  # Parameterize only web.tf
+  terraform-ai refactor variables web.tf`,
+		ValidArgsFunction: a.completeTfFiles,
+		RunE:              a.refactorVariablesCommand,
+	}
+
+	refactorCmd.AddCommand(variablesCmd)
+
+	return refactorCmd
+}
+
+// refactorVariablesCommand lifts the hard-coded values of the given files, or of every
+// .tf file in the working directory, into variables.tf, terraform.tfvars and outputs.tf.
+func (a *App) refactorVariablesCommand(_ *cobra.Command, args []string) error {
+	files, err := a.workspaceFiles()
+	if err != nil {
+		return err
+	}
+
+	res, err := refactor.Parameterize(files, args)
+	if err != nil {
+		return fmt.Errorf("error parameterizing templates: %w", err)
+	}
+
+	if len(res.Files) == 0 {
+		a.Log.Println("Nothing to parameterize.")
+
+		return nil
+	}
+
+	formatFiles(res.Files)
+	a.logFiles("", res.Files)
+
+	ok, err := a.applyConfirmation()
+	if err != nil || !ok {
+		return err
+	}
+
+	return a.storeFiles(res.Files)
+}
+
+// generatedFiles returns the formatted files to store for a generated template. With the parameterize
+// flag its hard-coded values are lifted into variables and outputs of the working directory.
+func (a *App) generatedFiles(name string, com string) (map[string][]byte, error) {
+	if !a.Config.Parameterize {
+		return map[string][]byte{name: []byte(terraform.Format(com))}, nil
+	}
+
+	files, err := a.workspaceFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	files[name] = []byte(com)
+
+	res, err := refactor.Parameterize(files, []string{name})
+	if err != nil {
+		return nil, fmt.Errorf("error parameterizing template: %w", err)
+	}
+
+	if _, ok := res.Files[name]; !ok {
+		res.Files[name] = []byte(com)
+	}
+
+	formatFiles(res.Files)
+
+	return res.Files, nil
+}
+
+// formatFiles formats every file in place, so what is shown is exactly what is stored.
+func formatFiles(files map[string][]byte) {
+	for name, contents := range files {
+		files[name] = []byte(terraform.Format(string(contents)))
+	}
+}
+
+// workspaceFiles reads the .tf files and terraform.tfvars of the working directory, keyed by name.
+func (a *App) workspaceFiles() (map[string][]byte, error) {
+	dir := a.workdir()
+
+	names, err := fs.Glob(dir, "*.tf")
+	if err != nil {
+		return nil, fmt.Errorf("error listing terraform files: %w", err)
+	}
+
+	names = append(names, refactor.TfvarsFile)
+	files := make(map[string][]byte, len(names))
+
+	for _, name := range names {
+		contents, err := fs.ReadFile(dir, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+
+		files[name] = contents
+	}
+
+	return files, nil
+}
+
+// storeFiles writes the files to the working directory, creating the directories of nested files.
+// Blank lines are removed from the contents, like utils.StoreFile does.
+func (a *App) storeFiles(files map[string][]byte) error {
+	dir := a.workdir()
+
+	for _, name := range sortedNames(files) {
+		// Modules are stored in their own directories
+		if err := dir.MkdirAll(path.Dir(filepath.ToSlash(name)), 0o755); err != nil {
+			return fmt.Errorf("error creating directory: %w", err)
+		}
+
+		contents := utils.RemoveBlankLinesFromString(string(files[name]))
+		if err := dir.WriteFile(filepath.ToSlash(name), []byte(contents), 0o600); err != nil {
+			return fmt.Errorf("error storing file: error writing file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// logFiles prints the files that are about to be stored, starting with the main template.
+func (a *App) logFiles(main string, files map[string][]byte) {
+	if contents, ok := files[main]; ok {
+		a.Log.Println(fmt.Sprintf("\n️🦄 Attempting to store the following template:\n%s", contents))
+	}
+
+	for _, name := range sortedNames(files) {
+		if name != main {
+			a.Log.Println(fmt.Sprintf("\n️🦄 Attempting to store %s:\n%s", name, files[name]))
+		}
+	}
+}
+
+// applyConfirmation asks the user to apply or not. Without confirmation it returns true.
+func (a *App) applyConfirmation() (bool, error) {
+	if !a.Config.RequireConfirmation {
+		return true, nil
+	}
+
+	result, err := a.Prompter.Select("Would you like to apply this? [Apply/Don't Apply]", []string{apply, dontApply}, "")
+	if err != nil {
+		return false, err
+	}
+
+	return result == apply, nil
+}
+
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
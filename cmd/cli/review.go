@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/tui"
+	"github.com/akhilsharma90/terraform-assistant/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// reviewFiles lets the user review the files about to be stored, in the terminal UI when it is enabled
+// and the terminal can show it, and with the action prompt otherwise. It returns the action like userActionPrompt does,
+// and the files with the edits the user made. A reprompt after edits carries the edited files,
+// so the model builds on them instead of starting over. When the request is given, the plan of the files
+// is checked against it unless verification is disabled. The blast radius of the files is shown with them.
+func (a *App) reviewFiles(ctx context.Context, title string, main string, files map[string][]byte, request []prompt.Segment) (string, map[string][]byte, error) {
+	if !a.Config.RequireConfirmation {
+		a.logFiles(main, files)
+		a.logImpact(ctx, files)
+
+		return apply, files, nil
+	}
+
+	var (
+		action string
+		edited []string
+		err    error
+	)
+
+	if !a.Config.Verify {
+		request = nil
+	}
+
+	impact := a.blastRadius(ctx)
+
+	if a.Config.TUI {
+		action, files, edited, err = a.reviewTUI(ctx, title, main, files, request, impact)
+	}
+
+	if !a.Config.TUI || errors.Is(err, ErrNoTerminal) {
+		action, files, edited, err = a.reviewPrompt(ctx, main, files, request, impact)
+	}
+
+	if err != nil || action == apply || action == dontApply || len(edited) == 0 {
+		return action, files, err
+	}
+
+	return editedReprompt(action, edited, files), files, nil
+}
+
+// reviewTUI shows the files in the terminal UI with their impact, where their plans are verified against
+// the request when it is given.
+func (a *App) reviewTUI(ctx context.Context, title string, main string, files map[string][]byte, request []prompt.Segment, impact func(map[string][]byte) []string) (string, map[string][]byte, []string, error) {
+	review := tui.Review{
+		Title:    title,
+		Main:     main,
+		Files:    files,
+		Validate: a.validateFiles,
+		Impact:   impact,
+	}
+
+	// Nested files such as modules change nothing terraform plans in the working directory
+	for name := range files {
+		if filepath.Base(name) == name {
+			review.Plan = func(files map[string][]byte) (string, error) {
+				return a.ops.Plan(ctx, files, a.targets(files)...)
+			}
+
+			if request != nil {
+				review.Verify = func(plan string) []string {
+					return a.verifyPlan(ctx, request, plan)
+				}
+			}
+
+			break
+		}
+	}
+
+	result, err := a.Prompter.Review(review)
+	if err != nil {
+		return dontApply, files, nil, err
+	}
+
+	switch result.Action {
+	case tui.Apply:
+		return apply, result.Files, result.Edited, nil
+	case tui.Reprompt:
+		return result.Reprompt, result.Files, result.Edited, nil
+	default:
+		return dontApply, result.Files, result.Edited, nil
+	}
+}
+
+// reviewPrompt prints the files and prompts for the action until the user applies, reprompts or gives up.
+// Edited files are validated again, and cannot be applied while they have diagnostics. Their impact is
+// shown again after every edit. When the request is given, the plan of the files is verified against it
+// before every prompt after a change.
+func (a *App) reviewPrompt(ctx context.Context, main string, files map[string][]byte, request []prompt.Segment, impact func(map[string][]byte) []string) (string, map[string][]byte, []string, error) {
+	files = maps.Clone(files)
+
+	var (
+		edited  []string
+		verdict []string
+		// verified is set while the verdict is of the files as they are
+		verified bool
+	)
+
+	a.logFiles(main, files)
+	a.logLines(impact(files))
+
+	for {
+		if request != nil && !verified {
+			verdict, verified = a.verifyFiles(ctx, request, files), true
+		}
+
+		action, err := a.verifiedActionPrompt(verdict)
+		if err != nil {
+			return dontApply, files, edited, err
+		}
+
+		switch action {
+		case edit:
+			name, err := a.editFile(main, files)
+			if err != nil {
+				return dontApply, files, edited, err
+			}
+
+			if name != "" {
+				a.logLines(impact(files))
+			}
+
+			if name != "" && !slices.Contains(edited, name) {
+				edited = append(edited, name)
+			}
+
+			verified = verified && name == ""
+		case apply:
+			if diagnostics := a.validateFiles(files); len(diagnostics) > 0 {
+				a.Log.Printf("⚠️ Fix the diagnostics before applying:\n- %s\n", strings.Join(diagnostics, "\n- "))
+
+				continue
+			}
+
+			return apply, files, edited, nil
+		default:
+			return action, files, edited, nil
+		}
+	}
+}
+
+// editFile opens a file in the user's editor and takes over the edits. It returns the name of the file
+// when it changed, and prints its diagnostics.
+func (a *App) editFile(main string, files map[string][]byte) (string, error) {
+	name, err := a.selectFile(main, files)
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := a.Prompter.Edit(name, string(files[name]))
+	if err != nil {
+		return "", err
+	}
+
+	if contents == string(files[name]) {
+		a.Log.Printf("No changes to %s.\n", name)
+
+		return "", nil
+	}
+
+	files[name] = []byte(contents)
+	a.Log.Printf("\n🦄 Edited %s:\n%s\n", name, contents)
+
+	for _, d := range a.validateFiles(files) {
+		a.Log.Printf("⚠️ %s\n", d)
+	}
+
+	return name, nil
+}
+
+// selectFile asks which file to edit, starting with the main file. A single file is selected right away.
+func (a *App) selectFile(main string, files map[string][]byte) (string, error) {
+	if len(files) == 1 {
+		for name := range files {
+			return name, nil
+		}
+	}
+
+	names := []string{main}
+	for _, name := range sortedNames(files) {
+		if name != main {
+			names = append(names, name)
+		}
+	}
+
+	return a.Prompter.Select("Which file would you like to edit?", names, "")
+}
+
+// editedReprompt adds the files the user edited to the reprompt, so the edits are part of the conversation.
+func editedReprompt(reprompt string, edited []string, files map[string][]byte) string {
+	var b strings.Builder
+
+	b.WriteString("I edited the template, keep my edits:\n")
+
+	for _, name := range edited {
+		fmt.Fprintf(&b, "# %s\n%s\n", name, strings.TrimSpace(string(files[name])))
+	}
+
+	b.WriteString(reprompt)
+
+	return b.String()
+}
+
+// validateFiles returns the diagnostics of the Terraform files: syntax errors and invalid terraform settings.
+func (a *App) validateFiles(files map[string][]byte) []string {
+	var diagnostics []string
+
+	for name, contents := range files {
+		if !utils.EndsWithTf(name) {
+			continue
+		}
+
+		syntax := terraform.Diagnostics(name, string(contents))
+		if len(syntax) > 0 {
+			diagnostics = append(diagnostics, syntax...)
+
+			continue
+		}
+
+		if err := terraform.ValidateSettings(string(contents)); err != nil {
+			diagnostics = append(diagnostics, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	sort.Strings(diagnostics)
+
+	var err error
+	if len(diagnostics) > 0 {
+		err = errors.New(strings.Join(diagnostics, "; "))
+	}
+
+	a.recordValidation("review", "", err)
+
+	return diagnostics
+}
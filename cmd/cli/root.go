@@ -1,100 +1,166 @@
 package cli
 
 import (
-	"flag"
+	"context"
+	"fmt"
+	"io"
 	"log"
-	"strconv"
+	"os"
 
 	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	"github.com/walles/env"
+	"golang.org/x/term"
 )
 
 const version = "0.0.2"
 
-//gettig all values from environment variables and setting our variables
-var (
-	// openAIDeploymentName is the name of the deployment used for the model in the OpenAI service.
-	openAIDeploymentName = flag.String("openai-deployment-name", env.GetOr("OPENAI_DEPLOYMENT_NAME", env.String, "text-davinci-003"), "The deployment name used for the model in OpenAI service.")
+// InitAndExecute initializes the working directory and execution directory,
+// and executes the root command, which parses the command line flags.
+func InitAndExecute(workDir string, executionDir string) {
+	app := NewApp()
+
+	// The directories from the environment take precedence, and the command line flags override them
+	if app.Config.WorkingDir == "" {
+		app.Config.WorkingDir = workDir
+	}
+
+	if app.Config.ExecDir == "" {
+		app.Config.ExecDir = executionDir
+	}
+
+	// Execute the root command
+	if err := app.Command().Execute(); err != nil {
+		// Scheduled jobs tell drift from failures by the exit code
+		if errors.Is(err, ErrDrift) {
+			os.Exit(driftExitCode)
+		}
+
+		if errors.Is(err, context.Canceled) {
+			log.Println(err)
+			os.Exit(interruptedExitCode)
+		}
+
+		log.Fatal(err)
+	}
+}
 
-	// maxTokens is the maximum number of tokens that will be used. It overrides the max tokens in the max tokens map.
-	maxTokens = flag.Int("max-tokens", env.GetOr("MAX_TOKENS", strconv.Atoi, 0), "The max token will overwrite the max tokens in the max tokens map.")
+// RootCmd returns the root command of an app configured by the environment.
+func RootCmd() *cobra.Command {
+	return NewApp().Command()
+}
 
-	// openAIAPIKey is the API key for the OpenAI service. This is required.
-	openAIAPIKey = flag.String("openai-api-key", env.GetOr("OPENAI_API_KEY", env.String, ""), "The API key for the OpenAI service. This is required.")
+// Command returns the root command for the CLI, running the commands with the app.
+func (a *App) Command() *cobra.Command {
+	//use cobra to start and create the CLI to interact with the user
+	cmd := &cobra.Command{
+		Use:   "terraform-ai <prompt>",
+		Short: "Generate and apply Terraform templates from natural language",
+		Long: `Generate Terraform templates from a prompt, review them and apply them.
+
+Every flag can also be set with its environment variable, such as OPENAI_API_KEY for --openai-api-key.`,
+		Example: `  # Generate and apply a template
+  terraform-ai "create an ec2 instance with ubuntu in us-east-2"
+
+  # Use an Azure OpenAI deployment
+  terraform-ai --azure-openai-endpoint https://example.openai.azure.com --openai-deployment-name gpt-4o "create an s3 bucket"
+
+  # Generate in the staging workspace, with hard-coded values lifted into variables
+  terraform-ai --workspace staging --parameterize "create a postgres rds instance"`,
+		Version:           version,
+		Args:              cobra.MinimumNArgs(1),
+		PersistentPreRunE: a.setup,
+		RunE:              a.runCommand, //essentially calling the runCommand which calls the run function (both in run.go file)
+		SilenceUsage:      true,
+	}
 
-	// azureOpenAIEndpoint is the endpoint for the Azure OpenAI service. If provided, Azure OpenAI service will be used instead of OpenAI service.
-	azureOpenAIEndpoint = flag.String("azure-openai-endpoint", env.GetOr("AZURE_OPENAI_ENDPOINT", env.String, ""), "The endpoint for Azure OpenAI service. If provided, Azure OpenAI service will be used instead of OpenAI service.")
+	cmd.PersistentFlags().AddFlagSet(a.Config.flags())
+	a.registerFlagCompletions(cmd)
 
-	// requireConfirmation specifies whether to require confirmation before executing the command. Defaults to true.
-	requireConfirmation = flag.Bool("require-confirmation", env.GetOr("REQUIRE_CONFIRMATION", strconv.ParseBool, true), "Whether to require confirmation before executing the command. Defaults to true.")
+	// The completion command is replaced by one limited to the supported shells
+	cmd.CompletionOptions.DisableDefaultCmd = true
 
-	// temperature is the temperature to use for the model. Range is between 0 and 1. Set closer to 0 if you want output to be more deterministic but less creative. Defaults to 0.0.
-	temperature = flag.Float64("temperature", env.GetOr("TEMPERATURE", env.WithBitSize(strconv.ParseFloat, 64), 0.0), "The temperature to use for the model. Range is between 0 and 1. Set closer to 0 if your want output to be more deterministic but less creative. Defaults to 0.0.")
+	initCmd := a.addInit()
+	cmd.AddCommand(initCmd)
 
-	// workingDir is the path of the project that you want to run.
-	workingDir = flag.String("working-dir", env.GetOr("WORKING_DIR", env.String, ""), "The path of project that you want to run.")
+	refactorCmd := a.addRefactor()
+	cmd.AddCommand(refactorCmd)
 
-	// execDir is the path of Terraform.
-	execDir = flag.String("exec-dir", env.GetOr("EXEC_DIR", env.String, ""), "The path of Terraform.")
+	fmtCmd := a.addFmt()
+	cmd.AddCommand(fmtCmd)
 
-	// ops is an instance of the terraform.Ops struct.
-	ops terraform.Ops
+	importCmd := a.addImport()
+	cmd.AddCommand(importCmd)
 
-	err error
-)
+	moduleCmd := a.addModule()
+	cmd.AddCommand(moduleCmd)
 
-// InitAndExecute initializes the working directory and execution directory,
-// parses command line flags, and executes the root command.
-func InitAndExecute(workDir string, executionDir string) {
-	flag.Parse()
-
-	//we have received workDir and executionDir in this function as args
-	//we will check if the variables for workingDir and execdir we have defined above
-	//that get values from the environment variables are empty, if yes
-	//we set their values with what's received in the args
-	// Set the working directory if not provided
-	if *workingDir == "" {
-		workingDir = &workDir
-	}
+	workspaceCmd := a.addWorkspace()
+	cmd.AddCommand(workspaceCmd)
 
-	// Set the execution directory if not provided
-	if *execDir == "" {
-		execDir = &executionDir
+	chatCmd := a.addChat()
+	cmd.AddCommand(chatCmd)
+
+	askStateCmd := a.addAskState()
+	cmd.AddCommand(askStateCmd)
+
+	stateCmd := a.addState()
+	cmd.AddCommand(stateCmd)
+
+	driftCmd := a.addDrift()
+	cmd.AddCommand(driftCmd)
+
+	completionCmd := addCompletion()
+	cmd.AddCommand(completionCmd)
+
+	return cmd
+}
+
+// setup sets up logging and the Terraform operations once the flags are parsed.
+func (a *App) setup(_ *cobra.Command, _ []string) error {
+	if err := a.setupLogging(); err != nil {
+		return err
 	}
 
-	// Check if the OpenAI API key is provided
-	if *openAIAPIKey == "" {
-		log.Fatal("Please provide an OpenAI key.")
+	if err := a.openCassette(); err != nil {
+		return err
 	}
 
-	// Execute the root command
-	if err := RootCmd().Execute(); err != nil {
-		log.Fatal(err)
+	ops, err := a.terraform()
+	if err != nil {
+		return err
 	}
+
+	a.ops = terraform.Traced(ops, a.tracer, a.logger)
+
+	return nil
 }
 
-// RootCmd returns the root command for the CLI.
-func RootCmd() *cobra.Command {
+// terraform returns the Terraform operations, run with the terraform binary unless they were given.
+func (a *App) terraform() (terraform.Ops, error) {
+	if a.Ops != nil {
+		return a.Ops, nil
+	}
+
 	//creates a new struct for Terraform (struct defined in the terraform.go file of terraform package)
 	//the struct requires working directory and exec directory
-	ops, err = terraform.NewTerraform(*workingDir, *execDir)
+	tf, err := terraform.NewTerraform(a.Config.WorkingDir, a.Config.ExecDir)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("error creating terraform: %w", err)
 	}
-//use cobra to start and create the CLI to interact with the user
-	cmd := &cobra.Command{
-		Use:          "terraform-ai",
-		Version:      version,
-		Args:         cobra.MinimumNArgs(1),
-		RunE:         runCommand, //essentially calling the runCommand which calls the run function (both in run.go file)
-		SilenceUsage: true,
+
+	if a.Config.Debug {
+		tf.SetLogger(a.logger)
 	}
 
-	cmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+	tf.SetOutput(a.Out, isTerminal(a.Out))
 
-	initCmd := addInit()
-	cmd.AddCommand(initCmd)
+	return tf, nil
+}
 
-	return cmd
+// isTerminal reports whether the writer is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+
+	return ok && term.IsTerminal(int(f.Fd()))
 }
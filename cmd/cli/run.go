@@ -1,13 +1,9 @@
 package cli
 
 import (
-	"context"
 	"fmt"
-	"log"
-	"os"
-	"os/signal"
 
-	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/conventions"
 	"github.com/akhilsharma90/terraform-assistant/pkg/utils"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -19,56 +15,99 @@ const (
 )
 
 // runCommand is a function that executes the run command.
-func runCommand(_ *cobra.Command, args []string) error {
+func (a *App) runCommand(_ *cobra.Command, args []string) error {
 	if len(args) == 0 {
 		// If the length is 0, return an error with a wrapped error message
 		return errors.Wrap(errLength, "prompt must be provided")
 	}
 
 	// Call the run function with the args
-	return run(args)
+	return a.run(args)
 }
 
 // run is a function that executes the main logic of the CLI command.
 //main business logic, takes care of everything from creating newOAIClients function
 //to calling completion function to calling userPrompt function and many other helper functions
 // It takes a slice of strings as input arguments and returns an error if any.
-func run(args []string) error {
+func (a *App) run(args []string) error {
 	// Create a context with a cancellation function that will be triggered on receiving an interrupt signal.
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := a.interruptContext()
 	defer cancel()
 
-	// Create new OAI clients.
-	oaiClients, err := newOAIClients()
+	// Create the model client.
+	llm, err := a.llm()
 	if err != nil {
 		return fmt.Errorf("error creating new OAI client: %w", err)
 	}
 
-	var action, com, name string
+	// Select the workspace to run in.
+	restore, err := a.targetWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	// Analyze the conventions of the existing workspace so generated code follows them.
+	conv, err := conventions.Analyze(a.Config.WorkingDir)
+	if err != nil {
+		return fmt.Errorf("error analyzing workspace conventions: %w", err)
+	}
+
+	var (
+		action, com, name, fix string
+		reprompts              []string
+		files                  map[string][]byte
+	)
+
 	for action != apply {
-		// Append the current action to the args slice.
-		args = append(args, action)
+		// Keep the reprompt so the next completion builds on it.
+		if action != "" {
+			reprompts = append(reprompts, action)
+		}
+
+		request := requestSegments(args, reprompts)
 
 		// Get completion for the run subcommand.
 		//this creates the content for the terraform file
-		com, err = completion(ctx, oaiClients, args, *openAIDeploymentName, runSubCommand)
+		segments := append(a.inventorySegments(), a.moduleSegments()...)
+		segments = append(segments, a.workspaceSegments(ctx)...)
+		segments = append(segments, conventionSegments(conv)...)
+
+		com, err = llm.Complete(ctx, append(segments, request...), a.Config.DeploymentName, runSubCommand)
 		if err != nil {
 			return fmt.Errorf("error completing run command: %w", err)
 		}
 
+		// Check the template against the workspace conventions, reprompting when the user asks to.
+		com, fix, err = a.checkConventions(conv, com)
+		if err != nil {
+			return err
+		}
+
+		if fix != "" {
+			action = fix
+
+			continue
+		}
+
 		// Get completion for the name subcommand.
 		//this just creates names of terraform files
-		name, err = completion(ctx, oaiClients, args, *openAIDeploymentName, nameSubCommand)
+		name, err = llm.Complete(ctx, request, a.Config.DeploymentName, nameSubCommand)
 		if err != nil {
 			return fmt.Errorf("error completing name command: %w", err)
 		}
 
-		// Print the template to be stored.
-		text := fmt.Sprintf("\n️🦄 Attempting to store the following template: %s", com)
-		log.Println(text)
+		// Get the name from the completion result.
+		name = utils.GetName(name)
 
-		// Prompt the user for an action.
-		action, err = userActionPrompt()
+		// Lift hard-coded values into variables and outputs when asked to.
+		files, err = a.generatedFiles(name, com)
+		if err != nil {
+			return err
+		}
+
+		// Let the user review the templates to be stored.
+		action, files, err = a.reviewFiles(ctx, "🦄 Generated templates", name, files, request)
 		if err != nil {
 			return err
 		}
@@ -79,24 +118,38 @@ func run(args []string) error {
 		}
 	}
 
-	// Check the template for errors.
-	if err = terraform.CheckTemplate(com); err != nil {
+	// Check the template for errors, including the edits the user made.
+	com = string(files[name])
+	if err = a.checkTemplate(com); err != nil {
 		return fmt.Errorf("error checking template: %w", err)
 	}
 
-	// Get the name from the completion result.
-	name = utils.GetName(name)
+	// Keep the resources the templates rename instead of destroying and creating them again.
+	if files, err = a.proposeMoves(files); err != nil {
+		return err
+	}
 
-	// Store the file with the given name and template.
-	err = utils.StoreFile(name, com)
+	// Scope the apply to the resources of the templates when asked to.
+	targets, err := a.applyTargets(ctx, files)
 	if err != nil {
-		return fmt.Errorf("error storing file: %w", err)
+		return err
+	}
+
+	// Applying to a production workspace needs an extra confirmation.
+	ok, err := a.confirmApply(ctx)
+	if err != nil || !ok {
+		return err
+	}
+
+	// Store the generated files in the working directory.
+	if err = a.storeFiles(files); err != nil {
+		return err
 	}
 
 	// Apply the Terraform operations.
-	err = ops.Apply()
+	err = a.ops.Apply(ctx, targets...)
 	if err != nil {
-		return fmt.Errorf("error applying Terraform: %w", err)
+		return a.applyError(err)
 	}
 
 	return nil
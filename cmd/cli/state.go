@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/state"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// statePreviewFile is the file the preview of a state operation adds to the configuration.
+const statePreviewFile = "terraform-assistant-state.tf"
+
+// Error for state operations on something that is not a resource or module address
+var errStateAddress = errors.New("invalid resource address")
+
+// stateOp is a change of the state, previewed with the block that plans the same change.
+type stateOp struct {
+	// description says what the operation does, such as "Move a to b in the state".
+	description string
+	// block is a moved or removed block the preview is planned with.
+	block string
+	run   func(ctx context.Context) (string, error)
+}
+
+// addState creates and returns a new Cobra command for the "state" subcommand.
+// Its subcommands change the state of the current workspace with safeguards.
+func (a *App) addState() *cobra.Command {
+	stateCmd := &cobra.Command{
+		Use:   "state",
+		Short: "Move and remove resources in the Terraform state with safeguards",
+		Long: `Move and remove resources in the Terraform state with safeguards.
+
+Renamed resources are destroyed and created again unless the state is told about the new name.
+Every operation previews the plan it results in first, and mv and rm back up the state before
+they change it.`,
+		Example: `  # Add moved blocks for the resources renamed in the configuration
+  terraform-ai state moved
+
+  # Move or forget a resource in the state
+  terraform-ai state mv aws_instance.web aws_instance.frontend
+  terraform-ai state rm aws_instance.legacy`,
+	}
+
+	stateCmd.AddCommand(&cobra.Command{
+		Use:   "moved",
+		Short: "Add moved blocks for the resources of the state the configuration renamed",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return a.stateMoved()
+		},
+	}, &cobra.Command{
+		Use:     "mv <from> <to>",
+		Short:   "Move a resource or module to another address in the state",
+		Example: "  terraform-ai state mv aws_instance.web aws_instance.frontend",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return a.stateMove(args[0], args[1])
+		},
+	}, &cobra.Command{
+		Use:     "rm <address>",
+		Short:   "Forget a resource or module without destroying it",
+		Example: "  terraform-ai state rm aws_instance.legacy",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return a.stateRemove(args[0])
+		},
+	})
+
+	return stateCmd
+}
+
+// stateMoved adds moved blocks for the resources and modules of the state that the configuration
+// declares under another name, once the user agrees with the plan they result in.
+func (a *App) stateMoved() error {
+	ctx, cancel := a.interruptContext()
+	defer cancel()
+
+	restore, err := a.targetWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	tfState, err := a.ops.State(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading state: %w", err)
+	}
+
+	files, err := a.workspaceFiles()
+	if err != nil {
+		return err
+	}
+
+	moves := terraform.StateMoves(stateAddresses(state.Resources(tfState)), files)
+	if len(moves) == 0 {
+		a.Log.Println("No renamed resources found.")
+
+		return nil
+	}
+
+	a.Log.Printf("\n🦄 Moved blocks for the renamed resources:\n%s", terraform.MovedBlocks(moves))
+
+	moved := map[string][]byte{terraform.MovedFile: withMoves(files[terraform.MovedFile], moves)}
+	if err = a.previewPlan(ctx, moved); err != nil {
+		return err
+	}
+
+	ok, err := a.confirm(fmt.Sprintf("Add the moved blocks to %s", terraform.MovedFile))
+	if err != nil || !ok {
+		return err
+	}
+
+	if err = a.storeFiles(moved); err != nil {
+		return err
+	}
+
+	a.Log.Printf("Added %d moved blocks to %s, the next apply moves the resources in the state.\n", len(moves), terraform.MovedFile)
+
+	return nil
+}
+
+// stateMove moves the resource or module at from to the address to in the state.
+func (a *App) stateMove(from string, to string) error {
+	for _, address := range []string{from, to} {
+		if !terraform.IsAddress(address) {
+			return errors.Wrap(errStateAddress, address)
+		}
+	}
+
+	return a.runStateOp(stateOp{
+		description: fmt.Sprintf("Move %s to %s in the state", from, to),
+		block:       terraform.MovedBlocks([]terraform.Move{{From: from, To: to}}),
+		run: func(ctx context.Context) (string, error) {
+			return a.ops.StateMove(ctx, from, to)
+		},
+	})
+}
+
+// stateRemove makes terraform forget the resource or module at the address without destroying it.
+func (a *App) stateRemove(address string) error {
+	if !terraform.IsAddress(address) {
+		return errors.Wrap(errStateAddress, address)
+	}
+
+	return a.runStateOp(stateOp{
+		description: fmt.Sprintf("Remove %s from the state without destroying it", address),
+		block:       terraform.RemovedBlock(address),
+		run: func(ctx context.Context) (string, error) {
+			return a.ops.StateRemove(ctx, address)
+		},
+	})
+}
+
+// runStateOp previews the plan the operation results in and runs it once confirmed, twice in a
+// protected workspace. The state is backed up before it is changed.
+func (a *App) runStateOp(op stateOp) error {
+	ctx, cancel := a.interruptContext()
+	defer cancel()
+
+	restore, err := a.targetWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	// Terraform refuses the block while the configuration still declares the old address,
+	// which is what the operation would then undo on the next apply
+	if err := a.previewPlan(ctx, map[string][]byte{statePreviewFile: []byte(op.block)}); err != nil {
+		return err
+	}
+
+	current, err := a.ops.Workspace(ctx)
+	if err != nil {
+		return err
+	}
+
+	ok, err := a.confirmProtectedWorkspace(current, "change its state")
+	if err != nil || !ok {
+		return err
+	}
+
+	ok, err = a.confirm(op.description)
+	if err != nil || !ok {
+		return err
+	}
+
+	backup, err := op.run(ctx)
+	if backup != "" {
+		a.Log.Printf("🦄 Backed up the state to %s\n", backup)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error changing state: %w", err)
+	}
+
+	a.Log.Println("Done, restore the backup with terraform state push if anything looks wrong.")
+
+	return nil
+}
+
+// previewPlan prints the plan of the configuration with the files added, which is what a change
+// of the state results in.
+func (a *App) previewPlan(ctx context.Context, files map[string][]byte) error {
+	plan, err := a.ops.Plan(ctx, files)
+	if err != nil {
+		return fmt.Errorf("error previewing the plan: %w", err)
+	}
+
+	a.Log.Printf("\n🦄 The plan afterwards:\n%s\n", plan)
+
+	return nil
+}
+
+// proposeMoves adds moved blocks to the files for the resources they rename in the working directory,
+// once the user agrees, so the renamed resources are not destroyed and created again.
+func (a *App) proposeMoves(files map[string][]byte) (map[string][]byte, error) {
+	existing, err := a.workspaceFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the root module has the addresses of the state
+	updated := withRootFiles(existing, files)
+
+	moves := terraform.Moves(existing, updated)
+	if len(moves) == 0 {
+		return files, nil
+	}
+
+	a.Log.Printf("\n🦄 These templates rename resources, which destroys and creates them again unless the state is told:\n%s",
+		terraform.MovedBlocks(moves))
+
+	ok, err := a.confirm(fmt.Sprintf("Add the moved blocks to %s", terraform.MovedFile))
+	if err != nil || !ok {
+		return files, err
+	}
+
+	files = maps.Clone(files)
+	files[terraform.MovedFile] = withMoves(updated[terraform.MovedFile], moves)
+
+	return files, nil
+}
+
+// withMoves returns the moved file with the moved blocks of the moves added.
+func withMoves(moved []byte, moves []terraform.Move) []byte {
+	blocks := terraform.MovedBlocks(moves)
+	if len(strings.TrimSpace(string(moved))) == 0 {
+		return []byte(blocks)
+	}
+
+	return []byte(terraform.Format(strings.TrimRight(string(moved), "\n") + "\n\n" + blocks))
+}
+
+// stateAddresses returns the addresses the configuration of the root module declares the resources
+// of the state with: managed resources without their instance keys and the modules of the others.
+func stateAddresses(resources []state.Resource) []string {
+	var addresses []string
+
+	for _, r := range resources {
+		switch {
+		case r.Module != "":
+			// module.db[0].module.rds is declared as module.db
+			parts := strings.SplitN(state.ConfigAddress(r.Module), ".", 3)
+			addresses = append(addresses, parts[0]+"."+parts[1])
+		case r.Mode == "managed":
+			addresses = append(addresses, state.ConfigAddress(r.Address))
+		}
+	}
+
+	return addresses
+}
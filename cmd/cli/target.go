@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+var errNoTargets = errors.New("the templates declare no resources, data sources or modules to target, apply them without --targeted")
+
+// targets returns the addresses to plan and apply the files with when targeted apply is on: the resources,
+// data sources and modules the templates declare in the root module. It returns nothing otherwise,
+// which plans and applies everything.
+func (a *App) targets(files map[string][]byte) []string {
+	if !a.Config.Targeted {
+		return nil
+	}
+
+	root := map[string][]byte{}
+
+	for name, contents := range files {
+		if utils.EndsWithTf(name) && !strings.ContainsAny(name, `/\`) {
+			root[name] = contents
+		}
+	}
+
+	return slices.Sorted(maps.Keys(terraform.Blocks(root)))
+}
+
+// applyTargets returns the targets of the files, see targets, and warns about the pending changes
+// of the working directory the targeted apply leaves out, which a plan without targets finds.
+// Files without targets are an error, applying them would apply everything targeting keeps out.
+func (a *App) applyTargets(ctx context.Context, files map[string][]byte) ([]string, error) {
+	if !a.Config.Targeted {
+		return nil, nil
+	}
+
+	targets := a.targets(files)
+	if len(targets) == 0 {
+		return nil, errNoTargets
+	}
+
+	plan, err := a.ops.Plan(ctx, files)
+	if err != nil {
+		a.Log.Printf("⚠️ The pending changes outside of %s could not be checked: %s\n", strings.Join(targets, ", "), err)
+
+		return targets, nil
+	}
+
+	if outside := terraform.OutsideTargets(plan, targets); len(outside) > 0 {
+		a.Log.Printf("\n⚠️ Applying only %s. These pending changes of the working directory are left for a later apply:\n  - %s\n",
+			strings.Join(targets, ", "), strings.Join(outside, "\n  - "))
+	}
+
+	return targets, nil
+}
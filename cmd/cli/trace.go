@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/models"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/tokenizer"
+	"github.com/akhilsharma90/terraform-assistant/pkg/trace"
+)
+
+// httpTimeout is the timeout of the model API requests, the default of both clients.
+const httpTimeout = 30 * time.Second
+
+// setupLogging sets the log level and opens the trace file.
+func (a *App) setupLogging() error {
+	a.logLevel.Set(slog.LevelInfo)
+	if a.Config.Debug {
+		a.logLevel.Set(slog.LevelDebug)
+	}
+
+	a.tracer = nil
+	if a.Config.TraceFile == "" {
+		return nil
+	}
+
+	tracer, err := trace.Open(a.Config.TraceFile)
+	if err != nil {
+		return err
+	}
+
+	a.tracer = tracer
+
+	return nil
+}
+
+// httpClient returns the client of the model API, which logs and traces the requests and records
+// or replays them with the cassette. Without any of them it returns nil, so the clients keep their own.
+func (a *App) httpClient() *http.Client {
+	if !a.Config.Debug && a.tracer == nil && a.recorder == nil {
+		return nil
+	}
+
+	var base http.RoundTripper
+	if a.recorder != nil {
+		base = a.recorder
+	}
+
+	return &http.Client{
+		Timeout:   httpTimeout,
+		Transport: &trace.Transport{Base: base, Tracer: a.tracer, Logger: a.logger},
+	}
+}
+
+// recordCompletion logs and traces a completion of the model that started at start, with the tokens
+// of the prompt and the completion and their cost at the pricing of the model.
+func (c *oaiClients) recordCompletion(deploymentName string, model models.Model, tk tokenizer.Tokenizer, subcommand string, text string, resp string, start time.Time, err error) {
+	inputTokens, outputTokens := promptTokens(tk, model, text), tk.Count(resp)
+	cost := model.Cost(inputTokens, outputTokens)
+
+	event := trace.Event{
+		Kind:       trace.KindModel,
+		Name:       deploymentName,
+		DurationMS: trace.Since(start),
+		Request:    text,
+		Response:   resp,
+		Error:      trace.ErrorString(err),
+		Attrs: map[string]any{
+			"model":             model.Name,
+			"api":               model.API,
+			"subcommand":        subcommand,
+			"temperature":       c.config.Temperature,
+			"prompt_tokens":     inputTokens,
+			"completion_tokens": outputTokens,
+			"cost_usd":          cost,
+		},
+	}
+
+	c.logger.Debug("model completion",
+		"deployment", deploymentName,
+		"model", model.Name,
+		"duration_ms", event.DurationMS,
+		"prompt_tokens", inputTokens,
+		"completion_tokens", outputTokens,
+		"cost_usd", cost,
+		"prompt", trace.Redact(text),
+		"completion", trace.Redact(resp),
+		"error", event.Error,
+	)
+	c.tracer.Record(event)
+}
+
+// recordValidation logs and traces a validation step of a generated template.
+func (a *App) recordValidation(name string, template string, err error) {
+	a.logger.Debug("validation", "step", name, "error", trace.ErrorString(err))
+	a.tracer.Record(trace.Event{
+		Kind:    trace.KindValidation,
+		Name:    name,
+		Request: template,
+		Error:   trace.ErrorString(err),
+	})
+}
+
+// checkTemplate checks the syntax of the template and records the check.
+func (a *App) checkTemplate(template string) error {
+	err := terraform.CheckTemplate(template)
+	a.recordValidation("check template", template, err)
+
+	return err
+}
+
+// validateSettings validates the terraform settings of the template and records the validation.
+func (a *App) validateSettings(template string) error {
+	err := terraform.ValidateSettings(template)
+	a.recordValidation("validate settings", template, err)
+
+	return err
+}
@@ -2,45 +2,52 @@ package cli
 
 import (
 	"fmt"
-
-	"github.com/manifoldco/promptui"
 )
 
 const (
+	// providerFile is the file init stores the provider and terraform settings in
+	providerFile = "provider.tf"
+
 	apply     = "Apply"
+	edit      = "Edit"
 	dontApply = "Don't Apply"
 	reprompt  = "Reprompt"
 )
 
 // userActionPrompt prompts the user for an action and returns the selected action.
-func userActionPrompt() (string, error) {
-	var (
-		result string
-		err    error
-	)
+func (a *App) userActionPrompt() (string, error) {
+	return a.verifiedActionPrompt(nil)
+}
 
+// verifiedActionPrompt prompts for an action like userActionPrompt, showing the verdict on the plan first.
+func (a *App) verifiedActionPrompt(verdict []string) (string, error) {
 	// If requireConfirmation flag is not set, return the default action as apply
-	if !*requireConfirmation {
+	if !a.Config.RequireConfirmation {
 		return apply, nil
 	}
 
-	// Create a label for the prompt
-	items := []string{apply, dontApply}
-	label := fmt.Sprintf("Would you like to apply this? [%s/%s/%s]", reprompt, items[0], items[1])
-
-	// Create a prompt with options to select apply or not apply
-	prompt := promptui.SelectWithAdd{
-		Label:    label,
-		Items:    items,
-		AddLabel: reprompt,
+	for _, line := range verdict {
+		a.Log.Println(line)
 	}
 
-	// Run the prompt and get the selected action
-	_, result, err = prompt.Run()
+	// Create a label for the prompt
+	items := []string{apply, edit, dontApply}
+	label := fmt.Sprintf("Would you like to apply this? [%s/%s/%s/%s]", reprompt, items[0], items[1], items[2])
 
+	// Prompt with options to select apply, edit or not apply, or to type a reprompt
+	result, err := a.Prompter.Select(label, items, reprompt)
 	if err != nil {
-		return dontApply, fmt.Errorf("error to run prompt: %w", err)
+		return dontApply, err
 	}
 
 	return result, nil
 }
+
+// confirm asks the user to confirm the label with y or N. Without confirmation it returns true.
+func (a *App) confirm(label string) (bool, error) {
+	if !a.Config.RequireConfirmation {
+		return true, nil
+	}
+
+	return a.Prompter.Confirm(label)
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/pkg/errors"
+)
+
+// Constant string for the verify subcommand description
+const verifySubCommand = "You are a Terraform plan reviewer, only generate a single JSON verdict on whether the plan does what the request asks for."
+
+// verifyHelp describes the verdict to the model.
+const verifyHelp = `The verdict is a JSON object with these fields:
+  "matches": true when the plan does what the request asks for and nothing else
+  "unrequested": the changes of the plan the request does not ask for, such as an extra IAM role, each with the address and why
+  "missing": what the request asks for that the plan does not do, such as a missing resource or the wrong region
+  "suspicious_destroys": the resources the plan destroys or replaces that the request does not ask to remove, each with the address and why
+  "summary": one sentence on the verdict
+Resources the request needs to work, such as a policy attachment for a requested role, are requested.`
+
+var errVerdict = errors.New("invalid verdict")
+
+// verdict is the verifier's judgement of whether the plan does what the request asks for.
+type verdict struct {
+	Matches            bool     `json:"matches"`
+	Unrequested        []string `json:"unrequested"`
+	Missing            []string `json:"missing"`
+	SuspiciousDestroys []string `json:"suspicious_destroys"`
+	Summary            string   `json:"summary"`
+}
+
+// lines renders the verdict for the user, starting with a line that says whether the plan matches.
+func (v verdict) lines() []string {
+	mismatches := len(v.Unrequested) + len(v.Missing) + len(v.SuspiciousDestroys)
+	if v.Matches && mismatches == 0 {
+		return []string{strings.TrimSpace("✔ The plan matches the request. " + v.Summary)}
+	}
+
+	lines := []string{strings.TrimSpace("⚠️ The plan may not match the request. " + v.Summary)}
+
+	for _, item := range v.Unrequested {
+		lines = append(lines, "  + Not requested: "+item)
+	}
+
+	for _, item := range v.Missing {
+		lines = append(lines, "  ? Missing: "+item)
+	}
+
+	for _, item := range v.SuspiciousDestroys {
+		lines = append(lines, "  ✘ Suspicious destroy: "+item)
+	}
+
+	return lines
+}
+
+// verifyFiles plans the files and returns the verdict of the verifier on the plan, see verifyPlan.
+// Nested files, such as modules, plan nothing and are not verified.
+func (a *App) verifyFiles(ctx context.Context, request []prompt.Segment, files map[string][]byte) []string {
+	for name := range files {
+		if strings.ContainsAny(name, `/\`) {
+			return nil
+		}
+	}
+
+	a.Log.Println("🦄 Verifying the plan against the request...")
+
+	plan, err := a.ops.Plan(ctx, files, a.targets(files)...)
+	if err != nil {
+		return []string{fmt.Sprintf("⚠️ The plan could not be verified: %s", err)}
+	}
+
+	return a.verifyPlan(ctx, request, plan)
+}
+
+// verifyPlan asks the verifier whether the plan does what the request asks for, and returns its verdict
+// for the user. A verifier that fails only warns, the user reviews the files anyway.
+func (a *App) verifyPlan(ctx context.Context, request []prompt.Segment, plan string) []string {
+	llm, err := a.llm()
+	if err != nil {
+		return []string{fmt.Sprintf("⚠️ The plan could not be verified: error creating new OAI client: %s", err)}
+	}
+
+	com, err := llm.Complete(ctx, verifySegments(request, plan), a.Config.DeploymentName, verifySubCommand)
+	if err != nil {
+		return []string{fmt.Sprintf("⚠️ The plan could not be verified: error completing verdict: %s", err)}
+	}
+
+	v, err := parseVerdict(com)
+	if err != nil {
+		return []string{fmt.Sprintf("⚠️ The plan could not be verified: %s", err)}
+	}
+
+	return v.lines()
+}
+
+// verifySegments asks for the verdict on the compact plan for the request.
+func verifySegments(request []prompt.Segment, plan string) []prompt.Segment {
+	segments := []prompt.Segment{
+		prompt.Required(verifyHelp),
+		prompt.Required("The plan:\n" + terraform.CompactPlan(plan)),
+		prompt.Required("The request, with the changes asked for after it:"),
+	}
+
+	return append(segments, request...)
+}
+
+// parseVerdict reads the verdict from the model's answer, which may be surrounded by other text.
+func parseVerdict(com string) (verdict, error) {
+	start, end := strings.Index(com, "{"), strings.LastIndex(com, "}")
+	if start < 0 || end < start {
+		return verdict{}, errors.Wrapf(errVerdict, "no JSON object in %q", com)
+	}
+
+	var v verdict
+	if err := json.Unmarshal([]byte(com[start:end+1]), &v); err != nil {
+		return verdict{}, errors.Wrap(errVerdict, err.Error())
+	}
+
+	return v, nil
+}
@@ -0,0 +1,298 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Constant string for the workspace subcommand description
+const workspaceSubCommand = "You are a Terraform workspace command generator, only generate a single line with one of: list, show, select <name>, new <name>, delete <name>."
+
+// Error for workspace operations that cannot be run
+var errWorkspace = errors.New("invalid workspace operation")
+
+// workspaceName matches the workspace names that can be created.
+var workspaceName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// workspaceOp is a workspace operation: list, show, select, new or delete, and the workspace it is for.
+type workspaceOp struct {
+	command string
+	name    string
+}
+
+// String renders the operation as the terraform command it runs.
+func (op workspaceOp) String() string {
+	return strings.TrimSpace("terraform workspace " + op.command + " " + op.name)
+}
+
+// addWorkspace creates and returns a new Cobra command for the "workspace" subcommand.
+// Workspaces are managed with its subcommands or with a prompt in natural language.
+func (a *App) addWorkspace() *cobra.Command {
+	workspaceCmd := &cobra.Command{
+		Use:   "workspace <prompt>",
+		Short: "Manage Terraform workspaces with a subcommand or a prompt",
+		Example: `  # Let the model pick the operation
+  terraform-ai workspace "switch to the staging workspace"
+
+  # Run an operation directly
+  terraform-ai workspace new feature-x
+  terraform-ai workspace select staging`,
+		RunE: a.workspaceCommand,
+	}
+
+	for _, sub := range []struct {
+		use, short string
+		args       cobra.PositionalArgs
+		complete   completionFunc
+	}{
+		{"list", "List the workspaces", cobra.NoArgs, nil},
+		{"show", "Show the current workspace", cobra.NoArgs, nil},
+		{"select <name>", "Select a workspace", cobra.ExactArgs(1), a.completeWorkspaces},
+		{"new <name>", "Create a workspace and select it", cobra.ExactArgs(1), nil},
+		{"delete <name>", "Delete a workspace, protected workspaces ask for their name first", cobra.ExactArgs(1), a.completeWorkspaces},
+	} {
+		command := strings.Fields(sub.use)[0]
+
+		workspaceCmd.AddCommand(&cobra.Command{
+			Use:               sub.use,
+			Short:             sub.short,
+			Example:           "  terraform-ai workspace " + strings.ReplaceAll(sub.use, "<name>", "staging"),
+			Args:              sub.args,
+			ValidArgsFunction: sub.complete,
+			RunE: func(_ *cobra.Command, args []string) error {
+				ctx, cancel := a.interruptContext()
+				defer cancel()
+
+				return a.runWorkspaceOp(ctx, workspaceOp{command: command, name: strings.Join(args, "")})
+			},
+		})
+	}
+
+	return workspaceCmd
+}
+
+// workspaceCommand asks the model which workspace operation the prompt describes and runs it once confirmed.
+func (a *App) workspaceCommand(_ *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return errors.Wrap(errLength, "prompt must be provided")
+	}
+
+	ctx, cancel := a.interruptContext()
+	defer cancel()
+
+	llm, err := a.llm()
+	if err != nil {
+		return fmt.Errorf("error creating new OAI client: %w", err)
+	}
+
+	var (
+		action    string
+		reprompts []string
+		op        workspaceOp
+	)
+
+	for action != apply {
+		if action != "" {
+			reprompts = append(reprompts, action)
+		}
+
+		segments := append(a.workspaceSegments(ctx), requestSegments(args, reprompts)...)
+
+		com, err := llm.Complete(ctx, segments, a.Config.DeploymentName, workspaceSubCommand)
+		if err != nil {
+			return fmt.Errorf("error completing workspace command: %w", err)
+		}
+
+		op, err = parseWorkspaceOp(com)
+		if err != nil {
+			return err
+		}
+
+		a.Log.Printf("\n🦄 Attempting to run: %s\n", op)
+
+		action, err = a.userActionPrompt()
+		if err != nil {
+			return err
+		}
+
+		// Let the user fix the command by hand instead of reprompting
+		for action == edit {
+			edited, err := a.Prompter.Edit("workspace.sh", op.String())
+			if err != nil {
+				return err
+			}
+
+			if op, err = parseWorkspaceOp(edited); err != nil {
+				return err
+			}
+
+			a.Log.Printf("\n🦄 Attempting to run: %s\n", op)
+
+			if action, err = a.userActionPrompt(); err != nil {
+				return err
+			}
+		}
+
+		if action == dontApply {
+			return nil
+		}
+	}
+
+	return a.runWorkspaceOp(ctx, op)
+}
+
+// parseWorkspaceOp reads the operation from the model's answer, which may be wrapped in a terraform workspace command.
+func parseWorkspaceOp(com string) (workspaceOp, error) {
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(com), "`"))
+	if len(fields) >= 2 && fields[0] == "terraform" && fields[1] == "workspace" {
+		fields = fields[2:]
+	}
+
+	if len(fields) == 0 {
+		return workspaceOp{}, errors.Wrap(errWorkspace, "no operation found")
+	}
+
+	op := workspaceOp{command: strings.ToLower(fields[0])}
+
+	switch op.command {
+	case "list", "show":
+		if len(fields) != 1 {
+			return workspaceOp{}, errors.Wrapf(errWorkspace, "%s takes no workspace: %s", op.command, com)
+		}
+	case "select", "new", "delete":
+		if len(fields) != 2 || !workspaceName.MatchString(fields[1]) {
+			return workspaceOp{}, errors.Wrapf(errWorkspace, "%s takes a workspace name: %s", op.command, com)
+		}
+
+		op.name = fields[1]
+	default:
+		return workspaceOp{}, errors.Wrapf(errWorkspace, "unknown operation: %s", com)
+	}
+
+	return op, nil
+}
+
+// runWorkspaceOp runs the workspace operation. Deleting a protected workspace needs an extra confirmation.
+func (a *App) runWorkspaceOp(ctx context.Context, op workspaceOp) error {
+	switch op.command {
+	case "list":
+		workspaces, current, err := a.ops.Workspaces(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range workspaces {
+			if name == current {
+				fmt.Fprintf(a.Out, "* %s\n", name)
+			} else {
+				fmt.Fprintf(a.Out, "  %s\n", name)
+			}
+		}
+
+		return nil
+	case "show":
+		current, err := a.ops.Workspace(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(a.Out, current)
+
+		return nil
+	case "select":
+		return a.ops.SelectWorkspace(ctx, op.name)
+	case "new":
+		return a.ops.NewWorkspace(ctx, op.name)
+	case "delete":
+		ok, err := a.confirmProtectedWorkspace(op.name, "delete it")
+		if err != nil || !ok {
+			return err
+		}
+
+		return a.ops.DeleteWorkspace(ctx, op.name)
+	default:
+		return errors.Wrapf(errWorkspace, "unknown operation: %s", op.command)
+	}
+}
+
+// targetWorkspace selects the workspace given with the workspace flag for the command. The function it
+// returns selects the previous workspace again once the command is done, so the working directory is
+// left in the workspace the user chose.
+func (a *App) targetWorkspace(ctx context.Context) (func(), error) {
+	if a.Config.Workspace == "" {
+		return func() {}, nil
+	}
+
+	previous, err := a.ops.Workspace(ctx)
+	if err != nil {
+		return func() {}, fmt.Errorf("error reading workspace: %w", err)
+	}
+
+	if previous == a.Config.Workspace {
+		return func() {}, nil
+	}
+
+	if err = a.ops.SelectWorkspace(ctx, a.Config.Workspace); err != nil {
+		return func() {}, fmt.Errorf("error selecting workspace %s: %w", a.Config.Workspace, err)
+	}
+
+	return func() {
+		// Also after an interrupt, which cancels the context of the command
+		if err := a.ops.SelectWorkspace(context.WithoutCancel(ctx), previous); err != nil {
+			a.Log.Printf("⚠️ The working directory is left in workspace %s, selecting %s again failed: %s\n", a.Config.Workspace, previous, err)
+		}
+	}, nil
+}
+
+// workspaceSegments tells the model about the current workspace once the working directory
+// uses workspaces, so names can include terraform.workspace.
+func (a *App) workspaceSegments(ctx context.Context) []prompt.Segment {
+	workspaces, current, err := a.ops.Workspaces(ctx)
+	if err != nil || (len(workspaces) <= 1 && current == terraform.DefaultWorkspace) {
+		return nil
+	}
+
+	return []prompt.Segment{{
+		Priority: prompt.PriorityContext,
+		Text: fmt.Sprintf("The current Terraform workspace is %q of %s. Use terraform.workspace in the names and tags of resources so every workspace gets its own.",
+			current, strings.Join(workspaces, ", ")),
+	}}
+}
+
+// confirmApply asks for an extra confirmation before applying in a protected workspace.
+func (a *App) confirmApply(ctx context.Context) (bool, error) {
+	current, err := a.ops.Workspace(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return a.confirmProtectedWorkspace(current, "apply to it")
+}
+
+// confirmProtectedWorkspace asks the user to type the name of a protected workspace before
+// the action is run on it. Other workspaces, and runs without confirmation, need no confirmation.
+func (a *App) confirmProtectedWorkspace(name string, action string) (bool, error) {
+	if !a.Config.RequireConfirmation || !terraform.IsProtectedWorkspace(name) {
+		return true, nil
+	}
+
+	result, err := a.Prompter.Input(fmt.Sprintf("⚠️ %q looks like a production workspace, type its name to %s", name, action))
+	if err != nil {
+		return false, err
+	}
+
+	if result != name {
+		a.Log.Printf("The name does not match %q, nothing was done.\n", name)
+
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,266 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/trace"
+	"github.com/pkg/errors"
+)
+
+// Mode is whether a recorder records or replays.
+type Mode string
+
+const (
+	// Record sends the requests and appends them with their responses to the cassette.
+	Record Mode = "record"
+	// Replay serves the responses from the cassette without sending the requests.
+	Replay Mode = "replay"
+)
+
+var (
+	errMode = errors.New("invalid cassette mode")
+
+	// ErrNoInteraction is returned when replaying a request the cassette has no response for.
+	ErrNoInteraction = errors.New("no recorded interaction matches the request")
+)
+
+// Request is a recorded request, without headers and host so neither API keys nor endpoints end up in cassettes.
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+// Interaction is a request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the file the interactions are recorded in.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records to or replays from a cassette file.
+// Requests are matched on their method, path and normalized body, so they match whatever the endpoint
+// and however the JSON is formatted. Identical requests are replayed in the order they were recorded.
+type Recorder struct {
+	path string
+	mode Mode
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// ParseMode parses record or replay.
+func ParseMode(mode string) (Mode, error) {
+	switch Mode(mode) {
+	case Record, Replay:
+		return Mode(mode), nil
+	default:
+		return "", errors.Wrapf(errMode, "%q, use %s or %s", mode, Record, Replay)
+	}
+}
+
+// New returns a recorder for the cassette at path. Replaying loads the cassette, recording starts
+// a new one. Requests are recorded by sending them with base, http.DefaultTransport when it is nil.
+func New(path string, mode Mode, base http.RoundTripper) (*Recorder, error) {
+	if _, err := ParseMode(string(mode)); err != nil {
+		return nil, err
+	}
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	r := &Recorder{path: path, mode: mode, base: base}
+
+	if mode == Record {
+		return r, nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cassette: %w", err)
+	}
+
+	if err := json.Unmarshal(contents, &r.cassette); err != nil {
+		return nil, fmt.Errorf("error decoding cassette %s: %w", path, err)
+	}
+
+	r.used = make([]bool, len(r.cassette.Interactions))
+
+	return r, nil
+}
+
+// HTTPClient returns a client that records or replays with the recorder, to pass to WithHTTPClient
+// of the OpenAI and Azure OpenAI clients.
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Redacted like recorded requests, so they still match
+	request := Request{Method: req.Method, Path: req.URL.Path, Body: trace.Redact(Normalize(body))}
+
+	if r.mode == Replay {
+		return r.replay(req, request)
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	response := Response{Status: resp.StatusCode, ContentType: resp.Header.Get("Content-Type"), Body: string(contents)}
+
+	if err := r.record(Interaction{Request: request, Response: response}); err != nil {
+		return nil, err
+	}
+
+	return response.http(req), nil
+}
+
+// replay serves the first unused interaction that matches the request.
+func (r *Recorder) replay(req *http.Request, request Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] && interaction.Request == request {
+			r.used[i] = true
+
+			return interaction.Response.http(req), nil
+		}
+	}
+
+	return nil, errors.Wrapf(ErrNoInteraction, "%s %s in %s", request.Method, request.Path, r.path)
+}
+
+// record appends the interaction and writes the cassette, so it is complete even when the process exits early.
+func (r *Recorder) record(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interaction.Response.Body = trace.Redact(interaction.Response.Body)
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+
+	contents, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("error creating cassette dir: %w", err)
+	}
+
+	if err := os.WriteFile(r.path, append(contents, '\n'), 0o600); err != nil {
+		return fmt.Errorf("error writing cassette: %w", err)
+	}
+
+	return nil
+}
+
+// Unused returns the number of recorded interactions that were not replayed.
+func (r *Recorder) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unused := 0
+
+	for _, used := range r.used {
+		if !used {
+			unused++
+		}
+	}
+
+	return unused
+}
+
+// Normalize returns JSON bodies compacted with sorted keys, and other bodies without surrounding whitespace.
+func Normalize(body string) string {
+	var value any
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return strings.TrimSpace(body)
+	}
+
+	// Maps are encoded with sorted keys
+	var b strings.Builder
+
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(value); err != nil {
+		return strings.TrimSpace(body)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// http returns the response to req.
+func (resp Response) http(req *http.Request) *http.Response {
+	header := http.Header{}
+	if resp.ContentType != "" {
+		header.Set("Content-Type", resp.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}
+}
+
+// readBody reads the request body and replaces it with a copy, so it can still be sent.
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+
+	contents, err := io.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return "", fmt.Errorf("error reading request body: %w", err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(contents))
+
+	return string(contents), nil
+}
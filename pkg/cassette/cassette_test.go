@@ -0,0 +1,104 @@
+package cassette_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/cassette"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func post(t *testing.T, client *http.Client, url string, body string) (int, string, error) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer sk-abcdefghijklmnop")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	contents, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, string(contents), nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"answer":"hello","call":%d}`, calls)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "run.json")
+
+	recorder, err := cassette.New(path, cassette.Record, nil)
+	require.NoError(t, err)
+
+	status, body, err := post(t, recorder.HTTPClient(), server.URL+"/v1/chat/completions", `{"prompt": "a", "model": "gpt-4o"}`)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"answer":"hello","call":1}`, body)
+
+	_, _, err = post(t, recorder.HTTPClient(), server.URL+"/v1/chat/completions", `{"prompt": "a", "model": "gpt-4o"}`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(contents), "sk-abcdefghijklmnop")
+	assert.NotContains(t, string(contents), server.URL)
+
+	// Replaying matches on the normalized body, whatever the endpoint
+	replayer, err := cassette.New(path, cassette.Replay, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayer.Unused())
+
+	_, body, err = post(t, replayer.HTTPClient(), "https://example.openai.azure.com/v1/chat/completions", `{"model":"gpt-4o","prompt":"a"}`)
+	require.NoError(t, err)
+	assert.Contains(t, body, `"call":1`)
+
+	_, body, err = post(t, replayer.HTTPClient(), "https://example.openai.azure.com/v1/chat/completions", `{"model":"gpt-4o","prompt":"a"}`)
+	require.NoError(t, err)
+	assert.Contains(t, body, `"call":2`)
+	assert.Equal(t, 0, replayer.Unused())
+	assert.Equal(t, 2, calls)
+
+	// Every interaction is replayed once
+	_, _, err = post(t, replayer.HTTPClient(), "https://example.openai.azure.com/v1/chat/completions", `{"model":"gpt-4o","prompt":"a"}`)
+	assert.ErrorIs(t, err, cassette.ErrNoInteraction)
+
+	_, _, err = post(t, replayer.HTTPClient(), "https://example.openai.azure.com/v1/chat/completions", `{"model":"gpt-4o","prompt":"b"}`)
+	assert.ErrorIs(t, err, cassette.ErrNoInteraction)
+}
+
+func TestNew(t *testing.T) {
+	_, err := cassette.ParseMode("rewind")
+	assert.Error(t, err)
+
+	mode, err := cassette.ParseMode("replay")
+	require.NoError(t, err)
+	assert.Equal(t, cassette.Replay, mode)
+
+	_, err = cassette.New(filepath.Join(t.TempDir(), "missing.json"), cassette.Replay, nil)
+	assert.Error(t, err)
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, `{"a":[1,"<b>"],"b":true}`, cassette.Normalize("{\n  \"b\": true,\n  \"a\": [1, \"<b>\"]\n}"))
+	assert.Equal(t, "not json", cassette.Normalize("  not json\n"))
+}
@@ -0,0 +1,402 @@
+package conventions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// minSamples is the number of resources that must agree before a pattern becomes a convention.
+	minSamples = 2
+	// minShare is the share of resources that must agree before a pattern becomes a convention.
+	minShare = 0.6
+)
+
+// Provider is a required_providers entry.
+type Provider struct {
+	Source  string
+	Version string
+}
+
+// Conventions are the patterns the existing .tf files of a workspace follow.
+// Tag values are kept as HCL source so references such as var.environment survive.
+type Conventions struct {
+	// DefaultTags are the tags set by provider default_tags.
+	DefaultTags map[string]string
+	// CommonTags are the tags most tagged resources set themselves.
+	CommonTags map[string]string
+	// NamePrefix is the prefix most resource names start with, e.g. "acme-" or "${var.project}-".
+	NamePrefix string
+	// RegionVariable is the variable providers read their region from.
+	RegionVariable string
+	// RequiredProviders are the pinned providers, keyed by local name.
+	RequiredProviders map[string]Provider
+	// Backend is the configured backend type, e.g. "s3".
+	Backend string
+
+	// taggedTypes are the resource types that are tagged in the workspace.
+	taggedTypes map[string]bool
+}
+
+// file is a parsed .tf file together with its source.
+type file struct {
+	src  []byte
+	body *hclsyntax.Body
+}
+
+// Analyze scans the .tf files in dir and returns the conventions they follow.
+// Files that fail to parse are skipped.
+func Analyze(dir string) (*Conventions, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing terraform files: %w", err)
+	}
+
+	files := make([]file, 0, len(names))
+
+	for _, name := range names {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", name, err)
+		}
+
+		if f, ok := parse(src, name); ok {
+			files = append(files, f)
+		}
+	}
+
+	return analyze(files), nil
+}
+
+// parse parses src as HCL native syntax.
+func parse(src []byte, name string) (file, bool) {
+	f, diags := hclsyntax.ParseConfig(src, name, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return file{}, false
+	}
+
+	body, ok := f.Body.(*hclsyntax.Body)
+
+	return file{src: src, body: body}, ok
+}
+
+func analyze(files []file) *Conventions {
+	conv := &Conventions{
+		DefaultTags:       map[string]string{},
+		CommonTags:        map[string]string{},
+		RequiredProviders: map[string]Provider{},
+		taggedTypes:       map[string]bool{},
+	}
+
+	var (
+		tagged   int
+		tagKeys  = map[string]map[string]int{}
+		prefixes = map[string]int{}
+		names    int
+	)
+
+	for _, f := range files {
+		for _, block := range f.body.Blocks {
+			switch block.Type {
+			case "provider":
+				conv.analyzeProvider(f.src, block)
+			case "terraform":
+				conv.analyzeTerraform(block)
+			case "resource":
+				if len(block.Labels) != 2 {
+					continue
+				}
+
+				if tags, ok := objectItems(f.src, block.Body.Attributes["tags"]); ok {
+					tagged++
+					conv.taggedTypes[block.Labels[0]] = true
+
+					for key, value := range tags {
+						if tagKeys[key] == nil {
+							tagKeys[key] = map[string]int{}
+						}
+
+						tagKeys[key][value]++
+					}
+				}
+
+				if name, ok := stringSource(f.src, block.Body.Attributes["name"]); ok {
+					names++
+					if prefix := namePrefix(name); prefix != "" {
+						prefixes[prefix]++
+					}
+				}
+			}
+		}
+	}
+
+	if tagged >= minSamples {
+		for key, values := range tagKeys {
+			if share(sum(values), tagged) >= minShare {
+				conv.CommonTags[key] = mostCommon(values)
+			}
+		}
+	}
+
+	if names >= minSamples {
+		prefix := mostCommon(prefixes)
+		if share(prefixes[prefix], names) >= minShare {
+			conv.NamePrefix = prefix
+		}
+	}
+
+	return conv
+}
+
+func (c *Conventions) analyzeProvider(src []byte, block *hclsyntax.Block) {
+	if region, ok := block.Body.Attributes["region"]; ok {
+		if name, ok := variableName(region.Expr); ok {
+			c.RegionVariable = name
+		}
+	}
+
+	for _, nested := range block.Body.Blocks {
+		if nested.Type != "default_tags" {
+			continue
+		}
+
+		if tags, ok := objectItems(src, nested.Body.Attributes["tags"]); ok {
+			for key, value := range tags {
+				c.DefaultTags[key] = value
+			}
+		}
+	}
+}
+
+func (c *Conventions) analyzeTerraform(block *hclsyntax.Block) {
+	for _, nested := range block.Body.Blocks {
+		switch nested.Type {
+		case "backend":
+			if len(nested.Labels) == 1 {
+				c.Backend = nested.Labels[0]
+			}
+		case "required_providers":
+			for name, provider := range requiredProviders(nested) {
+				c.RequiredProviders[name] = provider
+			}
+		}
+	}
+}
+
+// Empty reports whether no conventions were found.
+func (c *Conventions) Empty() bool {
+	return len(c.DefaultTags) == 0 && len(c.CommonTags) == 0 && c.NamePrefix == "" &&
+		c.RegionVariable == "" && len(c.RequiredProviders) == 0 && c.Backend == ""
+}
+
+// Constraints returns the conventions as instructions for the model.
+func (c *Conventions) Constraints() []string {
+	var constraints []string
+
+	if len(c.DefaultTags) > 0 {
+		constraints = append(constraints, fmt.Sprintf("The provider already sets default_tags %s, do not repeat them on resources.", strings.Join(sortedKeys(c.DefaultTags), ", ")))
+	}
+
+	if len(c.CommonTags) > 0 {
+		constraints = append(constraints, fmt.Sprintf("Every taggable resource must set tags = %s.", objectSource(c.CommonTags, "")))
+	}
+
+	if c.NamePrefix != "" {
+		constraints = append(constraints, fmt.Sprintf("Resource names must start with %q.", c.NamePrefix))
+	}
+
+	if c.RegionVariable != "" {
+		constraints = append(constraints, fmt.Sprintf("Use var.%s for regions instead of hard-coded values.", c.RegionVariable))
+	}
+
+	for _, name := range sortedKeys(c.RequiredProviders) {
+		p := c.RequiredProviders[name]
+		constraints = append(constraints, fmt.Sprintf("Use provider %s from %q with version %q.", name, p.Source, p.Version))
+	}
+
+	if c.Backend != "" {
+		constraints = append(constraints, fmt.Sprintf("The workspace already configures the %q backend, do not declare a backend.", c.Backend))
+	}
+
+	return constraints
+}
+
+// objectItems returns the keys and value sources of an object attribute such as tags.
+func objectItems(src []byte, attr *hclsyntax.Attribute) (map[string]string, bool) {
+	if attr == nil {
+		return nil, false
+	}
+
+	obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil, false
+	}
+
+	items := make(map[string]string, len(obj.Items))
+
+	for _, item := range obj.Items {
+		key, ok := objectKey(item.KeyExpr)
+		if !ok {
+			continue
+		}
+
+		items[key] = string(item.ValueExpr.Range().SliceBytes(src))
+	}
+
+	return items, true
+}
+
+// objectKey returns the name of an object key written as an identifier or a string.
+func objectKey(expr hclsyntax.Expression) (string, bool) {
+	if key := hcl.ExprAsKeyword(expr); key != "" {
+		return key, true
+	}
+
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || !val.Type().Equals(cty.String) || val.IsNull() {
+		return "", false
+	}
+
+	return val.AsString(), true
+}
+
+// stringSource returns the source between the quotes of a string attribute, interpolations included.
+func stringSource(src []byte, attr *hclsyntax.Attribute) (string, bool) {
+	if attr == nil {
+		return "", false
+	}
+
+	if _, ok := attr.Expr.(*hclsyntax.TemplateExpr); !ok {
+		return "", false
+	}
+
+	raw := string(attr.Expr.Range().SliceBytes(src))
+	if len(raw) < 2 || raw[0] != '"' || raw[len(raw)-1] != '"' {
+		return "", false
+	}
+
+	return raw[1 : len(raw)-1], true
+}
+
+// variableName returns the name of the variable an expression such as var.region refers to.
+func variableName(expr hclsyntax.Expression) (string, bool) {
+	traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(traversal.Traversal) != 2 || traversal.Traversal.RootName() != "var" {
+		return "", false
+	}
+
+	attr, ok := traversal.Traversal[1].(hcl.TraverseAttr)
+
+	return attr.Name, ok
+}
+
+// requiredProviders reads the source and version of every provider in a required_providers block.
+func requiredProviders(block *hclsyntax.Block) map[string]Provider {
+	providers := map[string]Provider{}
+
+	for name, attr := range block.Body.Attributes {
+		obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr)
+		if !ok {
+			continue
+		}
+
+		var p Provider
+
+		for _, item := range obj.Items {
+			key, ok := objectKey(item.KeyExpr)
+			if !ok {
+				continue
+			}
+
+			val, diags := item.ValueExpr.Value(nil)
+			if diags.HasErrors() || !val.Type().Equals(cty.String) || val.IsNull() {
+				continue
+			}
+
+			switch key {
+			case "source":
+				p.Source = val.AsString()
+			case "version":
+				p.Version = val.AsString()
+			}
+		}
+
+		providers[name] = p
+	}
+
+	return providers
+}
+
+// namePrefix returns a name up to and including its first separator, or "" when it has none.
+func namePrefix(name string) string {
+	i := strings.IndexAny(name, "-_")
+	if i <= 0 {
+		return ""
+	}
+
+	return name[:i+1]
+}
+
+func share(count int, total int) float64 {
+	return float64(count) / float64(total)
+}
+
+func sum(counts map[string]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+
+	return total
+}
+
+// mostCommon returns the key with the highest count, breaking ties alphabetically.
+func mostCommon(counts map[string]int) string {
+	var best string
+
+	for _, key := range sortedKeys(counts) {
+		if counts[key] > counts[best] {
+			best = key
+		}
+	}
+
+	return best
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// objectSource renders items as an HCL object, indenting the items with indent.
+func objectSource(items map[string]string, indent string) string {
+	var b strings.Builder
+
+	b.WriteString("{\n")
+
+	for _, key := range sortedKeys(items) {
+		name := key
+		if !hclsyntax.ValidIdentifier(key) {
+			name = fmt.Sprintf("%q", key)
+		}
+
+		fmt.Fprintf(&b, "%s  %s = %s\n", indent, name, items[key])
+	}
+
+	b.WriteString(indent + "}")
+
+	return b.String()
+}
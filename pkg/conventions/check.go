@@ -0,0 +1,289 @@
+package conventions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+)
+
+var errFix = errors.New("invalid convention fix")
+
+// Violation is a place where generated code does not follow the workspace conventions.
+type Violation struct {
+	// Address is the block the violation was found in, e.g. "aws_instance.web".
+	Address string
+	// Message describes the violation.
+	Message string
+
+	fix *fix
+}
+
+// Fixable reports whether the violation can be fixed with Fix.
+func (v Violation) Fixable() bool {
+	return v.fix != nil
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Address, v.Message)
+}
+
+// fix rewrites one attribute, or removes one nested block, of a top level block.
+type fix struct {
+	// block is the block type followed by its labels, e.g. ["resource", "aws_instance", "web"].
+	block []string
+	// nested is the nested block the change applies to, empty for the top level block itself.
+	nested string
+	// attribute is the attribute to set. When empty, the nested block is removed.
+	attribute string
+	// expr is the new HCL source of the attribute.
+	expr string
+}
+
+// Check returns the places where the HCL in src does not follow the conventions.
+// Sources that fail to parse have no violations, CheckTemplate reports those.
+func (c *Conventions) Check(src string) []Violation {
+	f, ok := parse([]byte(src), "")
+	if !ok {
+		return nil
+	}
+
+	var violations []Violation
+
+	for _, block := range f.body.Blocks {
+		switch block.Type {
+		case "resource":
+			violations = append(violations, c.checkResource(f.src, block)...)
+		case "provider":
+			violations = append(violations, c.checkRegion(block)...)
+		case "terraform":
+			violations = append(violations, c.checkTerraform(block)...)
+		}
+	}
+
+	return violations
+}
+
+func (c *Conventions) checkResource(src []byte, block *hclsyntax.Block) []Violation {
+	if len(block.Labels) != 2 {
+		return nil
+	}
+
+	var (
+		violations []Violation
+		address    = strings.Join(block.Labels, ".")
+		path       = append([]string{block.Type}, block.Labels...)
+	)
+
+	if v, ok := c.checkTags(src, block); ok {
+		violations = append(violations, v)
+	}
+
+	if c.NamePrefix != "" {
+		if name, ok := stringSource(src, block.Body.Attributes["name"]); ok && !strings.HasPrefix(name, c.NamePrefix) {
+			violations = append(violations, Violation{
+				Address: address,
+				Message: fmt.Sprintf("name %q does not start with %q", name, c.NamePrefix),
+				fix:     &fix{block: path, attribute: "name", expr: `"` + c.NamePrefix + name + `"`},
+			})
+		}
+	}
+
+	return append(violations, c.checkRegion(block)...)
+}
+
+// checkTags reports resources that miss common tags. Resources without tags are only
+// reported when resources of the same type are tagged in the workspace, as not every
+// resource type supports tags.
+func (c *Conventions) checkTags(src []byte, block *hclsyntax.Block) (Violation, bool) {
+	if len(c.CommonTags) == 0 {
+		return Violation{}, false
+	}
+
+	tags := map[string]string{}
+
+	if attr, ok := block.Body.Attributes["tags"]; ok {
+		items, ok := objectItems(src, attr)
+		if !ok {
+			// tags is set from an expression we cannot merge into
+			return Violation{}, false
+		}
+
+		tags = items
+	} else if !c.taggedTypes[block.Labels[0]] {
+		return Violation{}, false
+	}
+
+	var missing []string
+
+	for _, key := range sortedKeys(c.CommonTags) {
+		if _, ok := tags[key]; !ok {
+			missing = append(missing, key)
+			tags[key] = c.CommonTags[key]
+		}
+	}
+
+	if len(missing) == 0 {
+		return Violation{}, false
+	}
+
+	return Violation{
+		Address: strings.Join(block.Labels, "."),
+		Message: fmt.Sprintf("missing tags %s", strings.Join(missing, ", ")),
+		fix:     &fix{block: append([]string{block.Type}, block.Labels...), attribute: "tags", expr: objectSource(tags, "")},
+	}, true
+}
+
+// checkRegion reports region attributes that are not read from the region variable.
+func (c *Conventions) checkRegion(block *hclsyntax.Block) []Violation {
+	if c.RegionVariable == "" {
+		return nil
+	}
+
+	region, ok := block.Body.Attributes["region"]
+	if !ok {
+		return nil
+	}
+
+	if name, ok := variableName(region.Expr); ok && name == c.RegionVariable {
+		return nil
+	}
+
+	// Only hard-coded values are replaced, references to other objects are left alone.
+	if len(region.Expr.Variables()) > 0 {
+		return nil
+	}
+
+	return []Violation{{
+		Address: blockAddress(block),
+		Message: fmt.Sprintf("region is hard-coded instead of using var.%s", c.RegionVariable),
+		fix:     &fix{block: append([]string{block.Type}, block.Labels...), attribute: "region", expr: "var." + c.RegionVariable},
+	}}
+}
+
+func (c *Conventions) checkTerraform(block *hclsyntax.Block) []Violation {
+	var violations []Violation
+
+	for _, nested := range block.Body.Blocks {
+		switch nested.Type {
+		case "backend":
+			if c.Backend == "" {
+				continue
+			}
+
+			violations = append(violations, Violation{
+				Address: "terraform",
+				Message: fmt.Sprintf("declares a backend but the workspace already configures the %q backend", c.Backend),
+				fix:     &fix{block: []string{"terraform"}, nested: "backend"},
+			})
+		case "required_providers":
+			generated := requiredProviders(nested)
+
+			for _, name := range sortedKeys(generated) {
+				want, ok := c.RequiredProviders[name]
+				if !ok || generated[name] == want {
+					continue
+				}
+
+				violations = append(violations, Violation{
+					Address: "terraform",
+					Message: fmt.Sprintf("provider %s is %q %q but the workspace uses %q %q", name, generated[name].Source, generated[name].Version, want.Source, want.Version),
+					fix: &fix{
+						block:     []string{"terraform"},
+						nested:    "required_providers",
+						attribute: name,
+						expr:      fmt.Sprintf("{\n  source  = %q\n  version = %q\n}", want.Source, want.Version),
+					},
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// Fix applies the fixes of the fixable violations to src and returns the formatted result.
+func Fix(src string, violations []Violation) (string, error) {
+	f, diags := hclwrite.ParseConfig([]byte(src), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return "", fmt.Errorf("error parsing template: %w", diags)
+	}
+
+	for _, v := range violations {
+		if v.fix == nil {
+			continue
+		}
+
+		if err := v.fix.apply(f.Body()); err != nil {
+			return "", err
+		}
+	}
+
+	return string(hclwrite.Format(f.Bytes())), nil
+}
+
+func (x *fix) apply(body *hclwrite.Body) error {
+	block := body.FirstMatchingBlock(x.block[0], x.block[1:])
+	if block == nil {
+		return errors.Wrapf(errFix, "block %s not found", strings.Join(x.block, "."))
+	}
+
+	body = block.Body()
+
+	if x.nested != "" {
+		nested := firstBlockOfType(body, x.nested)
+		if nested == nil {
+			return errors.Wrapf(errFix, "block %s not found in %s", x.nested, strings.Join(x.block, "."))
+		}
+
+		if x.attribute == "" {
+			body.RemoveBlock(nested)
+
+			return nil
+		}
+
+		body = nested.Body()
+	}
+
+	tokens, err := exprTokens(x.expr)
+	if err != nil {
+		return err
+	}
+
+	body.SetAttributeRaw(x.attribute, tokens)
+
+	return nil
+}
+
+// firstBlockOfType returns the first nested block of the given type, whatever its labels.
+func firstBlockOfType(body *hclwrite.Body, typeName string) *hclwrite.Block {
+	for _, block := range body.Blocks() {
+		if block.Type() == typeName {
+			return block
+		}
+	}
+
+	return nil
+}
+
+// exprTokens parses the HCL source of an expression into tokens.
+func exprTokens(expr string) (hclwrite.Tokens, error) {
+	f, diags := hclwrite.ParseConfig([]byte("expr = "+expr+"\n"), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, errors.Wrapf(errFix, "invalid expression %s: %s", expr, diags)
+	}
+
+	return f.Body().GetAttribute("expr").Expr().BuildTokens(nil), nil
+}
+
+// blockAddress returns a readable address for a top level block.
+func blockAddress(block *hclsyntax.Block) string {
+	if block.Type == "resource" {
+		return strings.Join(block.Labels, ".")
+	}
+
+	return strings.Join(append([]string{block.Type}, block.Labels...), ".")
+}
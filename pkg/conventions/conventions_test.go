@@ -0,0 +1,181 @@
+package conventions_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/conventions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const workspace = `
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+
+  backend "s3" {
+    bucket = "acme-state"
+    key    = "network.tfstate"
+    region = "eu-west-1"
+  }
+}
+
+provider "aws" {
+  region = var.region
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "acme-logs"
+  tags = {
+    Team        = "platform"
+    Environment = var.environment
+  }
+}
+
+resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+  tags = {
+    Team        = "platform"
+    Environment = var.environment
+    Name        = "acme-web"
+  }
+}
+
+resource "aws_security_group" "web" {
+  name = "acme-web"
+}
+
+resource "aws_lb" "web" {
+  name = "acme-web-lb"
+}
+`
+
+func analyze(t *testing.T) *conventions.Conventions {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(workspace), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.tf"), []byte("resource {"), 0o600))
+
+	conv, err := conventions.Analyze(dir)
+	require.NoError(t, err)
+
+	return conv
+}
+
+func TestAnalyze(t *testing.T) {
+	conv := analyze(t)
+
+	assert.Equal(t, map[string]string{"Team": `"platform"`, "Environment": "var.environment"}, conv.CommonTags)
+	assert.Equal(t, "acme-", conv.NamePrefix)
+	assert.Equal(t, "region", conv.RegionVariable)
+	assert.Equal(t, map[string]conventions.Provider{"aws": {Source: "hashicorp/aws", Version: "~> 5.0"}}, conv.RequiredProviders)
+	assert.Equal(t, "s3", conv.Backend)
+	assert.False(t, conv.Empty())
+	assert.Len(t, conv.Constraints(), 5)
+}
+
+func TestAnalyzeEmptyWorkspace(t *testing.T) {
+	conv, err := conventions.Analyze(t.TempDir())
+	require.NoError(t, err)
+	assert.True(t, conv.Empty())
+	assert.Empty(t, conv.Constraints())
+}
+
+func TestCheckAndFix(t *testing.T) {
+	conv := analyze(t)
+
+	generated := `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 4.0"
+    }
+  }
+  backend "local" {}
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_instance" "api" {
+  ami           = "ami-456"
+  instance_type = "t3.micro"
+}
+
+resource "aws_lb" "api" {
+  name = "api-lb"
+}
+
+resource "aws_iam_role_policy_attachment" "api" {
+  role       = "api"
+  policy_arn = "arn:aws:iam::aws:policy/ReadOnlyAccess"
+}
+`
+
+	violations := conv.Check(generated)
+	require.Len(t, violations, 5)
+
+	for _, v := range violations {
+		assert.True(t, v.Fixable(), v.String())
+	}
+
+	fixed, err := conventions.Fix(generated, violations)
+	require.NoError(t, err)
+
+	expected := `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = var.region
+}
+
+resource "aws_instance" "api" {
+  ami           = "ami-456"
+  instance_type = "t3.micro"
+  tags = {
+    Environment = var.environment
+    Team        = "platform"
+  }
+}
+
+resource "aws_lb" "api" {
+  name = "acme-api-lb"
+}
+
+resource "aws_iam_role_policy_attachment" "api" {
+  role       = "api"
+  policy_arn = "arn:aws:iam::aws:policy/ReadOnlyAccess"
+}
+`
+	assert.Equal(t, expected, fixed)
+	assert.Empty(t, conv.Check(fixed))
+}
+
+func TestCheckKeepsExistingTags(t *testing.T) {
+	conv := analyze(t)
+
+	violations := conv.Check(`resource "aws_s3_bucket" "data" {
+  bucket = "acme-data"
+  tags = {
+    Team = "data"
+  }
+}
+`)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "aws_s3_bucket.data: missing tags Environment", violations[0].String())
+}
@@ -0,0 +1,71 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// defaultEditor is used when neither VISUAL nor EDITOR is set.
+const defaultEditor = "vi"
+
+// Command returns the command that opens path in the user's editor, from VISUAL or EDITOR.
+// The editor may come with arguments, such as "code --wait".
+func Command(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		fields = []string{defaultEditor}
+	}
+
+	return exec.Command(fields[0], append(fields[1:], path)...)
+}
+
+// TempFile writes the contents to a temporary file that keeps the extension of name,
+// so editors pick the right syntax. The caller removes the file.
+func TempFile(name string, contents string) (string, error) {
+	file, err := os.CreateTemp("", "terraform-assistant-*"+filepath.Ext(name))
+	if err != nil {
+		return "", fmt.Errorf("error creating temp file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(contents); err != nil {
+		os.Remove(file.Name())
+
+		return "", fmt.Errorf("error writing temp file: %w", err)
+	}
+
+	return file.Name(), nil
+}
+
+// Edit opens the contents in the user's editor and returns them once the editor exits.
+func Edit(name string, contents string) (string, error) {
+	path, err := TempFile(name, contents)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(path)
+
+	cmd := Command(path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running editor: %w", err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading edited file: %w", err)
+	}
+
+	return string(edited), nil
+}
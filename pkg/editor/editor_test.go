@@ -0,0 +1,59 @@
+package editor_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/editor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+	assert.Equal(t, []string{"code", "--wait", "main.tf"}, editor.Command("main.tf").Args)
+
+	t.Setenv("VISUAL", "nano")
+	assert.Equal(t, []string{"nano", "main.tf"}, editor.Command("main.tf").Args)
+
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, []string{"vi", "main.tf"}, editor.Command("main.tf").Args)
+}
+
+func TestEdit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake editor is a shell script")
+	}
+
+	// The fake editor appends a line to the file it is given
+	script := filepath.Join(t.TempDir(), "fake-editor")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho '# edited' >> \"$1\"\n"), 0o700))
+
+	t.Setenv("VISUAL", script)
+
+	edited, err := editor.Edit("main.tf", "resource \"aws_instance\" \"web\" {}\n")
+	require.NoError(t, err)
+	assert.Equal(t, "resource \"aws_instance\" \"web\" {}\n# edited\n", edited)
+
+	t.Setenv("VISUAL", "false")
+
+	_, err = editor.Edit("main.tf", "")
+	assert.Error(t, err)
+}
+
+func TestTempFile(t *testing.T) {
+	path, err := editor.TempFile("main.tf", "contents")
+	require.NoError(t, err)
+
+	defer os.Remove(path)
+
+	assert.Equal(t, ".tf", filepath.Ext(path))
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "contents", string(contents))
+}
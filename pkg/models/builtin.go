@@ -0,0 +1,42 @@
+package models
+
+// builtin is the list of models known without any configuration.
+// Pricing is in US dollars per 1K tokens as published by OpenAI.
+var builtin = []Model{
+	// Legacy completion models
+	{Name: "code-davinci-002", ContextWindow: 8001, MaxOutputTokens: 8001, API: CompletionAPI, Encoding: EncodingP50k},
+	{Name: "text-davinci-003", ContextWindow: 4097, MaxOutputTokens: 4097, API: CompletionAPI, Encoding: EncodingP50k, Pricing: Pricing{Input: 0.02, Output: 0.02}},
+	{Name: "gpt-3.5-turbo-instruct", ContextWindow: 4096, MaxOutputTokens: 4096, API: CompletionAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.0015, Output: 0.002}},
+
+	// GPT-3.5 Turbo
+	{Name: "gpt-3.5-turbo", ContextWindow: 16385, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.0005, Output: 0.0015}},
+	{Name: "gpt-3.5-turbo-0301", ContextWindow: 4096, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.0015, Output: 0.002}},
+	{Name: "gpt-3.5-turbo-0613", ContextWindow: 4096, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.0015, Output: 0.002}},
+	{Name: "gpt-3.5-turbo-16k", ContextWindow: 16385, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.003, Output: 0.004}},
+	{Name: "gpt-3.5-turbo-1106", ContextWindow: 16385, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.001, Output: 0.002}},
+	{Name: "gpt-3.5-turbo-0125", ContextWindow: 16385, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.0005, Output: 0.0015}},
+
+	// Azure names for GPT-3.5 Turbo
+	{Name: "gpt-35-turbo", ContextWindow: 4096, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.0015, Output: 0.002}},
+	{Name: "gpt-35-turbo-0301", ContextWindow: 4096, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.0015, Output: 0.002}},
+	{Name: "gpt-35-turbo-16k", ContextWindow: 16384, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.003, Output: 0.004}},
+
+	// GPT-4
+	{Name: "gpt-4", ContextWindow: 8192, MaxOutputTokens: 8192, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.03, Output: 0.06}},
+	{Name: "gpt-4-0314", ContextWindow: 8192, MaxOutputTokens: 8192, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.03, Output: 0.06}},
+	{Name: "gpt-4-0613", ContextWindow: 8192, MaxOutputTokens: 8192, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.03, Output: 0.06}},
+	{Name: "gpt-4-32k", ContextWindow: 32768, MaxOutputTokens: 32768, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.06, Output: 0.12}},
+	{Name: "gpt-4-32k-0314", ContextWindow: 32768, MaxOutputTokens: 32768, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.06, Output: 0.12}},
+	{Name: "gpt-4-turbo", ContextWindow: 128000, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.01, Output: 0.03}},
+	{Name: "gpt-4-1106-preview", ContextWindow: 128000, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.01, Output: 0.03}},
+	{Name: "gpt-4-0125-preview", ContextWindow: 128000, MaxOutputTokens: 4096, API: ChatAPI, Encoding: EncodingCL100k, Pricing: Pricing{Input: 0.01, Output: 0.03}},
+
+	// GPT-4o
+	{Name: "gpt-4o", ContextWindow: 128000, MaxOutputTokens: 16384, API: ChatAPI, Encoding: EncodingO200k, Pricing: Pricing{Input: 0.0025, Output: 0.01}},
+	{Name: "gpt-4o-mini", ContextWindow: 128000, MaxOutputTokens: 16384, API: ChatAPI, Encoding: EncodingO200k, Pricing: Pricing{Input: 0.00015, Output: 0.0006}},
+
+	// GPT-4.1
+	{Name: "gpt-4.1", ContextWindow: 1047576, MaxOutputTokens: 32768, API: ChatAPI, Encoding: EncodingO200k, Pricing: Pricing{Input: 0.002, Output: 0.008}},
+	{Name: "gpt-4.1-mini", ContextWindow: 1047576, MaxOutputTokens: 32768, API: ChatAPI, Encoding: EncodingO200k, Pricing: Pricing{Input: 0.0004, Output: 0.0016}},
+	{Name: "gpt-4.1-nano", ContextWindow: 1047576, MaxOutputTokens: 32768, API: ChatAPI, Encoding: EncodingO200k, Pricing: Pricing{Input: 0.0001, Output: 0.0004}},
+}
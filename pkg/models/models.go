@@ -0,0 +1,241 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// API identifies which OpenAI endpoint serves a model.
+type API string
+
+const (
+	// ChatAPI models are served from the chat completions endpoint.
+	ChatAPI API = "chat"
+	// CompletionAPI models are served from the legacy completions endpoint.
+	CompletionAPI API = "completion"
+)
+
+// Tokenizer encodings used by the OpenAI models.
+const (
+	EncodingR50k   = "r50k_base"
+	EncodingP50k   = "p50k_base"
+	EncodingCL100k = "cl100k_base"
+	EncodingO200k  = "o200k_base"
+)
+
+var (
+	errModel       = errors.New("unknown model")
+	errModelConfig = errors.New("invalid model config")
+)
+
+// Pricing is the price of a model in US dollars per 1K tokens.
+type Pricing struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// Model describes the limits and capabilities of a single model.
+type Model struct {
+	// Name is the OpenAI model name, e.g. "gpt-4o".
+	Name string `json:"name"`
+
+	// ContextWindow is the total number of tokens shared by prompt and completion.
+	ContextWindow int `json:"contextWindow"`
+
+	// MaxOutputTokens is the maximum number of tokens the model generates in one response.
+	MaxOutputTokens int `json:"maxOutputTokens"`
+
+	// API is the endpoint the model is served from.
+	API API `json:"api"`
+
+	// Encoding is the name of the tokenizer encoding, e.g. "cl100k_base".
+	Encoding string `json:"encoding"`
+
+	// Pricing is the price per 1K tokens.
+	Pricing Pricing `json:"pricing"`
+}
+
+// Cost returns the price in US dollars of a request with the given token usage.
+func (m Model) Cost(promptTokens int, completionTokens int) float64 {
+	return (float64(promptTokens)*m.Pricing.Input + float64(completionTokens)*m.Pricing.Output) / 1000
+}
+
+// validate checks that the model has everything needed to build a request.
+func (m Model) validate() error {
+	if m.Name == "" {
+		return errors.Wrap(errModelConfig, "model name must be provided")
+	}
+
+	if m.ContextWindow <= 0 {
+		return errors.Wrapf(errModelConfig, "model %q must have a positive context window", m.Name)
+	}
+
+	if m.MaxOutputTokens <= 0 || m.MaxOutputTokens > m.ContextWindow {
+		return errors.Wrapf(errModelConfig, "model %q max output tokens must be between 1 and %d", m.Name, m.ContextWindow)
+	}
+
+	if m.API != ChatAPI && m.API != CompletionAPI {
+		return errors.Wrapf(errModelConfig, "model %q api must be %q or %q", m.Name, ChatAPI, CompletionAPI)
+	}
+
+	if m.Encoding == "" {
+		return errors.Wrapf(errModelConfig, "model %q encoding must be provided", m.Name)
+	}
+
+	return nil
+}
+
+// Registry holds the known models and the Azure deployments that serve them.
+type Registry struct {
+	models      map[string]Model
+	deployments map[string]string
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		models:      map[string]Model{},
+		deployments: map[string]string{},
+	}
+}
+
+// Default returns a registry populated with the built-in models.
+func Default() *Registry {
+	reg := NewRegistry()
+	for _, m := range builtin {
+		reg.models[m.Name] = m
+	}
+
+	return reg
+}
+
+// Register adds a model to the registry, replacing any model with the same name.
+func (r *Registry) Register(m Model) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+
+	r.models[m.Name] = m
+
+	return nil
+}
+
+// MapDeployment records that the Azure deployment is serving the given model.
+func (r *Registry) MapDeployment(deployment string, model string) error {
+	if deployment == "" || model == "" {
+		return errors.Wrap(errModelConfig, "deployment and model must be provided")
+	}
+
+	r.deployments[deployment] = model
+
+	return nil
+}
+
+// Lookup returns the model for a model or Azure deployment name.
+// Azure deployments are resolved first, then exact model names, then the longest
+// registered name that the given name extends, so dated snapshots such as
+// "gpt-4o-2024-08-06" resolve to "gpt-4o".
+func (r *Registry) Lookup(name string) (Model, error) {
+	if model, ok := r.deployments[name]; ok {
+		name = model
+	}
+
+	if m, ok := r.models[name]; ok {
+		return m, nil
+	}
+
+	var (
+		found Model
+		ok    bool
+	)
+
+	for prefix, m := range r.models {
+		if strings.HasPrefix(name, prefix+"-") && len(prefix) > len(found.Name) {
+			found, ok = m, true
+		}
+	}
+
+	if !ok {
+		return Model{}, errors.Wrapf(errModel, "model %q not found in model registry, add it with --models-file", name)
+	}
+
+	found.Name = name
+
+	return found, nil
+}
+
+// Names returns the sorted names of all registered models and deployments.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.models)+len(r.deployments))
+	for name := range r.models {
+		names = append(names, name)
+	}
+
+	for name := range r.deployments {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// config is the on-disk format read by LoadFile.
+type config struct {
+	Models           []Model           `json:"models"`
+	AzureDeployments map[string]string `json:"azureDeployments"`
+}
+
+// LoadFile reads additional models and Azure deployment mappings from a JSON file.
+// Models in the file override built-in models with the same name.
+func (r *Registry) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading models file: %w", err)
+	}
+
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return errors.Wrapf(errModelConfig, "error parsing models file %s: %s", path, err)
+	}
+
+	for _, m := range cfg.Models {
+		if err := r.Register(m); err != nil {
+			return err
+		}
+	}
+
+	for deployment, model := range cfg.AzureDeployments {
+		if err := r.MapDeployment(deployment, model); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseDeploymentMap parses a comma separated list of deployment=model pairs.
+func ParseDeploymentMap(value string) (map[string]string, error) {
+	deployments := map[string]string{}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		deployment, model, ok := strings.Cut(pair, "=")
+		if !ok || deployment == "" || model == "" {
+			return nil, errors.Wrapf(errModelConfig, "expected deployment=model but got %q", pair)
+		}
+
+		deployments[strings.TrimSpace(deployment)] = strings.TrimSpace(model)
+	}
+
+	return deployments, nil
+}
@@ -0,0 +1,102 @@
+package models_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	registry := models.Default()
+
+	cases := []struct {
+		name          string
+		api           models.API
+		contextWindow int
+	}{
+		{"text-davinci-003", models.CompletionAPI, 4097},
+		{"gpt-3.5-turbo", models.ChatAPI, 16385},
+		{"gpt-35-turbo-0301", models.ChatAPI, 4096},
+		{"gpt-4-32k-0314", models.ChatAPI, 32768},
+		{"gpt-4o-2024-08-06", models.ChatAPI, 128000},
+		{"gpt-4o-mini-2024-07-18", models.ChatAPI, 128000},
+		{"gpt-4-turbo-2024-04-09", models.ChatAPI, 128000},
+	}
+
+	for _, c := range cases {
+		m, err := registry.Lookup(c.name)
+		require.NoError(t, err, c.name)
+		assert.Equal(t, c.name, m.Name)
+		assert.Equal(t, c.api, m.API, c.name)
+		assert.Equal(t, c.contextWindow, m.ContextWindow, c.name)
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	_, err := models.Default().Lookup("my-model")
+	assert.Error(t, err)
+
+	_, err = models.Default().Lookup("gpt-4.5")
+	assert.Error(t, err)
+}
+
+func TestLookupDeployment(t *testing.T) {
+	registry := models.Default()
+	require.NoError(t, registry.MapDeployment("prod-chat", "gpt-4o"))
+
+	m, err := registry.Lookup("prod-chat")
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o", m.Name)
+	assert.Equal(t, models.EncodingO200k, m.Encoding)
+}
+
+func TestRegister(t *testing.T) {
+	registry := models.NewRegistry()
+
+	err := registry.Register(models.Model{Name: "broken", ContextWindow: 10, MaxOutputTokens: 20, API: models.ChatAPI, Encoding: models.EncodingCL100k})
+	assert.Error(t, err)
+
+	err = registry.Register(models.Model{Name: "local", ContextWindow: 10, MaxOutputTokens: 5, API: "edits", Encoding: models.EncodingCL100k})
+	assert.Error(t, err)
+
+	err = registry.Register(models.Model{Name: "local", ContextWindow: 10, MaxOutputTokens: 5, API: models.ChatAPI, Encoding: models.EncodingCL100k})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"local"}, registry.Names())
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+	contents := `{
+  "models": [
+    {"name": "gpt-4o", "contextWindow": 64000, "maxOutputTokens": 8000, "api": "chat", "encoding": "o200k_base"},
+    {"name": "llama-3-70b", "contextWindow": 8192, "maxOutputTokens": 2048, "api": "chat", "encoding": "cl100k_base", "pricing": {"input": 0.001, "output": 0.002}}
+  ],
+  "azureDeployments": {"infra-gen": "llama-3-70b"}
+}`
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+
+	registry := models.Default()
+	require.NoError(t, registry.LoadFile(path))
+
+	m, err := registry.Lookup("gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, 64000, m.ContextWindow)
+
+	m, err = registry.Lookup("infra-gen")
+	require.NoError(t, err)
+	assert.Equal(t, "llama-3-70b", m.Name)
+	assert.InDelta(t, 0.004, m.Cost(2000, 1000), 1e-9)
+}
+
+func TestParseDeploymentMap(t *testing.T) {
+	deployments, err := models.ParseDeploymentMap("chat=gpt-4o, legacy = gpt-35-turbo,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"chat": "gpt-4o", "legacy": "gpt-35-turbo"}, deployments)
+
+	_, err = models.ParseDeploymentMap("chat")
+	assert.Error(t, err)
+}
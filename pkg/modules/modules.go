@@ -0,0 +1,320 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// Dir is the directory of the working directory that holds the local modules.
+	Dir = "modules"
+
+	// manifestFile records the modules terraform init installed, relative to the working directory.
+	manifestFile = ".terraform/modules/modules.json"
+)
+
+// registrySource matches registry module sources such as terraform-aws-modules/vpc/aws,
+// optionally prefixed by a registry host.
+var registrySource = regexp.MustCompile(`^([a-z0-9.-]+\.[a-z]+/)?[A-Za-z0-9_-]+/[A-Za-z0-9_-]+/[a-z0-9]+(//.*)?$`)
+
+// Input is a variable a module accepts.
+type Input struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// Output is a value a module exposes.
+type Output struct {
+	Name        string
+	Description string
+}
+
+// Module is a module the workspace can call, with its interface when its source is available.
+type Module struct {
+	// Name is the directory name of a local module or the label of the module block calling a registry module.
+	Name string
+	// Source is the value to use as the source of a module block.
+	Source string
+	// Version is the version constraint of a registry module.
+	Version string
+	Inputs  []Input
+	Outputs []Output
+}
+
+// Parse reads the inputs and outputs of the module in dir. Files that fail to parse are skipped.
+func Parse(dir string) (*Module, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing terraform files: %w", err)
+	}
+
+	files := make(map[string][]byte, len(names))
+
+	for _, name := range names {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", name, err)
+		}
+
+		files[filepath.Base(name)] = src
+	}
+
+	return ParseFiles(filepath.Base(dir), files), nil
+}
+
+// ParseFiles reads the inputs and outputs of a module from its files, keyed by name.
+// Inputs and outputs are sorted by name and files that fail to parse are skipped.
+func ParseFiles(name string, files map[string][]byte) *Module {
+	module := &Module{Name: name}
+
+	for fileName, src := range files {
+		if filepath.Ext(fileName) != ".tf" {
+			continue
+		}
+
+		body, ok := parseBody(src)
+		if !ok {
+			continue
+		}
+
+		for _, block := range body.Blocks {
+			if len(block.Labels) != 1 {
+				continue
+			}
+
+			switch block.Type {
+			case "variable":
+				module.Inputs = append(module.Inputs, parseInput(src, block))
+			case "output":
+				module.Outputs = append(module.Outputs, Output{
+					Name:        block.Labels[0],
+					Description: stringAttribute(block.Body, "description"),
+				})
+			}
+		}
+	}
+
+	sort.Slice(module.Inputs, func(i, j int) bool { return module.Inputs[i].Name < module.Inputs[j].Name })
+	sort.Slice(module.Outputs, func(i, j int) bool { return module.Outputs[i].Name < module.Outputs[j].Name })
+
+	return module
+}
+
+// Discover returns the local modules under the modules directory of workingDir and the registry
+// modules called by its .tf files. Registry modules have inputs and outputs once terraform init
+// has downloaded them.
+func Discover(workingDir string) ([]Module, error) {
+	dirs, err := filepath.Glob(filepath.Join(workingDir, Dir, "*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing modules: %w", err)
+	}
+
+	var modules []Module
+
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+
+		module, err := Parse(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		module.Source = "./" + Dir + "/" + module.Name
+		modules = append(modules, *module)
+	}
+
+	registry, err := registryModules(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(modules, registry...), nil
+}
+
+// registryModules returns the registry modules called by the .tf files of workingDir.
+func registryModules(workingDir string) ([]Module, error) {
+	names, err := filepath.Glob(filepath.Join(workingDir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing terraform files: %w", err)
+	}
+
+	installed, err := installedModules(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []Module
+
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", name, err)
+		}
+
+		body, ok := parseBody(src)
+		if !ok {
+			continue
+		}
+
+		for _, block := range body.Blocks {
+			if block.Type != "module" || len(block.Labels) != 1 {
+				continue
+			}
+
+			source := stringAttribute(block.Body, "source")
+			if !registrySource.MatchString(source) || seen[source] {
+				continue
+			}
+
+			seen[source] = true
+			module := &Module{Name: block.Labels[0]}
+
+			if dir, ok := installed[block.Labels[0]]; ok {
+				if module, err = Parse(filepath.Join(workingDir, dir)); err != nil {
+					return nil, err
+				}
+
+				module.Name = block.Labels[0]
+			}
+
+			module.Source = source
+			module.Version = stringAttribute(block.Body, "version")
+			modules = append(modules, *module)
+		}
+	}
+
+	return modules, nil
+}
+
+// installedModules reads the directories terraform init downloaded the modules of workingDir to, keyed by module name.
+func installedModules(workingDir string) (map[string]string, error) {
+	src, err := os.ReadFile(filepath.Join(workingDir, manifestFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading module manifest: %w", err)
+	}
+
+	var manifest struct {
+		Modules []struct {
+			Key string `json:"Key"`
+			Dir string `json:"Dir"`
+		} `json:"Modules"`
+	}
+
+	if err := json.Unmarshal(src, &manifest); err != nil {
+		return nil, fmt.Errorf("error parsing module manifest: %w", err)
+	}
+
+	dirs := make(map[string]string, len(manifest.Modules))
+	for _, m := range manifest.Modules {
+		// Nested modules have keys such as vpc.subnets and are not called from the workspace
+		if m.Key != "" && !strings.Contains(m.Key, ".") {
+			dirs[m.Key] = m.Dir
+		}
+	}
+
+	return dirs, nil
+}
+
+// Describe summarizes the module and its interface for the model.
+func (m Module) Describe() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "module %q with source %q", m.Name, m.Source)
+
+	if m.Version != "" {
+		fmt.Fprintf(&b, " and version %q", m.Version)
+	}
+
+	if len(m.Inputs) > 0 {
+		inputs := make([]string, 0, len(m.Inputs))
+
+		for _, in := range m.Inputs {
+			switch {
+			case in.Required && in.Type != "":
+				inputs = append(inputs, fmt.Sprintf("%s (%s, required)", in.Name, in.Type))
+			case in.Required:
+				inputs = append(inputs, in.Name+" (required)")
+			case in.Type != "":
+				inputs = append(inputs, fmt.Sprintf("%s (%s)", in.Name, in.Type))
+			default:
+				inputs = append(inputs, in.Name)
+			}
+		}
+
+		b.WriteString(", inputs: " + strings.Join(inputs, ", "))
+	}
+
+	if len(m.Outputs) > 0 {
+		outputs := make([]string, 0, len(m.Outputs))
+		for _, out := range m.Outputs {
+			outputs = append(outputs, out.Name)
+		}
+
+		b.WriteString(", outputs: " + strings.Join(outputs, ", "))
+	}
+
+	return b.String()
+}
+
+// parseInput reads a variable block. Variables without a default are required.
+func parseInput(src []byte, block *hclsyntax.Block) Input {
+	input := Input{
+		Name:        block.Labels[0],
+		Description: stringAttribute(block.Body, "description"),
+	}
+
+	if attr, ok := block.Body.Attributes["type"]; ok {
+		input.Type = string(attr.Expr.Range().SliceBytes(src))
+	}
+
+	_, hasDefault := block.Body.Attributes["default"]
+	input.Required = !hasDefault
+
+	return input
+}
+
+// parseBody parses the HCL source, reporting false when it is invalid.
+func parseBody(src []byte) (*hclsyntax.Body, bool) {
+	file, diags := hclsyntax.ParseConfig(src, "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+
+	return body, ok
+}
+
+// stringAttribute returns the value of a literal string attribute, or "" when it is missing or not a literal.
+func stringAttribute(body *hclsyntax.Body, name string) string {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || value.IsNull() || value.Type() != cty.String {
+		return ""
+	}
+
+	return value.AsString()
+}
@@ -0,0 +1,160 @@
+package modules_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/modules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const moduleTemplate = `variable "name" {
+  type        = string
+  description = "Name of the bucket"
+}
+
+resource "aws_s3_bucket" "this" {
+  bucket = var.name
+}
+
+variable "versioning" {
+  type    = bool
+  default = true
+}
+
+output "arn" {
+  description = "ARN of the bucket"
+  value       = aws_s3_bucket.this.arn
+}
+`
+
+// writeFiles writes the files, keyed by their path relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.tf": `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 5.0"
+}
+
+module "network" {
+  source = "./modules/network"
+}
+
+module "dns" {
+  source = "app.terraform.io/acme/dns/aws"
+}
+`,
+		"modules/network/variables.tf": `variable "cidr" {
+  type = string
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+`,
+		"modules/network/outputs.tf": `output "subnet_ids" {
+  value = aws_subnet.this[*].id
+}
+`,
+		"modules/broken/main.tf":            `resource {`,
+		".terraform/modules/modules.json":   `{"Modules":[{"Key":"","Source":"","Dir":"."},{"Key":"vpc","Source":"registry.terraform.io/terraform-aws-modules/vpc/aws","Dir":".terraform/modules/vpc"},{"Key":"vpc.subnets","Dir":".terraform/modules/vpc/modules/subnets"}]}`,
+		".terraform/modules/vpc/main.tf":    `variable "azs" {}`,
+		".terraform/modules/vpc/outputs.tf": `output "vpc_id" {}`,
+	})
+
+	found, err := modules.Discover(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []modules.Module{
+		{Name: "broken", Source: "./modules/broken"},
+		{
+			Name:    "network",
+			Source:  "./modules/network",
+			Inputs:  []modules.Input{{Name: "cidr", Type: "string", Required: true}, {Name: "tags", Type: "map(string)"}},
+			Outputs: []modules.Output{{Name: "subnet_ids"}},
+		},
+		{
+			Name:    "vpc",
+			Source:  "terraform-aws-modules/vpc/aws",
+			Version: "~> 5.0",
+			Inputs:  []modules.Input{{Name: "azs", Required: true}},
+			Outputs: []modules.Output{{Name: "vpc_id"}},
+		},
+		{Name: "dns", Source: "app.terraform.io/acme/dns/aws"},
+	}, found)
+
+	assert.Equal(t, `module "network" with source "./modules/network", inputs: cidr (string, required), tags (map(string)), outputs: subnet_ids`, found[1].Describe())
+	assert.Equal(t, `module "vpc" with source "terraform-aws-modules/vpc/aws" and version "~> 5.0", inputs: azs (required), outputs: vpc_id`, found[2].Describe())
+}
+
+func TestDiscoverEmpty(t *testing.T) {
+	found, err := modules.Discover(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestNew(t *testing.T) {
+	files, err := modules.New("bucket", "An S3 bucket.", moduleTemplate)
+	require.NoError(t, err)
+
+	assert.Equal(t, `resource "aws_s3_bucket" "this" {
+  bucket = var.name
+}
+`, string(files["modules/bucket/main.tf"]))
+
+	assert.Equal(t, `variable "name" {
+  type        = string
+  description = "Name of the bucket"
+}
+
+variable "versioning" {
+  type    = bool
+  default = true
+}
+`, string(files["modules/bucket/variables.tf"]))
+
+	assert.Equal(t, `output "arn" {
+  description = "ARN of the bucket"
+  value       = aws_s3_bucket.this.arn
+}
+`, string(files["modules/bucket/outputs.tf"]))
+
+	assert.Equal(t, `module "bucket" {
+  source = "../.."
+  name   = "example"
+}
+`, string(files["modules/bucket/examples/basic/main.tf"]))
+
+	assert.Equal(t, "# bucket\n\nAn S3 bucket.\n\n## Usage\n\n```hcl\nmodule \"bucket\" {\n  source = \"./modules/bucket\"\n  name   = \"example\"\n}\n```\n"+
+		"\n## Inputs\n\n| Name | Description | Type | Required |\n|------|-------------|------|:--------:|\n"+
+		"| name | Name of the bucket | `string` | yes |\n| versioning | n/a | `bool` | no |\n"+
+		"\n## Outputs\n\n| Name | Description |\n|------|-------------|\n| arn | ARN of the bucket |\n",
+		string(files["modules/bucket/README.md"]))
+
+	assert.Len(t, files, 5)
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := modules.New("../bucket", "", moduleTemplate)
+	assert.Error(t, err)
+
+	_, err = modules.New("bucket", "", "resource {")
+	assert.Error(t, err)
+
+	_, err = modules.New("bucket", "", `variable "name" {}`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,175 @@
+package modules
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	mainFile      = "main.tf"
+	variablesFile = "variables.tf"
+	outputsFile   = "outputs.tf"
+	readmeFile    = "README.md"
+
+	// exampleDir is the example calling the module, relative to the module directory.
+	exampleDir = "examples/basic"
+)
+
+var (
+	errName   = errors.New("invalid module name")
+	errModule = errors.New("invalid module template")
+
+	validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+)
+
+// New lays out the generated module template as a module directory under the modules directory:
+// main.tf, variables.tf, outputs.tf, a README.md documenting its interface and an example calling it.
+// The returned files are keyed by their path relative to the working directory.
+func New(name string, description string, template string) (map[string][]byte, error) {
+	if !validName.MatchString(name) {
+		return nil, errors.Wrapf(errName, "%q must be lowercase letters, digits, '-' and '_'", name)
+	}
+
+	split, err := Split(template)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := path.Join(Dir, name)
+	module := ParseFiles(name, split)
+	module.Source = "./" + dir
+
+	files := make(map[string][]byte, len(split)+2)
+	for fileName, contents := range split {
+		files[path.Join(dir, fileName)] = contents
+	}
+
+	files[path.Join(dir, readmeFile)] = []byte(Readme(module, description))
+	files[path.Join(dir, exampleDir, mainFile)] = []byte(Call(module, "../.."))
+
+	return files, nil
+}
+
+// Split sorts the blocks of a module template into main.tf, variables.tf and outputs.tf.
+func Split(template string) (map[string][]byte, error) {
+	file, diags := hclwrite.ParseConfig([]byte(template), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, errors.Wrapf(errModule, "%s", diags.Error())
+	}
+
+	split := map[string]*hclwrite.File{}
+
+	for _, block := range file.Body().Blocks() {
+		name := mainFile
+
+		switch block.Type() {
+		case "variable":
+			name = variablesFile
+		case "output":
+			name = outputsFile
+		}
+
+		if _, ok := split[name]; !ok {
+			split[name] = hclwrite.NewEmptyFile()
+		} else {
+			split[name].Body().AppendNewline()
+		}
+
+		split[name].Body().AppendBlock(block)
+	}
+
+	if _, ok := split[mainFile]; !ok {
+		return nil, errors.Wrap(errModule, "no resources found")
+	}
+
+	files := make(map[string][]byte, len(split))
+	for name, f := range split {
+		files[name] = hclwrite.Format(f.Bytes())
+	}
+
+	return files, nil
+}
+
+// Readme documents the module: its description, how to call it and its inputs and outputs.
+func Readme(m *Module, description string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", m.Name)
+
+	if description != "" {
+		fmt.Fprintf(&b, "%s\n\n", description)
+	}
+
+	fmt.Fprintf(&b, "## Usage\n\n```hcl\n%s```\n", Call(m, m.Source))
+
+	if len(m.Inputs) > 0 {
+		b.WriteString("\n## Inputs\n\n| Name | Description | Type | Required |\n|------|-------------|------|:--------:|\n")
+
+		for _, in := range m.Inputs {
+			required := "no"
+			if in.Required {
+				required = "yes"
+			}
+
+			fmt.Fprintf(&b, "| %s | %s | `%s` | %s |\n", in.Name, cell(in.Description), cell(in.Type), required)
+		}
+	}
+
+	if len(m.Outputs) > 0 {
+		b.WriteString("\n## Outputs\n\n| Name | Description |\n|------|-------------|\n")
+
+		for _, out := range m.Outputs {
+			fmt.Fprintf(&b, "| %s | %s |\n", out.Name, cell(out.Description))
+		}
+	}
+
+	return b.String()
+}
+
+// Call returns a module block calling the module from source, with example values for its required inputs.
+func Call(m *Module, source string) string {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body().AppendNewBlock("module", []string{m.Name}).Body()
+	body.SetAttributeValue("source", cty.StringVal(source))
+
+	for _, in := range m.Inputs {
+		if in.Required {
+			body.SetAttributeValue(in.Name, exampleValue(in.Type))
+		}
+	}
+
+	return string(hclwrite.Format(file.Bytes()))
+}
+
+// exampleValue returns a placeholder value for an input of the given type.
+func exampleValue(typ string) cty.Value {
+	switch {
+	case strings.HasPrefix(typ, "number"):
+		return cty.NumberIntVal(1)
+	case strings.HasPrefix(typ, "bool"):
+		return cty.True
+	case strings.HasPrefix(typ, "list"), strings.HasPrefix(typ, "set"), strings.HasPrefix(typ, "tuple"):
+		return cty.EmptyTupleVal
+	case strings.HasPrefix(typ, "map"), strings.HasPrefix(typ, "object"):
+		return cty.EmptyObjectVal
+	default:
+		return cty.StringVal("example")
+	}
+}
+
+// cell makes text safe to use in a Markdown table cell.
+func cell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return "n/a"
+	}
+
+	return strings.ReplaceAll(text, "|", "\\|")
+}
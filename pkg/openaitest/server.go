@@ -0,0 +1,346 @@
+package openaitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// API is the endpoint a request was sent to.
+type API string
+
+const (
+	// Chat is the chat completions endpoint.
+	Chat API = "chat"
+	// Completions is the legacy completions endpoint.
+	Completions API = "completions"
+)
+
+// Flavor is the service a request was sent to.
+type Flavor string
+
+const (
+	// OpenAI is the OpenAI API, under /v1.
+	OpenAI Flavor = "openai"
+	// Azure is the Azure OpenAI API, under /openai/deployments/<deployment>.
+	Azure Flavor = "azure"
+)
+
+// paths match the endpoints of both services.
+var paths = []struct {
+	pattern *regexp.Regexp
+	flavor  Flavor
+	api     API
+}{
+	{regexp.MustCompile(`^/v1/chat/completions$`), OpenAI, Chat},
+	{regexp.MustCompile(`^/v1/completions$`), OpenAI, Completions},
+	{regexp.MustCompile(`^/v1/engines/([^/]+)/completions$`), OpenAI, Completions},
+	{regexp.MustCompile(`^/openai/deployments/([^/]+)/chat/completions$`), Azure, Chat},
+	{regexp.MustCompile(`^/openai/deployments/([^/]+)/completions$`), Azure, Completions},
+}
+
+// Reply is a scripted response. The zero value beyond Content answers with Content.
+type Reply struct {
+	// Content is the completion.
+	Content string
+	// Status is the status code of an error response, such as 429 or 500.
+	Status int
+	// Malformed answers with a body that is not JSON.
+	Malformed bool
+	// Expect checks the request, a non-nil error fails the test.
+	Expect func(r Request) error
+}
+
+// Error returns a reply that fails with the status code.
+func Error(status int) Reply {
+	return Reply{Status: status}
+}
+
+// Request is a request the server received.
+type Request struct {
+	Flavor Flavor
+	API    API
+	// Deployment is the Azure deployment or the OpenAI engine of the path, if any.
+	Deployment string
+	Model      string
+	// Prompt is the prompt of a completion, or the messages of a chat joined by newlines.
+	Prompt string
+	Stream bool
+	Header http.Header
+	Body   []byte
+}
+
+// Server is an in-process OpenAI and Azure OpenAI server that answers with scripted replies in order.
+type Server struct {
+	// URL is the Azure OpenAI endpoint. OpenAI clients use BaseURL.
+	URL string
+	// Key is the API key requests must carry, as a bearer token for OpenAI and as api-key for Azure.
+	// Requests are not authenticated when it is empty.
+	Key string
+
+	t      testing.TB
+	server *httptest.Server
+
+	mu       sync.Mutex
+	replies  []Reply
+	requests []Request
+}
+
+// NewServer starts a server with the scripted replies. It is closed when the test ends, which fails
+// when replies were left unused.
+func NewServer(t testing.TB, replies ...Reply) *Server {
+	t.Helper()
+
+	s := &Server{t: t, replies: replies}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL
+
+	t.Cleanup(func() {
+		s.server.Close()
+
+		if left := s.Pending(); left > 0 {
+			t.Errorf("openaitest: %d scripted replies were not requested", left)
+		}
+	})
+
+	return s
+}
+
+// BaseURL is the base URL of the OpenAI API, for WithBaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + "/v1"
+}
+
+// Enqueue scripts more replies.
+func (s *Server) Enqueue(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replies = append(s.replies, replies...)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request{}, s.requests...)
+}
+
+// Pending returns the number of replies that were not requested yet.
+func (s *Server) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.replies)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		s.t.Errorf("openaitest: %s", err)
+		writeError(w, http.StatusNotFound, err.Error())
+
+		return
+	}
+
+	if !s.authorized(req) {
+		writeError(w, http.StatusUnauthorized, "invalid api key")
+
+		return
+	}
+
+	reply, ok := s.next(req)
+	if !ok {
+		s.t.Errorf("openaitest: no scripted reply for %s %s request: %s", req.Flavor, req.API, req.Prompt)
+		writeError(w, http.StatusInternalServerError, "no scripted reply")
+
+		return
+	}
+
+	if reply.Expect != nil {
+		if err := reply.Expect(req); err != nil {
+			s.t.Errorf("openaitest: unexpected request: %s", err)
+		}
+	}
+
+	switch {
+	case reply.Status != 0:
+		writeError(w, reply.Status, http.StatusText(reply.Status))
+	case reply.Malformed:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"choices": [`)
+	case req.Stream:
+		writeStream(w, req, reply.Content)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(completion(req, reply.Content))
+	}
+}
+
+// next records the request and takes the next reply.
+func (s *Server) next(req Request) (Reply, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req)
+
+	if len(s.replies) == 0 {
+		return Reply{}, false
+	}
+
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+
+	return reply, true
+}
+
+// authorized checks the key the way the service of the request does.
+func (s *Server) authorized(req Request) bool {
+	if s.Key == "" {
+		return true
+	}
+
+	if req.Flavor == Azure {
+		return req.Header.Get("api-key") == s.Key
+	}
+
+	return req.Header.Get("Authorization") == "Bearer "+s.Key
+}
+
+// parseRequest reads the endpoint and the prompt of the request.
+func parseRequest(r *http.Request) (Request, error) {
+	req := Request{Header: r.Header.Clone()}
+
+	if r.Method != http.MethodPost {
+		return req, fmt.Errorf("unexpected method %s %s", r.Method, r.URL.Path)
+	}
+
+	found := false
+
+	for _, p := range paths {
+		if match := p.pattern.FindStringSubmatch(r.URL.Path); match != nil {
+			req.Flavor, req.API, found = p.flavor, p.api, true
+			if len(match) > 1 {
+				req.Deployment = match[1]
+			}
+
+			break
+		}
+	}
+
+	if !found {
+		return req, fmt.Errorf("unexpected path %s", r.URL.Path)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return req, fmt.Errorf("error reading body: %w", err)
+	}
+
+	req.Body = body
+
+	var payload struct {
+		Model    string          `json:"model"`
+		Stream   bool            `json:"stream"`
+		Prompt   json.RawMessage `json:"prompt"`
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return req, fmt.Errorf("error decoding body: %w", err)
+	}
+
+	req.Model, req.Stream = payload.Model, payload.Stream
+
+	if req.API == Chat {
+		contents := make([]string, 0, len(payload.Messages))
+		for _, m := range payload.Messages {
+			contents = append(contents, m.Content)
+		}
+
+		req.Prompt = strings.Join(contents, "\n")
+
+		return req, nil
+	}
+
+	// The prompt is a string or a list of strings
+	var prompts []string
+	if err := json.Unmarshal(payload.Prompt, &prompts); err != nil {
+		var prompt string
+		if err := json.Unmarshal(payload.Prompt, &prompt); err != nil {
+			return req, fmt.Errorf("error decoding prompt: %w", err)
+		}
+
+		prompts = []string{prompt}
+	}
+
+	req.Prompt = strings.Join(prompts, "\n")
+
+	return req, nil
+}
+
+// completion returns the response body of the request's API.
+func completion(req Request, content string) map[string]any {
+	choice := map[string]any{"index": 0, "finish_reason": "stop"}
+	object := "text_completion"
+
+	if req.API == Chat {
+		choice["message"] = map[string]any{"role": "assistant", "content": content}
+		object = "chat.completion"
+	} else {
+		choice["text"] = content
+	}
+
+	return map[string]any{
+		"id":      "openaitest",
+		"object":  object,
+		"created": time.Now().Unix(),
+		"model":   req.Model,
+		"choices": []any{choice},
+		"usage":   map[string]any{"prompt_tokens": 0, "completion_tokens": 0, "total_tokens": 0},
+	}
+}
+
+// writeStream streams the content word by word as server-sent events.
+func writeStream(w http.ResponseWriter, req Request, content string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+
+	flusher, _ := w.(http.Flusher)
+
+	for _, chunk := range strings.SplitAfter(content, " ") {
+		choice := map[string]any{"index": 0}
+		if req.API == Chat {
+			choice["delta"] = map[string]any{"content": chunk}
+		} else {
+			choice["text"] = chunk
+		}
+
+		event, _ := json.Marshal(map[string]any{"id": "openaitest", "model": req.Model, "choices": []any{choice}})
+		fmt.Fprintf(w, "data: %s\n\n", event)
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	_, _ = io.WriteString(w, "data: [DONE]\n\n")
+}
+
+// writeError writes an error the way the OpenAI API does.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"message": message, "type": "openaitest"},
+	})
+}
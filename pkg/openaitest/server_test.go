@@ -0,0 +1,186 @@
+package openaitest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	openai "github.com/PullRequestInc/go-gpt3"
+	azureopenai "github.com/akhilsharma90/terraform-assistant/pkg/gpt3"
+	"github.com/akhilsharma90/terraform-assistant/pkg/openaitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIChat(t *testing.T) {
+	server := openaitest.NewServer(t, openaitest.Reply{
+		Content: "hello",
+		Expect: func(r openaitest.Request) error {
+			if r.Model != "gpt-4o" {
+				return errors.New("unexpected model " + r.Model)
+			}
+
+			return nil
+		},
+	})
+	server.Key = "test-key"
+
+	client := openai.NewClient("test-key", openai.WithBaseURL(server.BaseURL()))
+
+	resp, err := client.ChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []openai.ChatCompletionRequestMessage{{Role: "user", Content: "say hello"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", resp.Choices[0].Message.Content)
+
+	requests := server.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, openaitest.OpenAI, requests[0].Flavor)
+	assert.Equal(t, openaitest.Chat, requests[0].API)
+	assert.Equal(t, "say hello", requests[0].Prompt)
+	assert.Equal(t, "Bearer test-key", requests[0].Header.Get("Authorization"))
+}
+
+func TestOpenAICompletionWithEngine(t *testing.T) {
+	server := openaitest.NewServer(t, openaitest.Reply{Content: "hello"})
+
+	client := openai.NewClient("test-key", openai.WithBaseURL(server.BaseURL()))
+
+	resp, err := client.CompletionWithEngine(context.Background(), "text-davinci-003", openai.CompletionRequest{
+		Prompt: []string{"say hello"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", resp.Choices[0].Text)
+
+	requests := server.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, openaitest.Completions, requests[0].API)
+	assert.Equal(t, "text-davinci-003", requests[0].Deployment)
+	assert.Equal(t, "say hello", requests[0].Prompt)
+}
+
+func TestOpenAIChatStream(t *testing.T) {
+	server := openaitest.NewServer(t, openaitest.Reply{Content: "hello streaming world"})
+
+	client := openai.NewClient("test-key", openai.WithBaseURL(server.BaseURL()))
+
+	var chunks []string
+
+	err := client.ChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []openai.ChatCompletionRequestMessage{{Role: "user", Content: "say hello"}},
+	}, func(resp *openai.ChatCompletionStreamResponse) {
+		chunks = append(chunks, resp.Choices[0].Delta.Content)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello ", "streaming ", "world"}, chunks)
+	assert.True(t, server.Requests()[0].Stream)
+}
+
+func TestAzure(t *testing.T) {
+	server := openaitest.NewServer(t, openaitest.Reply{Content: "hello"}, openaitest.Reply{Content: "hello stream"})
+	server.Key = "test-key"
+
+	client, err := azureopenai.NewClient(server.URL, "test-key", "infra-gen")
+	require.NoError(t, err)
+
+	resp, err := client.ChatCompletion(context.Background(), azureopenai.ChatCompletionRequest{
+		Messages: []azureopenai.ChatCompletionRequestMessage{{Role: "user", Content: "say hello"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", resp.Choices[0].Message.Content)
+
+	var text strings.Builder
+
+	err = client.CompletionStream(context.Background(), azureopenai.CompletionRequest{
+		Prompt: []string{"say hello"},
+	}, func(resp *azureopenai.CompletionResponse) {
+		text.WriteString(resp.Choices[0].Text)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello stream", text.String())
+
+	requests := server.Requests()
+	require.Len(t, requests, 2)
+
+	for _, r := range requests {
+		assert.Equal(t, openaitest.Azure, r.Flavor)
+		assert.Equal(t, "infra-gen", r.Deployment)
+		assert.Equal(t, "test-key", r.Header.Get("api-key"))
+	}
+
+	assert.Equal(t, openaitest.Chat, requests[0].API)
+	assert.Equal(t, openaitest.Completions, requests[1].API)
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		reply  openaitest.Reply
+		status int
+	}{
+		{name: "rate limited", reply: openaitest.Error(http.StatusTooManyRequests), status: http.StatusTooManyRequests},
+		{name: "server error", reply: openaitest.Error(http.StatusInternalServerError), status: http.StatusInternalServerError},
+		{name: "malformed", reply: openaitest.Reply{Malformed: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := openaitest.NewServer(t, tt.reply)
+
+			client := openai.NewClient("test-key", openai.WithBaseURL(server.BaseURL()))
+
+			_, err := client.ChatCompletion(context.Background(), openai.ChatCompletionRequest{
+				Model:    "gpt-4o",
+				Messages: []openai.ChatCompletionRequestMessage{{Role: "user", Content: "say hello"}},
+			})
+			require.Error(t, err)
+
+			var apiErr openai.APIError
+			if tt.status == 0 {
+				assert.False(t, errors.As(err, &apiErr), err)
+
+				return
+			}
+
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+		})
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.Key = "test-key"
+
+	client, err := azureopenai.NewClient(server.URL, "wrong-key", "infra-gen")
+	require.NoError(t, err)
+
+	_, err = client.ChatCompletion(context.Background(), azureopenai.ChatCompletionRequest{
+		Messages: []azureopenai.ChatCompletionRequestMessage{{Role: "user", Content: "say hello"}},
+	})
+
+	var apiErr azureopenai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Empty(t, server.Requests())
+}
+
+func TestEnqueue(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.Enqueue(openaitest.Reply{Content: "later"})
+	assert.Equal(t, 1, server.Pending())
+
+	client := openai.NewClient("test-key", openai.WithBaseURL(server.BaseURL()))
+
+	resp, err := client.ChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []openai.ChatCompletionRequestMessage{{Role: "user", Content: "say hello"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "later", resp.Choices[0].Message.Content)
+	assert.Zero(t, server.Pending())
+}
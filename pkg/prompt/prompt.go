@@ -0,0 +1,100 @@
+package prompt
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Priority decides which segments are dropped first when a prompt does not fit
+// in the context window. Lower priorities are dropped first.
+type Priority int
+
+const (
+	// PriorityInventory is for workspace inventory such as the existing resources.
+	PriorityInventory Priority = iota + 1
+	// PriorityHistory is for earlier turns of the conversation.
+	PriorityHistory
+	// PriorityContext is for context the generated code should follow.
+	PriorityContext
+	// PriorityRequired is for the request itself, which is never dropped.
+	PriorityRequired
+)
+
+var errBudget = errors.New("prompt exceeds token budget")
+
+// Segment is one piece of a prompt.
+type Segment struct {
+	Priority Priority
+	Text     string
+}
+
+// Required returns a segment that is never dropped.
+func Required(text string) Segment {
+	return Segment{Priority: PriorityRequired, Text: text}
+}
+
+// Render joins the segments into the prompt text.
+func Render(segments []Segment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteString(s.Text)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// Fit drops segments until the rendered prompt uses at most budget tokens as counted by count.
+// Segments are dropped lowest priority first and, within a priority, oldest first.
+// The kept segments stay in their original order. An error is returned when the
+// required segments alone exceed the budget.
+func Fit(segments []Segment, budget int, count func(string) int) ([]Segment, error) {
+	tokens := make([]int, len(segments))
+	total := 0
+
+	for i, s := range segments {
+		tokens[i] = count(s.Text + "\n")
+		total += tokens[i]
+	}
+
+	if total <= budget {
+		return segments, nil
+	}
+
+	// Order the droppable segments by priority, keeping their original order within a priority.
+	order := make([]int, 0, len(segments))
+	for i, s := range segments {
+		if s.Priority < PriorityRequired {
+			order = append(order, i)
+		}
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return segments[order[a]].Priority < segments[order[b]].Priority
+	})
+
+	dropped := make(map[int]bool, len(order))
+	for _, i := range order {
+		if total <= budget {
+			break
+		}
+
+		dropped[i] = true
+		total -= tokens[i]
+	}
+
+	if total > budget {
+		return nil, errors.Wrapf(errBudget, "required prompt uses %d tokens but only %d are available", total, budget)
+	}
+
+	kept := make([]Segment, 0, len(segments)-len(dropped))
+	for i, s := range segments {
+		if !dropped[i] {
+			kept = append(kept, s)
+		}
+	}
+
+	return kept, nil
+}
@@ -0,0 +1,55 @@
+package prompt_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// words counts one token per word, which is enough to test the budget arithmetic.
+func words(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestFitKeepsEverythingWithinBudget(t *testing.T) {
+	segments := []prompt.Segment{
+		{Priority: prompt.PriorityInventory, Text: "aws_vpc.main"},
+		prompt.Required("create a subnet"),
+	}
+
+	kept, err := prompt.Fit(segments, 10, words)
+	require.NoError(t, err)
+	assert.Equal(t, segments, kept)
+}
+
+func TestFitDropsLowestPriorityFirst(t *testing.T) {
+	segments := []prompt.Segment{
+		{Priority: prompt.PriorityInventory, Text: "aws_vpc.main aws_subnet.a aws_subnet.b"},
+		{Priority: prompt.PriorityHistory, Text: "use t3 instances"},
+		{Priority: prompt.PriorityHistory, Text: "add tags"},
+		prompt.Required("create an ec2 instance"),
+	}
+
+	kept, err := prompt.Fit(segments, 9, words)
+	require.NoError(t, err)
+	assert.Equal(t, []prompt.Segment{segments[1], segments[2], segments[3]}, kept)
+
+	kept, err = prompt.Fit(segments, 6, words)
+	require.NoError(t, err)
+	assert.Equal(t, []prompt.Segment{segments[2], segments[3]}, kept)
+}
+
+func TestFitRequiredExceedsBudget(t *testing.T) {
+	segments := []prompt.Segment{prompt.Required("create an ec2 instance")}
+
+	_, err := prompt.Fit(segments, 2, words)
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	segments := []prompt.Segment{prompt.Required("a"), prompt.Required("b")}
+	assert.Equal(t, "a\nb\n", prompt.Render(segments))
+}
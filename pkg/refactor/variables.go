@@ -0,0 +1,474 @@
+package refactor
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// VariablesFile is the file variable blocks are written to.
+	VariablesFile = "variables.tf"
+	// OutputsFile is the file output blocks are written to.
+	OutputsFile = "outputs.tf"
+	// TfvarsFile is the file variable values are written to.
+	TfvarsFile = "terraform.tfvars"
+)
+
+// descriptions are the attributes that are lifted into variables by name, with a readable description.
+var descriptions = map[string]string{
+	"ami":               "AMI ID",
+	"availability_zone": "Availability zone",
+	"bucket":            "Bucket name",
+	"cidr_block":        "CIDR block",
+	"cidr_blocks":       "CIDR blocks",
+	"engine_version":    "Engine version",
+	"image_id":          "Image ID",
+	"instance_class":    "Instance class",
+	"instance_type":     "Instance type",
+	"key_name":          "Key pair name",
+	"location":          "Location",
+	"machine_type":      "Machine type",
+	"name":              "Name",
+	"node_type":         "Node type",
+	"project":           "Project",
+	"region":            "Region",
+	"vm_size":           "VM size",
+	"zone":              "Zone",
+}
+
+// patterns are values that are lifted into variables whatever the attribute is called.
+var patterns = []*regexp.Regexp{
+	regexp.MustCompile(`^\d{1,3}(\.\d{1,3}){3}/\d{1,2}$`),             // CIDR
+	regexp.MustCompile(`^ami-[0-9a-f]{8,17}$`),                        // AMI
+	regexp.MustCompile(`^(us|eu|ap|sa|ca|me|af|il)-[a-z]+-\d[a-z]?$`), // AWS region or zone
+}
+
+// arnTypes are resource types known to export an arn attribute.
+var arnTypes = map[string]bool{
+	"aws_cloudwatch_log_group": true,
+	"aws_db_instance":          true,
+	"aws_dynamodb_table":       true,
+	"aws_ecr_repository":       true,
+	"aws_ecs_cluster":          true,
+	"aws_eks_cluster":          true,
+	"aws_iam_policy":           true,
+	"aws_iam_role":             true,
+	"aws_instance":             true,
+	"aws_internet_gateway":     true,
+	"aws_kms_key":              true,
+	"aws_lambda_function":      true,
+	"aws_lb":                   true,
+	"aws_lb_target_group":      true,
+	"aws_rds_cluster":          true,
+	"aws_route_table":          true,
+	"aws_s3_bucket":            true,
+	"aws_security_group":       true,
+	"aws_sns_topic":            true,
+	"aws_sqs_queue":            true,
+	"aws_subnet":               true,
+	"aws_vpc":                  true,
+}
+
+// Variable is a variable lifted out of a hard-coded value.
+type Variable struct {
+	Name        string
+	Type        string
+	Description string
+	Value       cty.Value
+}
+
+// Output is an output added for a resource attribute.
+type Output struct {
+	Name        string
+	Value       string
+	Description string
+}
+
+// Result is the outcome of Parameterize.
+type Result struct {
+	// Files holds the complete new contents of every file that changed, keyed by name.
+	Files map[string][]byte
+	// Variables are the variables that were added.
+	Variables []Variable
+	// Outputs are the outputs that were added.
+	Outputs []Output
+}
+
+// literal is one hard-coded value found in a target file.
+type literal struct {
+	body  *hclwrite.Body
+	name  string
+	label string
+	addr  string
+	value cty.Value
+	key   string
+}
+
+// Parameterize lifts hard-coded values in the target files into variables, writes their
+// values to terraform.tfvars and adds outputs for the IDs and ARNs of their resources.
+// files holds the .tf files of the workspace, and terraform.tfvars when it exists, keyed by
+// name. When targets is empty every .tf file is a target. It works on the syntax alone, so
+// neither terraform nor a model is needed.
+func Parameterize(files map[string][]byte, targets []string) (*Result, error) {
+	parsed := map[string]*hclwrite.File{}
+
+	for name, src := range files {
+		f, diags := hclwrite.ParseConfig(src, name, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("error parsing %s: %w", name, diags)
+		}
+
+		parsed[name] = f
+	}
+
+	if len(targets) == 0 {
+		for name := range files {
+			if filepath.Ext(name) == ".tf" {
+				targets = append(targets, name)
+			}
+		}
+	}
+
+	sort.Strings(targets)
+
+	variables, outputs := declared(parsed)
+
+	var (
+		literals []literal
+		changed  = map[string]bool{}
+		res      = &Result{Files: map[string][]byte{}}
+	)
+
+	for _, name := range targets {
+		f, ok := parsed[name]
+		if !ok {
+			return nil, fmt.Errorf("error parameterizing %s: file not found", name)
+		}
+
+		for _, block := range f.Body().Blocks() {
+			switch block.Type() {
+			case "resource", "data", "provider":
+				found := findLiterals(block.Body(), blockLabel(block), address(block))
+				if len(found) > 0 {
+					literals = append(literals, found...)
+					changed[name] = true
+				}
+			}
+		}
+
+		res.Outputs = append(res.Outputs, resourceOutputs(f.Body(), outputs)...)
+	}
+
+	res.Variables = nameVariables(literals, variables)
+
+	if len(res.Variables) > 0 {
+		appendVariables(file(parsed, VariablesFile), file(parsed, TfvarsFile), res.Variables)
+		changed[VariablesFile] = true
+		changed[TfvarsFile] = true
+	}
+
+	if len(res.Outputs) > 0 {
+		outputsFile := file(parsed, OutputsFile)
+
+		for _, o := range res.Outputs {
+			body := outputsFile.Body()
+			if len(body.Blocks()) > 0 || len(body.Attributes()) > 0 {
+				body.AppendNewline()
+			}
+
+			block := body.AppendNewBlock("output", []string{o.Name})
+			block.Body().SetAttributeValue("description", cty.StringVal(o.Description))
+			block.Body().SetAttributeRaw("value", rawTokens(o.Value))
+		}
+
+		changed[OutputsFile] = true
+	}
+
+	for name := range changed {
+		res.Files[name] = hclwrite.Format(parsed[name].Bytes())
+	}
+
+	return res, nil
+}
+
+// declared returns the names of the variables and outputs already declared in the files.
+func declared(files map[string]*hclwrite.File) (map[string]bool, map[string]bool) {
+	variables, outputs := map[string]bool{}, map[string]bool{}
+
+	for _, f := range files {
+		for _, block := range f.Body().Blocks() {
+			if len(block.Labels()) != 1 {
+				continue
+			}
+
+			switch block.Type() {
+			case "variable":
+				variables[block.Labels()[0]] = true
+			case "output":
+				outputs[block.Labels()[0]] = true
+			}
+		}
+	}
+
+	return variables, outputs
+}
+
+// findLiterals returns the hard-coded values worth lifting in body and its nested blocks.
+func findLiterals(body *hclwrite.Body, label string, addr string) []literal {
+	var literals []literal
+
+	attrs := body.Attributes()
+	names := make([]string, 0, len(attrs))
+
+	for name := range attrs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, ok := literalValue(attrs[name])
+		if !ok || !liftable(name, value) {
+			continue
+		}
+
+		literals = append(literals, literal{
+			body:  body,
+			name:  name,
+			label: label,
+			addr:  addr,
+			value: value,
+			key:   name + "=" + string(hclwrite.TokensForValue(value).Bytes()),
+		})
+	}
+
+	for _, nested := range body.Blocks() {
+		literals = append(literals, findLiterals(nested.Body(), label+"_"+nested.Type(), addr)...)
+	}
+
+	return literals
+}
+
+// literalValue returns the value of an attribute that does not reference anything.
+func literalValue(attr *hclwrite.Attribute) (cty.Value, bool) {
+	src := attr.Expr().BuildTokens(nil).Bytes()
+
+	expr, diags := hclsyntax.ParseExpression(src, "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() || len(expr.Variables()) > 0 {
+		return cty.NilVal, false
+	}
+
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() {
+		return cty.NilVal, false
+	}
+
+	switch {
+	case value.Type().IsPrimitiveType():
+		return value, true
+	case value.Type().IsTupleType() && value.LengthInt() > 0:
+		// Lists of strings such as cidr_blocks become list(string) variables.
+		for _, elem := range value.AsValueSlice() {
+			if !elem.Type().Equals(cty.String) {
+				return cty.NilVal, false
+			}
+		}
+
+		return cty.ListVal(value.AsValueSlice()), true
+	default:
+		return cty.NilVal, false
+	}
+}
+
+// liftable reports whether a hard-coded value should become a variable.
+func liftable(name string, value cty.Value) bool {
+	if _, ok := descriptions[name]; ok {
+		return !value.Type().Equals(cty.String) || value.AsString() != ""
+	}
+
+	if !value.Type().Equals(cty.String) {
+		return false
+	}
+
+	for _, p := range patterns {
+		if p.MatchString(value.AsString()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nameVariables replaces the literals with variable references and returns the new variables.
+// Literals of the same attribute with the same value share a variable. A variable is named
+// after its attribute when that is unambiguous, and after its block and attribute otherwise.
+func nameVariables(literals []literal, taken map[string]bool) []Variable {
+	values := map[string]map[string]bool{}
+	for _, l := range literals {
+		if values[l.name] == nil {
+			values[l.name] = map[string]bool{}
+		}
+
+		values[l.name][l.key] = true
+	}
+
+	var (
+		variables []Variable
+		names     = map[string]string{}
+	)
+
+	for _, l := range literals {
+		name, ok := names[l.key]
+		if !ok {
+			name = l.name
+			if len(values[l.name]) > 1 || taken[name] {
+				name = l.label + "_" + l.name
+			}
+
+			name = unique(name, taken)
+			taken[name] = true
+			names[l.key] = name
+
+			variables = append(variables, Variable{
+				Name:        name,
+				Type:        typeexpr.TypeString(l.value.Type()),
+				Description: fmt.Sprintf("%s for %s", description(l.name), l.addr),
+				Value:       l.value,
+			})
+		}
+
+		l.body.SetAttributeTraversal(l.name, hcl.Traversal{
+			hcl.TraverseRoot{Name: "var"},
+			hcl.TraverseAttr{Name: name},
+		})
+	}
+
+	return variables
+}
+
+// unique returns name, or name with a numeric suffix when name is taken.
+func unique(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s_%d", name, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+func description(name string) string {
+	if d, ok := descriptions[name]; ok {
+		return d
+	}
+
+	return strings.ReplaceAll(name, "_", " ")
+}
+
+// resourceOutputs returns outputs for the ID, and ARN where there is one, of every resource in body
+// that does not have them yet.
+func resourceOutputs(body *hclwrite.Body, outputs map[string]bool) []Output {
+	var added []Output
+
+	for _, block := range body.Blocks() {
+		if block.Type() != "resource" || len(block.Labels()) != 2 {
+			continue
+		}
+
+		typ, name := block.Labels()[0], block.Labels()[1]
+
+		// aws_instance.web becomes instance_web_id
+		short := typ
+		if i := strings.Index(typ, "_"); i > 0 {
+			short = typ[i+1:]
+		}
+
+		attrs := []string{"id"}
+		if arnTypes[typ] {
+			attrs = append(attrs, "arn")
+		}
+
+		for _, attr := range attrs {
+			output := fmt.Sprintf("%s_%s_%s", short, name, attr)
+			if outputs[output] {
+				continue
+			}
+
+			outputs[output] = true
+
+			added = append(added, Output{
+				Name:        output,
+				Value:       fmt.Sprintf("%s.%s.%s", typ, name, attr),
+				Description: fmt.Sprintf("The %s of %s.%s", strings.ToUpper(attr), typ, name),
+			})
+		}
+	}
+
+	return added
+}
+
+// appendVariables appends variable blocks to variables.tf and their values to terraform.tfvars.
+func appendVariables(variablesFile *hclwrite.File, tfvarsFile *hclwrite.File, variables []Variable) {
+	for _, v := range variables {
+		body := variablesFile.Body()
+		if len(body.Blocks()) > 0 || len(body.Attributes()) > 0 {
+			body.AppendNewline()
+		}
+
+		block := body.AppendNewBlock("variable", []string{v.Name})
+		block.Body().SetAttributeRaw("type", rawTokens(v.Type))
+		block.Body().SetAttributeValue("description", cty.StringVal(v.Description))
+
+		tfvarsFile.Body().SetAttributeValue(v.Name, v.Value)
+	}
+}
+
+// file returns the parsed file with the given name, adding an empty one when it does not exist.
+func file(files map[string]*hclwrite.File, name string) *hclwrite.File {
+	if f, ok := files[name]; ok {
+		return f
+	}
+
+	files[name] = hclwrite.NewEmptyFile()
+
+	return files[name]
+}
+
+// blockLabel returns the most specific label of a block, e.g. "web" for resource "aws_instance" "web".
+func blockLabel(block *hclwrite.Block) string {
+	labels := block.Labels()
+	if len(labels) == 0 {
+		return block.Type()
+	}
+
+	return labels[len(labels)-1]
+}
+
+// address returns the Terraform address of a block, e.g. "aws_instance.web".
+func address(block *hclwrite.Block) string {
+	switch block.Type() {
+	case "resource":
+		return strings.Join(block.Labels(), ".")
+	default:
+		return strings.Join(append([]string{block.Type()}, block.Labels()...), ".")
+	}
+}
+
+// rawTokens parses the HCL source of an expression into tokens.
+func rawTokens(src string) hclwrite.Tokens {
+	f, _ := hclwrite.ParseConfig([]byte("expr = "+src+"\n"), "", hcl.Pos{Line: 1, Column: 1})
+
+	return f.Body().GetAttribute("expr").Expr().BuildTokens(nil)
+}
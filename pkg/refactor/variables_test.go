@@ -0,0 +1,158 @@
+package refactor_test
+
+import (
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/refactor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParameterize(t *testing.T) {
+	files := map[string][]byte{
+		"main.tf": []byte(`provider "aws" {
+  region = "us-east-2"
+}
+
+resource "aws_instance" "hello_future" {
+  ami           = "ami-0f65671a86f061fcd"
+  instance_type = "t2.micro"
+  subnet_id     = aws_subnet.main.id
+  monitoring    = true
+  tags = {
+    Name = "hello-future"
+  }
+}
+
+resource "aws_security_group" "web" {
+  vpc_id = aws_vpc.main.id
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/16"]
+  }
+}
+`),
+		"variables.tf": []byte(`variable "environment" {
+  type = string
+}
+`),
+	}
+
+	res, err := refactor.Parameterize(files, []string{"main.tf"})
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(res.Variables))
+	for _, v := range res.Variables {
+		names = append(names, v.Name)
+	}
+
+	assert.Equal(t, []string{"region", "ami", "instance_type", "cidr_blocks"}, names)
+	assert.Equal(t, "list(string)", res.Variables[3].Type)
+
+	assert.Equal(t, `provider "aws" {
+  region = var.region
+}
+
+resource "aws_instance" "hello_future" {
+  ami           = var.ami
+  instance_type = var.instance_type
+  subnet_id     = aws_subnet.main.id
+  monitoring    = true
+  tags = {
+    Name = "hello-future"
+  }
+}
+
+resource "aws_security_group" "web" {
+  vpc_id = aws_vpc.main.id
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = var.cidr_blocks
+  }
+}
+`, string(res.Files["main.tf"]))
+
+	assert.Equal(t, `variable "environment" {
+  type = string
+}
+
+variable "region" {
+  type        = string
+  description = "Region for provider.aws"
+}
+
+variable "ami" {
+  type        = string
+  description = "AMI ID for aws_instance.hello_future"
+}
+
+variable "instance_type" {
+  type        = string
+  description = "Instance type for aws_instance.hello_future"
+}
+
+variable "cidr_blocks" {
+  type        = list(string)
+  description = "CIDR blocks for aws_security_group.web"
+}
+`, string(res.Files[refactor.VariablesFile]))
+
+	assert.Equal(t, `region        = "us-east-2"
+ami           = "ami-0f65671a86f061fcd"
+instance_type = "t2.micro"
+cidr_blocks   = ["10.0.0.0/16"]
+`, string(res.Files[refactor.TfvarsFile]))
+
+	require.Len(t, res.Outputs, 4)
+	assert.Contains(t, string(res.Files[refactor.OutputsFile]), `output "instance_hello_future_id" {
+  description = "The ID of aws_instance.hello_future"
+  value       = aws_instance.hello_future.id
+}`)
+	assert.Contains(t, string(res.Files[refactor.OutputsFile]), `value       = aws_security_group.web.arn`)
+}
+
+func TestParameterizeNamesClashingVariables(t *testing.T) {
+	files := map[string][]byte{
+		"network.tf": []byte(`resource "aws_subnet" "public" {
+  cidr_block = "10.0.1.0/24"
+}
+
+resource "aws_subnet" "private" {
+  cidr_block = "10.0.2.0/24"
+}
+
+resource "aws_subnet" "spare" {
+  cidr_block = "10.0.2.0/24"
+}
+`),
+		"outputs.tf": []byte(`output "subnet_public_id" {
+  value = aws_subnet.public.id
+}
+`),
+	}
+
+	res, err := refactor.Parameterize(files, nil)
+	require.NoError(t, err)
+
+	require.Len(t, res.Variables, 2)
+	assert.Equal(t, "public_cidr_block", res.Variables[0].Name)
+	assert.Equal(t, "private_cidr_block", res.Variables[1].Name)
+	assert.Contains(t, string(res.Files["network.tf"]), `resource "aws_subnet" "spare" {
+  cidr_block = var.private_cidr_block
+}`)
+
+	// The existing output is kept and not added again.
+	assert.Len(t, res.Outputs, 5)
+	assert.NotContains(t, string(res.Files[refactor.OutputsFile])[len(files["outputs.tf"]):], `"subnet_public_id"`)
+}
+
+func TestParameterizeInvalidFile(t *testing.T) {
+	_, err := refactor.Parameterize(map[string][]byte{"main.tf": []byte("resource {")}, nil)
+	assert.Error(t, err)
+}
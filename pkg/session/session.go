@@ -0,0 +1,213 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// RoleUser and RoleAssistant are the roles of the messages of a conversation.
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+
+	// ext is the extension of saved sessions.
+	ext = ".json"
+)
+
+var errID = errors.New("invalid session id")
+
+// validID matches session IDs, so an ID cannot point outside the sessions directory.
+var validID = regexp.MustCompile(`^[0-9]{8}-[0-9]{6}-[0-9a-f]{4}$`)
+
+// Message is a turn of the conversation.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Session is a chat conversation and the changes it generated that are not applied yet.
+type Session struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	// Model is the deployment name the session talks to.
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	// Main is the pending template the conversation is working on.
+	Main string `json:"main,omitempty"`
+	// Pending are the generated files that are not stored yet, keyed by name.
+	Pending map[string]string `json:"pending"`
+
+	// undo are the pending files before every change, the last change last.
+	undo []snapshot
+}
+
+// snapshot is the state of a session before a change.
+type snapshot struct {
+	main    string
+	pending map[string]string
+	// messages is the number of messages before the change.
+	messages int
+}
+
+// New starts a session with the model.
+func New(model string) *Session {
+	now := time.Now()
+
+	random := make([]byte, 2)
+	if _, err := rand.Read(random); err != nil {
+		panic(err)
+	}
+
+	return &Session{
+		ID:      now.Format("20060102-150405") + "-" + hex.EncodeToString(random),
+		Created: now,
+		Model:   model,
+		Pending: map[string]string{},
+	}
+}
+
+// AddMessage appends a turn to the conversation.
+func (s *Session) AddMessage(role string, content string) {
+	s.Messages = append(s.Messages, Message{Role: role, Content: content})
+}
+
+// Stage adds the files to the pending changes, replacing pending files with the same name,
+// and makes main the template the conversation works on. The change can be undone, together
+// with the messages added after it.
+func (s *Session) Stage(main string, files map[string]string) {
+	s.undo = append(s.undo, snapshot{main: s.Main, pending: copyFiles(s.Pending), messages: len(s.Messages)})
+
+	s.Main = main
+	for name, contents := range files {
+		s.Pending[name] = contents
+	}
+}
+
+// Undo reverts the last staged change and drops the messages of its turn, so the model no longer sees
+// them. It reports false when there is nothing to undo.
+func (s *Session) Undo() bool {
+	if len(s.undo) == 0 {
+		return false
+	}
+
+	last := s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+	s.Main = last.main
+	s.Pending = last.pending
+	s.Messages = s.Messages[:min(last.messages, len(s.Messages))]
+
+	return true
+}
+
+// Commit returns the pending files and clears them, once they are stored. Commits cannot be undone.
+func (s *Session) Commit() map[string]string {
+	pending := s.Pending
+
+	s.Main = ""
+	s.Pending = map[string]string{}
+	s.undo = nil
+
+	return pending
+}
+
+// PendingNames returns the names of the pending files, sorted.
+func (s *Session) PendingNames() []string {
+	names := make([]string, 0, len(s.Pending))
+	for name := range s.Pending {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// DefaultDir returns the directory sessions are saved in by default.
+func DefaultDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding config dir: %w", err)
+	}
+
+	return filepath.Join(dir, "terraform-assistant", "sessions"), nil
+}
+
+// Save writes the session to dir as <id>.json.
+func Save(dir string, s *Session) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("error creating sessions dir: %w", err)
+	}
+
+	contents, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding session: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, s.ID+ext), contents, 0o600); err != nil {
+		return fmt.Errorf("error writing session: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads the session with the ID from dir.
+func Load(dir string, id string) (*Session, error) {
+	if !validID.MatchString(id) {
+		return nil, errors.Wrapf(errID, "%q", id)
+	}
+
+	contents, err := os.ReadFile(filepath.Join(dir, id+ext))
+	if err != nil {
+		return nil, fmt.Errorf("error reading session: %w", err)
+	}
+
+	var s Session
+	if err := json.Unmarshal(contents, &s); err != nil {
+		return nil, fmt.Errorf("error decoding session: %w", err)
+	}
+
+	if s.Pending == nil {
+		s.Pending = map[string]string{}
+	}
+
+	return &s, nil
+}
+
+// List returns the IDs of the sessions saved in dir, oldest first.
+func List(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+	if err != nil {
+		return nil, fmt.Errorf("error listing sessions: %w", err)
+	}
+
+	var ids []string
+
+	for _, path := range paths {
+		if id := strings.TrimSuffix(filepath.Base(path), ext); validID.MatchString(id) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+func copyFiles(files map[string]string) map[string]string {
+	copied := make(map[string]string, len(files))
+	for name, contents := range files {
+		copied[name] = contents
+	}
+
+	return copied
+}
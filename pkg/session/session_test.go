@@ -0,0 +1,85 @@
+package session_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStageUndoCommit(t *testing.T) {
+	s := session.New("gpt-4o")
+	assert.Regexp(t, `^\d{8}-\d{6}-[0-9a-f]{4}$`, s.ID)
+	assert.False(t, s.Undo())
+
+	s.Stage("web.tf", map[string]string{"web.tf": "v1"})
+	s.Stage("web.tf", map[string]string{"web.tf": "v2", "variables.tf": "vars"})
+	assert.Equal(t, []string{"variables.tf", "web.tf"}, s.PendingNames())
+	assert.Equal(t, "v2", s.Pending["web.tf"])
+
+	require.True(t, s.Undo())
+	assert.Equal(t, map[string]string{"web.tf": "v1"}, s.Pending)
+	assert.Equal(t, "web.tf", s.Main)
+
+	require.True(t, s.Undo())
+	assert.Empty(t, s.Pending)
+	assert.Equal(t, "", s.Main)
+	assert.False(t, s.Undo())
+
+	// Undoing a turn drops its messages
+	s.Stage("bucket.tf", map[string]string{"bucket.tf": "bucket"})
+	s.AddMessage(session.RoleUser, "create a bucket")
+	s.AddMessage(session.RoleAssistant, "bucket")
+	s.Stage("bucket.tf", map[string]string{"bucket.tf": "private bucket"})
+	s.AddMessage(session.RoleUser, "make it private")
+	s.AddMessage(session.RoleAssistant, "private bucket")
+
+	require.True(t, s.Undo())
+	assert.Equal(t, []session.Message{{Role: session.RoleUser, Content: "create a bucket"}, {Role: session.RoleAssistant, Content: "bucket"}}, s.Messages)
+	assert.Equal(t, map[string]string{"bucket.tf": "bucket"}, s.Pending)
+	s.Commit()
+
+	s.Stage("db.tf", map[string]string{"db.tf": "db"})
+	assert.Equal(t, map[string]string{"db.tf": "db"}, s.Commit())
+	assert.Empty(t, s.Pending)
+	assert.Equal(t, "", s.Main)
+	assert.False(t, s.Undo())
+}
+
+func TestSaveLoadList(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+
+	ids, err := session.List(dir)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	s := session.New("gpt-4o")
+	s.AddMessage(session.RoleUser, "create a bucket")
+	s.AddMessage(session.RoleAssistant, `resource "aws_s3_bucket" "logs" {}`)
+	s.Stage("bucket.tf", map[string]string{"bucket.tf": `resource "aws_s3_bucket" "logs" {}`})
+
+	require.NoError(t, session.Save(dir, s))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.json"), []byte("{}"), 0o600))
+
+	ids, err = session.List(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{s.ID}, ids)
+
+	loaded, err := session.Load(dir, s.ID)
+	require.NoError(t, err)
+	assert.Equal(t, s.ID, loaded.ID)
+	assert.Equal(t, "gpt-4o", loaded.Model)
+	assert.Equal(t, s.Messages, loaded.Messages)
+	assert.Equal(t, "bucket.tf", loaded.Main)
+	assert.Equal(t, s.Pending, loaded.Pending)
+	assert.True(t, s.Created.Equal(loaded.Created))
+
+	_, err = session.Load(dir, "../../etc/passwd")
+	assert.Error(t, err)
+
+	_, err = session.Load(dir, "20260101-000000-abcd")
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package state
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Drift is a resource that changed outside terraform, as a refresh-only plan finds it.
+type Drift struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	// Action is update when the resource changed and delete when it is gone.
+	Action string `json:"action"`
+	// Attributes are the attributes that changed, none for a resource that is gone.
+	Attributes []AttributeDrift `json:"attributes,omitempty"`
+}
+
+// AttributeDrift is an attribute whose actual value is not the one in the state.
+type AttributeDrift struct {
+	// Attribute is the dotted path of the attribute, with the indexes of lists as numbers.
+	Attribute string `json:"attribute"`
+	// State is the value in the state and Actual the value outside terraform, nil when it is not set.
+	// Both are Redacted when terraform marks them as sensitive.
+	State  any `json:"state"`
+	Actual any `json:"actual"`
+}
+
+// Drifted returns the resources of the refresh-only plan that changed outside terraform, ordered by address.
+func Drifted(plan *tfjson.Plan) []Drift {
+	if plan == nil {
+		return nil
+	}
+
+	var drifts []Drift
+
+	for _, rc := range plan.ResourceDrift {
+		if rc == nil || rc.Change == nil || rc.Mode != tfjson.ManagedResourceMode {
+			continue
+		}
+
+		drift := Drift{Address: rc.Address, Type: rc.Type}
+
+		switch {
+		case rc.Change.Actions.Delete():
+			drift.Action = "delete"
+		case rc.Change.Actions.Update():
+			drift.Action = "update"
+			attributeDrifts(&drift.Attributes, "", rc.Change.Before, rc.Change.After,
+				rc.Change.BeforeSensitive, rc.Change.AfterSensitive)
+
+			// Changes terraform ignores, such as the order of unordered sets, leave nothing to report
+			if len(drift.Attributes) == 0 {
+				continue
+			}
+		default:
+			continue
+		}
+
+		drifts = append(drifts, drift)
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Address < drifts[j].Address
+	})
+
+	return drifts
+}
+
+// attributeDrifts appends the values that differ between before and after, down to the attributes of
+// nested objects. Lists are compared as a whole. The marks have the shape of the values, see redact.
+func attributeDrifts(drifts *[]AttributeDrift, path string, before any, after any, beforeMarks any, afterMarks any) {
+	beforeMap, beforeOK := before.(map[string]any)
+	afterMap, afterOK := after.(map[string]any)
+
+	if beforeOK && afterOK {
+		keys := map[string]bool{}
+		for key := range beforeMap {
+			keys[key] = true
+		}
+
+		for key := range afterMap {
+			keys[key] = true
+		}
+
+		names := make([]string, 0, len(keys))
+		for key := range keys {
+			names = append(names, key)
+		}
+
+		sort.Strings(names)
+
+		for _, key := range names {
+			attributeDrifts(drifts, strings.TrimPrefix(path+"."+key, "."), beforeMap[key], afterMap[key],
+				markOf(beforeMarks, key), markOf(afterMarks, key))
+		}
+
+		return
+	}
+
+	// Sensitive values are compared before they are redacted, so their changes are reported too
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	*drifts = append(*drifts, AttributeDrift{
+		Attribute: path,
+		State:     redact(before, beforeMarks),
+		Actual:    redact(after, afterMarks),
+	})
+}
+
+// markOf returns the marks of the attribute of an object.
+func markOf(marks any, key string) any {
+	if marked, ok := marks.(bool); ok {
+		return marked
+	}
+
+	m, _ := marks.(map[string]any)
+
+	return m[key]
+}
@@ -0,0 +1,38 @@
+package state_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/state"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrifted(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "drift.json"))
+	require.NoError(t, err)
+
+	var plan tfjson.Plan
+	require.NoError(t, json.Unmarshal(b, &plan))
+
+	assert.Equal(t, []state.Drift{
+		{
+			Address: "aws_instance.web",
+			Type:    "aws_instance",
+			Action:  "update",
+			Attributes: []state.AttributeDrift{
+				{Attribute: "instance_type", State: "t2.micro", Actual: "t3.micro"},
+				{Attribute: "security_groups", State: []any{"a"}, Actual: []any{"a", "b"}},
+				{Attribute: "tags.Owner", State: nil, Actual: "ops"},
+				{Attribute: "user_data", State: state.Redacted, Actual: state.Redacted},
+			},
+		},
+		{Address: "aws_s3_bucket.old", Type: "aws_s3_bucket", Action: "delete"},
+	}, state.Drifted(&plan))
+
+	assert.Empty(t, state.Drifted(nil))
+}
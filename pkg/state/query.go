@@ -0,0 +1,337 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// QueryHelp describes queries to the model, which turns questions into them.
+const QueryHelp = `A query is a JSON object with these optional fields, resources match all the fields that are set:
+  "type": the resource type, such as "aws_instance"
+  "address": a glob matching the resource address, such as "module.network.*"
+  "where": conditions on attributes, objects with "attribute" (a dotted path such as "tags.Name" or "ebs_block_device.0.volume_size"),
+           "op" (one of eq, ne, contains, prefix, exists, lt, gt) and "value"
+  "depends_on": the address of a resource or module, matches the resources depending on it, directly or through others
+  "dependencies_of": the address of a resource, matches the resources it depends on, directly or through others
+  "attributes": the dotted paths of the attributes that answer the question, to show for every match
+For example, the instances of type t2.micro are {"type": "aws_instance", "where": [{"attribute": "instance_type", "op": "eq", "value": "t2.micro"}], "attributes": ["instance_type"]}`
+
+var errQuery = errors.New("invalid state query")
+
+// Query selects resources of the state.
+type Query struct {
+	Type           string      `json:"type,omitempty"`
+	Address        string      `json:"address,omitempty"`
+	Where          []Condition `json:"where,omitempty"`
+	DependsOn      string      `json:"depends_on,omitempty"`
+	DependenciesOf string      `json:"dependencies_of,omitempty"`
+	// Attributes are the attributes to show for the matching resources, all of them when empty.
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+// Condition is a condition on an attribute of a resource.
+type Condition struct {
+	// Attribute is the dotted path of the attribute, with the indexes of lists as numbers.
+	Attribute string `json:"attribute"`
+	// Op is one of eq, ne, contains, prefix, exists, lt and gt.
+	Op    string `json:"op"`
+	Value any    `json:"value,omitempty"`
+}
+
+// ParseQuery reads a query, which may be surrounded by other text such as code fences.
+func ParseQuery(s string) (Query, error) {
+	start, end := strings.Index(s, "{"), strings.LastIndex(s, "}")
+	if start < 0 || end < start {
+		return Query{}, errors.Wrapf(errQuery, "no JSON object in %q", s)
+	}
+
+	var q Query
+	if err := json.Unmarshal([]byte(s[start:end+1]), &q); err != nil {
+		return Query{}, errors.Wrap(errQuery, err.Error())
+	}
+
+	for _, c := range q.Where {
+		switch c.Op {
+		case "eq", "ne", "contains", "prefix", "exists", "lt", "gt":
+		default:
+			return Query{}, errors.Wrapf(errQuery, "unknown op %q of %s", c.Op, c.Attribute)
+		}
+	}
+
+	if q.Address != "" {
+		if _, err := path.Match(q.Address, ""); err != nil {
+			return Query{}, errors.Wrapf(errQuery, "address %q: %s", q.Address, err)
+		}
+	}
+
+	return q, nil
+}
+
+// String returns the query as JSON.
+func (q Query) String() string {
+	b, _ := json.Marshal(q)
+
+	return string(b)
+}
+
+// Run returns the resources matching the query, in the order given.
+func (q Query) Run(resources []Resource) []Resource {
+	var dependents, dependencies map[string]bool
+	if q.DependsOn != "" {
+		dependents = dependentsOf(resources, q.DependsOn)
+	}
+
+	if q.DependenciesOf != "" {
+		dependencies = dependenciesOf(resources, q.DependenciesOf)
+	}
+
+	var matches []Resource
+
+	for _, r := range resources {
+		if q.Type != "" && r.Type != q.Type {
+			continue
+		}
+
+		if q.Address != "" {
+			if ok, _ := path.Match(q.Address, r.Address); !ok {
+				continue
+			}
+		}
+
+		if dependents != nil && !dependents[r.Address] {
+			continue
+		}
+
+		if dependencies != nil && !dependencies[r.Address] {
+			continue
+		}
+
+		if !q.matches(r) {
+			continue
+		}
+
+		matches = append(matches, r)
+	}
+
+	return matches
+}
+
+// Select returns the attributes of the query for the resource, all of them when the query names none.
+func (q Query) Select(r Resource) map[string]any {
+	if len(q.Attributes) == 0 {
+		return r.Values
+	}
+
+	selected := make(map[string]any, len(q.Attributes))
+
+	for _, attribute := range q.Attributes {
+		if value, ok := Lookup(r.Values, attribute); ok {
+			selected[attribute] = value
+		}
+	}
+
+	return selected
+}
+
+// matches reports whether the resource meets every condition.
+func (q Query) matches(r Resource) bool {
+	for _, c := range q.Where {
+		value, ok := Lookup(r.Values, c.Attribute)
+		if !c.holds(value, ok) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// holds reports whether the condition holds for the value, which is missing when ok is false.
+func (c Condition) holds(value any, ok bool) bool {
+	if c.Op == "exists" {
+		return ok && value != nil
+	}
+
+	if !ok {
+		return c.Op == "ne"
+	}
+
+	switch c.Op {
+	case "eq":
+		return equal(value, c.Value)
+	case "ne":
+		return !equal(value, c.Value)
+	case "contains":
+		switch v := value.(type) {
+		case []any:
+			for _, item := range v {
+				if equal(item, c.Value) {
+					return true
+				}
+			}
+
+			return false
+		case map[string]any:
+			_, found := v[fmt.Sprint(c.Value)]
+
+			return found
+		default:
+			return strings.Contains(fmt.Sprint(value), fmt.Sprint(c.Value))
+		}
+	case "prefix":
+		return strings.HasPrefix(fmt.Sprint(value), fmt.Sprint(c.Value))
+	case "lt", "gt":
+		a, aOK := number(value)
+		b, bOK := number(c.Value)
+
+		if !aOK || !bOK {
+			return false
+		}
+
+		if c.Op == "lt" {
+			return a < b
+		}
+
+		return a > b
+	default:
+		return false
+	}
+}
+
+// Lookup returns the value at the dotted path, with the indexes of lists as numbers.
+func Lookup(values map[string]any, attribute string) (any, bool) {
+	var value any = values
+
+	for _, key := range strings.Split(attribute, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			item, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+
+			value = item
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// equal compares values of the state and the query, numbers by value and the rest as text.
+func equal(a any, b any) bool {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return x == y
+		}
+	}
+
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// number returns the value as a number, when it is one or text of one.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// dependentsOf returns the addresses of the resources depending on the target, directly or through others.
+func dependentsOf(resources []Resource, target string) map[string]bool {
+	targets := map[string]bool{ConfigAddress(target): true}
+	dependents := map[string]bool{}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, r := range resources {
+			if dependents[r.Address] {
+				continue
+			}
+
+			for _, dep := range r.DependsOn {
+				if refers(dep, targets) {
+					dependents[r.Address] = true
+					targets[ConfigAddress(r.Address)] = true
+					changed = true
+
+					break
+				}
+			}
+		}
+	}
+
+	return dependents
+}
+
+// dependenciesOf returns the addresses of the resources the target depends on, directly or through others.
+func dependenciesOf(resources []Resource, target string) map[string]bool {
+	byConfig := map[string][]Resource{}
+	for _, r := range resources {
+		byConfig[ConfigAddress(r.Address)] = append(byConfig[ConfigAddress(r.Address)], r)
+	}
+
+	dependencies := map[string]bool{}
+	queue := byConfig[ConfigAddress(target)]
+
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+
+		for _, dep := range r.DependsOn {
+			for config, instances := range byConfig {
+				if !refers(dep, map[string]bool{config: true}) && !strings.HasPrefix(config, dep+".") {
+					continue
+				}
+
+				for _, instance := range instances {
+					if !dependencies[instance.Address] {
+						dependencies[instance.Address] = true
+						queue = append(queue, instance)
+					}
+				}
+			}
+		}
+	}
+
+	return dependencies
+}
+
+// refers reports whether the dependency is one of the targets or, for a module target, in it.
+func refers(dep string, targets map[string]bool) bool {
+	dep = ConfigAddress(dep)
+	if targets[dep] {
+		return true
+	}
+
+	for target := range targets {
+		if strings.HasPrefix(dep, target+".") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,161 @@
+// Package state reads the resources of a Terraform state, with the sensitive values redacted,
+// answers queries over them without the model and reports the resources that drifted outside terraform.
+package state
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Redacted replaces the values terraform marks as sensitive.
+const Redacted = "(sensitive)"
+
+// Resource is a resource or data source instance in the state.
+type Resource struct {
+	// Address is the address of the instance, such as module.network.aws_subnet.private[0].
+	Address string `json:"address"`
+	// Module is the address of the module the resource is declared in, empty in the root module.
+	Module string `json:"module,omitempty"`
+	// Mode is managed for resources and data for data sources.
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Values are the attributes of the instance, the sensitive ones replaced with Redacted.
+	Values map[string]any `json:"values,omitempty"`
+	// DependsOn are the addresses of the resources and modules the resource depends on.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// Resources returns the resources of the root module and its child modules, ordered by address.
+func Resources(s *tfjson.State) []Resource {
+	if s == nil || s.Values == nil || s.Values.RootModule == nil {
+		return nil
+	}
+
+	var resources []Resource
+
+	modules := []*tfjson.StateModule{s.Values.RootModule}
+	for len(modules) > 0 {
+		module := modules[0]
+		modules = append(modules[1:], module.ChildModules...)
+
+		for _, r := range module.Resources {
+			resources = append(resources, Resource{
+				Address:   r.Address,
+				Module:    module.Address,
+				Mode:      string(r.Mode),
+				Type:      r.Type,
+				Name:      r.Name,
+				Values:    redactValues(r.AttributeValues, r.SensitiveValues),
+				DependsOn: r.DependsOn,
+			})
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Address < resources[j].Address
+	})
+
+	return resources
+}
+
+// Types returns the resource types in the state with the attributes their resources have, for the model
+// to write queries with.
+func Types(resources []Resource) map[string][]string {
+	seen := map[string]map[string]bool{}
+
+	for _, r := range resources {
+		if seen[r.Type] == nil {
+			seen[r.Type] = map[string]bool{}
+		}
+
+		for name := range r.Values {
+			seen[r.Type][name] = true
+		}
+	}
+
+	types := make(map[string][]string, len(seen))
+
+	for t, names := range seen {
+		types[t] = make([]string, 0, len(names))
+		for name := range names {
+			types[t] = append(types[t], name)
+		}
+
+		sort.Strings(types[t])
+	}
+
+	return types
+}
+
+// redactValues returns the values with the sensitive ones, marked true in sensitive, replaced.
+func redactValues(values map[string]any, sensitive json.RawMessage) map[string]any {
+	var marks any
+	if len(sensitive) > 0 {
+		// Unreadable marks redact nothing, the values terraform shows are already without secrets then
+		_ = json.Unmarshal(sensitive, &marks)
+	}
+
+	redacted, _ := redact(values, marks).(map[string]any)
+
+	return redacted
+}
+
+// redact replaces the parts of value marked true in marks, which has the same shape as the value.
+func redact(value any, marks any) any {
+	if marked, ok := marks.(bool); ok && marked {
+		return Redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		m, _ := marks.(map[string]any)
+		redacted := make(map[string]any, len(v))
+
+		for key, item := range v {
+			redacted[key] = redact(item, m[key])
+		}
+
+		return redacted
+	case []any:
+		l, _ := marks.([]any)
+		redacted := make([]any, len(v))
+
+		for i, item := range v {
+			var mark any
+			if i < len(l) {
+				mark = l[i]
+			}
+
+			redacted[i] = redact(item, mark)
+		}
+
+		return redacted
+	default:
+		return value
+	}
+}
+
+// ConfigAddress returns the address without the instance keys, as resources refer to each other in depends_on
+// and the configuration declares them.
+func ConfigAddress(address string) string {
+	var b strings.Builder
+
+	depth := 0
+
+	for _, c := range address {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
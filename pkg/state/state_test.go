@@ -0,0 +1,138 @@
+package state_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/state"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture returns the resources of the fixture state.
+func fixture(t *testing.T) []state.Resource {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", "state.json"))
+	require.NoError(t, err)
+
+	var s tfjson.State
+	require.NoError(t, json.Unmarshal(b, &s))
+
+	return state.Resources(&s)
+}
+
+// addresses returns the addresses of the resources.
+func addresses(resources []state.Resource) []string {
+	var addrs []string
+	for _, r := range resources {
+		addrs = append(addrs, r.Address)
+	}
+
+	return addrs
+}
+
+func TestResources(t *testing.T) {
+	resources := fixture(t)
+
+	assert.Equal(t, []string{
+		"aws_instance.bastion",
+		"aws_instance.web[0]",
+		"aws_instance.web[1]",
+		"aws_subnet.public",
+		"aws_vpc.main",
+		"module.db.aws_db_instance.this",
+	}, addresses(resources))
+
+	db := resources[5]
+	assert.Equal(t, "module.db", db.Module)
+	assert.Equal(t, state.Redacted, db.Values["password"])
+	assert.Equal(t, "admin", db.Values["username"])
+
+	assert.Empty(t, state.Resources(&tfjson.State{}))
+	assert.Equal(t, []string{"id", "instance_type", "root_block_device", "tags"}, state.Types(resources)["aws_instance"])
+}
+
+func TestQuery(t *testing.T) {
+	resources := fixture(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "type and attribute",
+			query: `{"type": "aws_instance", "where": [{"attribute": "instance_type", "op": "eq", "value": "t2.micro"}]}`,
+			want:  []string{"aws_instance.bastion", "aws_instance.web[0]"},
+		},
+		{
+			name:  "nested attribute",
+			query: `{"where": [{"attribute": "root_block_device.0.volume_size", "op": "gt", "value": 20}]}`,
+			want:  []string{"aws_instance.web[1]"},
+		},
+		{
+			name:  "tags",
+			query: `{"where": [{"attribute": "tags.Name", "op": "prefix", "value": "web"}]}`,
+			want:  []string{"aws_instance.web[0]", "aws_instance.web[1]"},
+		},
+		{
+			name:  "address glob",
+			query: `{"address": "module.db.*"}`,
+			want:  []string{"module.db.aws_db_instance.this"},
+		},
+		{
+			name:  "depends on, through others",
+			query: `{"depends_on": "aws_vpc.main"}`,
+			want:  []string{"aws_instance.web[0]", "aws_instance.web[1]", "aws_subnet.public", "module.db.aws_db_instance.this"},
+		},
+		{
+			name:  "dependencies of",
+			query: `{"dependencies_of": "aws_instance.web[1]"}`,
+			want:  []string{"aws_subnet.public", "aws_vpc.main"},
+		},
+		{
+			name:  "sensitive values do not match",
+			query: `{"where": [{"attribute": "password", "op": "eq", "value": "hunter22"}]}`,
+		},
+		{
+			name:  "in code fences",
+			query: "```json\n{\"type\": \"aws_vpc\"}\n```",
+			want:  []string{"aws_vpc.main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := state.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, addresses(q.Run(resources)))
+		})
+	}
+}
+
+func TestQuerySelect(t *testing.T) {
+	q, err := state.ParseQuery(`{"type": "aws_instance", "attributes": ["instance_type", "tags.Name", "missing"]}`)
+	require.NoError(t, err)
+
+	matches := q.Run(fixture(t))
+	require.Len(t, matches, 3)
+
+	assert.Equal(t, map[string]any{"instance_type": "t2.micro", "tags.Name": "bastion"}, q.Select(matches[0]))
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"the instances",
+		`{"where": [{"attribute": "instance_type", "op": "like", "value": "t2"}]}`,
+		`{"address": "["}`,
+		`{"type": 1}`,
+	} {
+		_, err := state.ParseQuery(query)
+		assert.Error(t, err, query)
+	}
+}
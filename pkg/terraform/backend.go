@@ -0,0 +1,186 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var errSettings = errors.New("invalid terraform settings")
+
+// backends are the backends built into Terraform.
+var backends = map[string]bool{
+	"azurerm":    true,
+	"consul":     true,
+	"cos":        true,
+	"gcs":        true,
+	"http":       true,
+	"kubernetes": true,
+	"local":      true,
+	"oss":        true,
+	"pg":         true,
+	"remote":     true,
+	"s3":         true,
+}
+
+// providerSource matches required_providers sources such as hashicorp/aws or registry.example.com/acme/aws.
+var providerSource = regexp.MustCompile(`^([a-z0-9.-]+/)?[a-z0-9-]+/[a-z0-9-]+$`)
+
+// InitOptions are the options of terraform init.
+type InitOptions struct {
+	// BackendConfig are -backend-config values, key=value pairs or paths of backend config files.
+	BackendConfig []string
+	// MigrateState copies the existing state to a changed backend.
+	MigrateState bool
+	// Reconfigure ignores the existing backend configuration and state.
+	Reconfigure bool
+}
+
+// Backend returns the type of the backend the template declares, or "" when it declares none.
+func Backend(template string) string {
+	body, ok := parseSettings(template)
+	if !ok {
+		return ""
+	}
+
+	for _, block := range body.Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+
+		for _, nested := range block.Body.Blocks {
+			if nested.Type == "backend" && len(nested.Labels) == 1 {
+				return nested.Labels[0]
+			}
+		}
+	}
+
+	return ""
+}
+
+// ValidateSettings checks the terraform blocks of the template: there is at most one backend,
+// it is built into Terraform and its arguments are literals, and every required provider
+// has a source and is declared as an object.
+func ValidateSettings(template string) error {
+	if err := CheckTemplate(template); err != nil {
+		return err
+	}
+
+	body, ok := parseSettings(template)
+	if !ok {
+		return errors.Wrap(errSettings, "unexpected body")
+	}
+
+	var found []string
+
+	for _, block := range body.Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+
+		for _, nested := range block.Body.Blocks {
+			var err error
+
+			switch nested.Type {
+			case "backend":
+				found = append(found, strings.Join(nested.Labels, "."))
+				err = validateBackend(nested)
+			case "required_providers":
+				err = validateRequiredProviders(nested)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(found) > 1 {
+		return errors.Wrapf(errSettings, "only one backend can be configured, found %s", strings.Join(found, ", "))
+	}
+
+	return nil
+}
+
+// validateBackend checks the type and arguments of a backend block.
+func validateBackend(block *hclsyntax.Block) error {
+	if len(block.Labels) != 1 || !backends[block.Labels[0]] {
+		return errors.Wrapf(errSettings, "unknown backend %q", strings.Join(block.Labels, " "))
+	}
+
+	// Backends are configured before variables are evaluated, so only literals are allowed
+	for name, attr := range block.Body.Attributes {
+		if len(attr.Expr.Variables()) > 0 {
+			return errors.Wrapf(errSettings, "backend %q argument %s cannot use variables, pass it with -backend-config instead", block.Labels[0], name)
+		}
+	}
+
+	for _, nested := range block.Body.Blocks {
+		if err := validateBackend(&hclsyntax.Block{Labels: block.Labels, Body: nested.Body}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRequiredProviders checks that every required provider is an object with a valid source.
+func validateRequiredProviders(block *hclsyntax.Block) error {
+	for name, attr := range block.Body.Attributes {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || !value.Type().IsObjectType() {
+			return errors.Wrapf(errSettings, "required provider %s must be an object with source and version", name)
+		}
+
+		if !value.Type().HasAttribute("source") {
+			return errors.Wrapf(errSettings, "required provider %s has no source", name)
+		}
+
+		source := value.GetAttr("source")
+		if source.Type() != cty.String || source.IsNull() || !providerSource.MatchString(source.AsString()) {
+			return errors.Wrapf(errSettings, "required provider %s has an invalid source", name)
+		}
+
+		if value.Type().HasAttribute("version") && value.GetAttr("version").Type() != cty.String {
+			return errors.Wrapf(errSettings, "required provider %s version must be a string", name)
+		}
+	}
+
+	return nil
+}
+
+// Flags returns the terraform init flags for the options, as shown to the user.
+func (o InitOptions) Flags() []string {
+	var flags []string
+
+	for _, value := range o.BackendConfig {
+		flags = append(flags, fmt.Sprintf("-backend-config=%s", value))
+	}
+
+	if o.MigrateState {
+		flags = append(flags, "-migrate-state")
+	}
+
+	if o.Reconfigure {
+		flags = append(flags, "-reconfigure")
+	}
+
+	return flags
+}
+
+// parseSettings parses the template, reporting false when it is invalid.
+func parseSettings(template string) (*hclsyntax.Body, bool) {
+	file, diags := hclsyntax.ParseConfig([]byte(template), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+
+	return body, ok
+}
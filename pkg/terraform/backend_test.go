@@ -0,0 +1,65 @@
+package terraform_test
+
+import (
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+const s3Settings = `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+
+  backend "s3" {
+    bucket         = "acme-terraform-state"
+    key            = "prod/terraform.tfstate"
+    region         = "eu-west-1"
+    dynamodb_table = "terraform-locks"
+    encrypt        = true
+  }
+}
+
+provider "aws" {
+  region = "eu-west-1"
+}
+`
+
+func TestBackend(t *testing.T) {
+	assert.Equal(t, "s3", terraform.Backend(s3Settings))
+	assert.Equal(t, "", terraform.Backend(`provider "aws" {}`))
+	assert.Equal(t, "", terraform.Backend("terraform {"))
+}
+
+func TestValidateSettings(t *testing.T) {
+	assert.NoError(t, terraform.ValidateSettings(s3Settings))
+	assert.NoError(t, terraform.ValidateSettings(`provider "aws" {}`))
+	assert.NoError(t, terraform.ValidateSettings(`terraform {
+  backend "gcs" {}
+}`))
+
+	for name, template := range map[string]string{
+		"invalid":         "terraform {",
+		"unknown backend": "terraform {\n  backend \"dynamodb\" {}\n}",
+		"two backends":    "terraform {\n  backend \"s3\" {}\n}\nterraform {\n  backend \"local\" {}\n}",
+		"variable":        "terraform {\n  backend \"s3\" {\n    bucket = var.bucket\n  }\n}",
+		"nested variable": "terraform {\n  backend \"s3\" {\n    assume_role {\n      role_arn = local.role\n    }\n  }\n}",
+		"legacy provider": "terraform {\n  required_providers {\n    aws = \"~> 5.0\"\n  }\n}",
+		"no source":       "terraform {\n  required_providers {\n    aws = { version = \"~> 5.0\" }\n  }\n}",
+		"invalid source":  "terraform {\n  required_providers {\n    aws = { source = \"aws\" }\n  }\n}",
+		"version number":  "terraform {\n  required_providers {\n    aws = { source = \"hashicorp/aws\", version = 5 }\n  }\n}",
+	} {
+		assert.Error(t, terraform.ValidateSettings(template), name)
+	}
+}
+
+func TestInitOptionsFlags(t *testing.T) {
+	assert.Empty(t, terraform.InitOptions{}.Flags())
+	assert.Equal(t, []string{"-backend-config=bucket=state", "-migrate-state"},
+		terraform.InitOptions{BackendConfig: []string{"bucket=state"}, MigrateState: true}.Flags())
+	assert.Equal(t, []string{"-reconfigure"}, terraform.InitOptions{Reconfigure: true}.Flags())
+}
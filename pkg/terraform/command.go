@@ -0,0 +1,93 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// command runs terraform with the arguments in the working directory, writing its output to out.
+// Terraform runs in its own process group, so the interrupt of the terminal does not reach it.
+// Cancelling the context interrupts it once, for it to stop gracefully. Killing the context, see
+// WithKill, kills it with its providers, and so does an interrupt it has not stopped for after stopTimeout.
+func (ter *Terraform) command(ctx context.Context, out io.Writer, args ...string) error {
+	var stderr strings.Builder
+
+	cmd := exec.Command(ter.ExecDir, args...)
+	cmd.Dir = ter.WorkingDir
+	cmd.Env = commandEnv(os.Environ())
+	cmd.Stdout = out
+	cmd.Stderr = &stderr
+	newProcessGroup(cmd)
+
+	if ter.logger != nil {
+		ter.logger.Debug("running Terraform command: "+cmd.String(), "component", "terraform")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting terraform: %w", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+			return
+		}
+
+		_ = interruptProcess(cmd.Process)
+
+		timeout := time.NewTimer(stopTimeout)
+		defer timeout.Stop()
+
+		select {
+		case <-killed(ctx):
+		case <-timeout.C:
+		case <-done:
+			return
+		}
+
+		_ = killProcess(cmd.Process)
+	}()
+
+	err := cmd.Wait()
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, stderr.String())
+	}
+
+	return nil
+}
+
+// commandEnv returns the environment terraform runs with, like terraform-exec's: in automation mode,
+// without logging to stderr and in the selected workspace rather than the one of TF_WORKSPACE.
+func commandEnv(environ []string) []string {
+	env := make([]string, 0, len(environ)+1)
+
+	for _, variable := range environ {
+		name, _, _ := strings.Cut(variable, "=")
+
+		switch name {
+		case "TF_WORKSPACE", "TF_LOG", "TF_LOG_CORE", "TF_LOG_PATH", "TF_LOG_PROVIDER", "TF_IN_AUTOMATION":
+			continue
+		}
+
+		env = append(env, variable)
+	}
+
+	return append(env, "TF_IN_AUTOMATION=1")
+}
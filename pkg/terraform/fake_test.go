@@ -0,0 +1,54 @@
+package terraform_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTerraform writes a terraform script that reports the given version and records the arguments
+// of every other command in an args file next to it. The commands are case branches matched against
+// all the arguments, which fake the commands a test runs; $testdata is the testdata directory.
+// It returns the path of the script.
+func fakeTerraform(t *testing.T, version string, commands ...string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake terraform is a shell script")
+	}
+
+	testdata, err := filepath.Abs("testdata")
+	require.NoError(t, err)
+
+	script := `#!/bin/sh
+testdata='` + testdata + `'
+case "$1" in
+version)
+  echo '{"terraform_version":"` + version + `","platform":"linux_amd64","provider_selections":{},"terraform_outdated":false}'
+  exit 0
+  ;;
+esac
+echo "$@" >> "$(dirname "$0")/args"
+case "$*" in
+` + strings.Join(commands, "") + `esac
+`
+
+	path := filepath.Join(t.TempDir(), "terraform")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o700))
+
+	return path
+}
+
+// fakeArgs returns the arguments the fake terraform was called with, one command per line.
+func fakeArgs(t *testing.T, execPath string) []string {
+	t.Helper()
+
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(execPath), "args"))
+	require.NoError(t, err)
+
+	return strings.Split(strings.TrimSpace(string(args)), "\n")
+}
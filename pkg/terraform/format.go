@@ -0,0 +1,63 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Format returns the template formatted in the canonical terraform fmt style.
+// Leading blank lines are removed and the template ends with exactly one newline.
+// It runs in-process, so no terraform binary is needed.
+func Format(template string) string {
+	template = strings.TrimLeft(template, "\n\r")
+	if strings.TrimSpace(template) == "" {
+		return ""
+	}
+
+	formatted := string(hclwrite.Format([]byte(template)))
+
+	return strings.TrimRight(formatted, "\n\r\t ") + "\n"
+}
+
+// FormatDiff returns a unified diff between the template and its formatted version,
+// or an empty string when the template is already formatted.
+func FormatDiff(name string, template string) (string, error) {
+	return Diff(name, name+" (formatted)", template, Format(template))
+}
+
+// Diff returns a unified diff from the from text to the to text, or an empty string when they are equal.
+func Diff(fromName string, toName string, from string, to string) (string, error) {
+	if from == to {
+		return "", nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from),
+		B:        splitLines(to),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error diffing %s: %w", fromName, err)
+	}
+
+	return diff, nil
+}
+
+// splitLines splits text into lines that keep their newline, marking a missing final newline like diff does.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+
+	last := len(lines) - 1
+	if lines[last] == "" {
+		return lines[:last]
+	}
+
+	lines[last] += "\n\\ No newline at end of file\n"
+
+	return lines
+}
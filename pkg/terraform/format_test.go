@@ -0,0 +1,38 @@
+package terraform_test
+
+import (
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	input := "\n\nresource \"aws_instance\" \"web\" {\nami = \"ami-123\"\n    instance_type=\"t2.micro\"\n}\n\n\n"
+	expected := "resource \"aws_instance\" \"web\" {\n  ami           = \"ami-123\"\n  instance_type = \"t2.micro\"\n}\n"
+
+	assert.Equal(t, expected, terraform.Format(input))
+	assert.Equal(t, expected, terraform.Format(expected))
+	assert.Equal(t, expected, terraform.Format(expected[:len(expected)-1]))
+	assert.Equal(t, "", terraform.Format("\n \n"))
+}
+
+func TestFormatDiff(t *testing.T) {
+	diff, err := terraform.FormatDiff("main.tf", "provider \"aws\" {\n  region = \"us-east-2\"\n}\n")
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	diff, err = terraform.FormatDiff("main.tf", "provider \"aws\" {\nregion = \"us-east-2\"\n}")
+	require.NoError(t, err)
+	assert.Equal(t, `--- main.tf
++++ main.tf (formatted)
+@@ -1,3 +1,3 @@
+ provider "aws" {
+-region = "us-east-2"
+-}
+\ No newline at end of file
++  region = "us-east-2"
++}
+`, diff)
+}
@@ -0,0 +1,294 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+var (
+	// dotEdge matches an edge of terraform graph output, such as "aws_instance.web" -> "aws_subnet.a"
+	// or, before terraform 1.7, "[root] aws_instance.web (expand)" -> "[root] aws_subnet.a (expand)".
+	dotEdge = regexp.MustCompile(`"([^"]+)"\s*->\s*"([^"]+)"`)
+	// dotSuffix matches the kind of node older terraform versions add to addresses, such as " (expand)".
+	dotSuffix = regexp.MustCompile(`\s+\([a-z ]+\)$`)
+)
+
+// Impact is what a change of the configuration affects.
+type Impact struct {
+	// Added are the blocks the change adds.
+	Added []string
+	// Changed are the blocks the change edits or removes.
+	Changed []string
+	// Affected are the resources, data sources, modules and outputs that depend on the added and changed
+	// blocks, directly or through others, and may be updated or replaced with them.
+	Affected []string
+}
+
+// Graph is the dependency graph of a configuration: the resources, data sources, modules, outputs,
+// variables and locals, and which of them refer to which.
+type Graph struct {
+	// dependents are the blocks referring to a block, by the address of the block referred to.
+	dependents map[string]map[string]bool
+}
+
+// configNode is a block of the configuration, or a local value, with what it refers to.
+type configNode struct {
+	// source is the block without its formatting, to tell whether it changed.
+	source string
+	refs   []string
+}
+
+// NewGraph returns an empty dependency graph.
+func NewGraph() *Graph {
+	return &Graph{dependents: map[string]map[string]bool{}}
+}
+
+// BlastRadius returns the blocks the new files change in the old ones and what depends on them, in the
+// configuration of both and in the graph terraform prints, when there is one.
+func BlastRadius(oldFiles map[string][]byte, newFiles map[string][]byte, dot string) Impact {
+	oldNodes, newNodes := configNodes(oldFiles), configNodes(newFiles)
+
+	var added, changed []string
+
+	for address, node := range newNodes {
+		if old, ok := oldNodes[address]; !ok {
+			added = append(added, address)
+		} else if old.source != node.source {
+			changed = append(changed, address)
+		}
+	}
+
+	for address := range oldNodes {
+		if _, ok := newNodes[address]; !ok {
+			changed = append(changed, address)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(changed)
+
+	g := NewGraph()
+	g.addNodes(oldNodes)
+	g.addNodes(newNodes)
+	g.AddDOT(dot)
+
+	sources := append(append([]string{}, added...), changed...)
+
+	isChanged := map[string]bool{}
+	for _, address := range sources {
+		isChanged[address] = true
+	}
+
+	var affected []string
+
+	for _, address := range g.Downstream(sources) {
+		if !isChanged[address] && !strings.HasPrefix(address, "var.") && !strings.HasPrefix(address, "local.") {
+			affected = append(affected, address)
+		}
+	}
+
+	return Impact{Added: added, Changed: changed, Affected: affected}
+}
+
+// Graph returns the dependency graph terraform prints for the configuration of the working directory, in DOT.
+func (ter *Terraform) Graph(ctx context.Context) (string, error) {
+	dot, err := ter.Exec.Graph(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error running Graph: %w", err)
+	}
+
+	return dot, nil
+}
+
+// AddConfig adds the blocks of the files and their references to the graph. Files that fail to parse are skipped.
+func (g *Graph) AddConfig(files map[string][]byte) {
+	g.addNodes(configNodes(files))
+}
+
+// AddDOT adds the edges of terraform graph output to the graph. The blocks of child modules are
+// added as their module, and providers and the other nodes terraform adds are left out.
+func (g *Graph) AddDOT(dot string) {
+	for _, match := range dotEdge.FindAllStringSubmatch(dot, -1) {
+		from, to := dotAddress(match[1]), dotAddress(match[2])
+		if from != "" && to != "" && from != to {
+			g.addEdge(from, to)
+		}
+	}
+}
+
+// Downstream returns the addresses of everything depending on the addresses, directly or through others, in order.
+func (g *Graph) Downstream(addresses []string) []string {
+	seen := map[string]bool{}
+	queue := append([]string{}, addresses...)
+
+	for len(queue) > 0 {
+		address := queue[0]
+		queue = queue[1:]
+
+		for dependent := range g.dependents[address] {
+			if !seen[dependent] {
+				seen[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	downstream := make([]string, 0, len(seen))
+	for address := range seen {
+		downstream = append(downstream, address)
+	}
+
+	sort.Strings(downstream)
+
+	return downstream
+}
+
+// addNodes adds the references of the nodes that are to other nodes.
+func (g *Graph) addNodes(nodes map[string]configNode) {
+	for address, node := range nodes {
+		for _, ref := range node.refs {
+			if _, ok := nodes[ref]; ok && ref != address {
+				g.addEdge(address, ref)
+			}
+		}
+	}
+}
+
+// addEdge records that from depends on to.
+func (g *Graph) addEdge(from string, to string) {
+	if g.dependents[to] == nil {
+		g.dependents[to] = map[string]bool{}
+	}
+
+	g.dependents[to][from] = true
+}
+
+// configNodes returns the resources, data sources, modules, outputs, variables and locals of the files by address.
+func configNodes(files map[string][]byte) map[string]configNode {
+	nodes := map[string]configNode{}
+
+	for name, src := range files {
+		body, ok := parseBody(name, src)
+		if !ok {
+			continue
+		}
+
+		for _, block := range body.Blocks {
+			if block.Type == "locals" {
+				for _, attr := range block.Body.Attributes {
+					nodes["local."+attr.Name] = newConfigNode(src, attr.SrcRange, attr.Expr)
+				}
+
+				continue
+			}
+
+			address := blockAddress(block)
+
+			switch {
+			case address != "":
+			case block.Type == "output" && len(block.Labels) == 1:
+				address = "output." + block.Labels[0]
+			case block.Type == "variable" && len(block.Labels) == 1:
+				address = "var." + block.Labels[0]
+			default:
+				continue
+			}
+
+			nodes[address] = newConfigNode(src, block.Range(), block.Body)
+		}
+	}
+
+	return nodes
+}
+
+// newConfigNode returns the node of the source in the range, with the references of the syntax node.
+func newConfigNode(src []byte, r hcl.Range, node hclsyntax.Node) configNode {
+	refs := map[string]bool{}
+
+	_ = hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		if expr, ok := n.(*hclsyntax.ScopeTraversalExpr); ok {
+			if ref := referenceAddress(expr.Traversal); ref != "" {
+				refs[ref] = true
+			}
+		}
+
+		return nil
+	})
+
+	sorted := make([]string, 0, len(refs))
+	for ref := range refs {
+		sorted = append(sorted, ref)
+	}
+
+	sort.Strings(sorted)
+
+	return configNode{
+		source: strings.Join(strings.Fields(string(src[r.Start.Byte:r.End.Byte])), " "),
+		refs:   sorted,
+	}
+}
+
+// referenceAddress returns the address of the block a reference such as aws_subnet.a.id, var.region
+// or module.vpc.subnet_ids refers to, and nothing for count, each and the other built-in references.
+func referenceAddress(traversal hcl.Traversal) string {
+	var names []string
+
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			names = append(names, s.Name)
+		case hcl.TraverseAttr:
+			names = append(names, s.Name)
+		default:
+			// The names of an address come before any index
+			if len(names) < 3 {
+				names = append(names, "")
+			}
+		}
+
+		if len(names) == 3 {
+			break
+		}
+	}
+
+	if len(names) < 2 || names[1] == "" {
+		return ""
+	}
+
+	switch names[0] {
+	case "var", "local", "module":
+		return names[0] + "." + names[1]
+	case "data":
+		if len(names) < 3 || names[2] == "" {
+			return ""
+		}
+
+		return strings.Join(names[:3], ".")
+	case "count", "each", "self", "path", "terraform":
+		return ""
+	default:
+		return names[0] + "." + names[1]
+	}
+}
+
+// dotAddress returns the address of a node of terraform graph output, as the module for the blocks of
+// child modules, and nothing for providers and the other nodes terraform adds.
+func dotAddress(node string) string {
+	address := dotSuffix.ReplaceAllString(strings.TrimPrefix(node, "[root] "), "")
+	if strings.ContainsAny(address, `[] `) || strings.HasPrefix(address, "provider") || strings.HasPrefix(address, "meta.") || address == "root" {
+		return ""
+	}
+
+	parts := strings.Split(address, ".")
+	if parts[0] == "module" && len(parts) > 2 {
+		return "module." + parts[1]
+	}
+
+	return address
+}
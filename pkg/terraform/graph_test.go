@@ -0,0 +1,178 @@
+package terraform_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGraph prints a graph with the policy of the bucket.
+const fakeGraph = `graph*) printf 'digraph G {\n  "aws_s3_bucket_policy.logs" -> "aws_s3_bucket.logs";\n}\n' ;;
+`
+
+func TestBlastRadius(t *testing.T) {
+	old := map[string][]byte{
+		"main.tf": []byte(`resource "aws_vpc" "main" {
+  cidr_block = var.cidr
+}
+
+resource "aws_subnet" "public" {
+  count  = 2
+  vpc_id = aws_vpc.main.id
+}
+
+resource "aws_instance" "web" {
+  subnet_id = aws_subnet.public[0].id
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+locals {
+  subnet_ids = aws_subnet.public[*].id
+  tags       = { Name = "web" }
+}
+
+module "lb" {
+  source  = "./modules/lb"
+  subnets = local.subnet_ids
+}
+
+data "aws_subnets" "all" {
+  filter {
+    name   = "vpc-id"
+    values = [aws_vpc.main.id]
+  }
+}
+`),
+		"variables.tf": []byte(`variable "cidr" {
+  default = "10.0.0.0/16"
+}
+`),
+		"outputs.tf": []byte(`output "web_ip" {
+  value = aws_instance.web.private_ip
+}
+
+output "lb" {
+  value = module.lb.dns_name
+}
+`),
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		dot   string
+		want  terraform.Impact
+	}{
+		{
+			name:  "unchanged",
+			files: map[string]string{"variables.tf": "variable \"cidr\" {\n  default   =   \"10.0.0.0/16\"\n}\n"},
+		},
+		{
+			name:  "added",
+			files: map[string]string{"policy.tf": "resource \"aws_s3_bucket_policy\" \"logs\" {\n  bucket = aws_s3_bucket.logs.id\n}\n"},
+			want:  terraform.Impact{Added: []string{"aws_s3_bucket_policy.logs"}},
+		},
+		{
+			name:  "changed through references and locals",
+			files: map[string]string{"variables.tf": "variable \"cidr\" {\n  default = \"10.1.0.0/16\"\n}\n"},
+			want: terraform.Impact{
+				Changed:  []string{"var.cidr"},
+				Affected: []string{"aws_instance.web", "aws_subnet.public", "aws_vpc.main", "data.aws_subnets.all", "module.lb", "output.lb", "output.web_ip"},
+			},
+		},
+		{
+			name:  "reformatted",
+			files: map[string]string{"main.tf": string(old["main.tf"]) + "\n"},
+			dot: `digraph {
+	"[root] aws_s3_bucket_policy.logs (expand)" -> "[root] aws_s3_bucket.logs (expand)"
+	"[root] module.app.aws_instance.app (expand)" -> "[root] aws_s3_bucket_policy.logs (expand)"
+	"[root] aws_s3_bucket.logs (expand)" -> "[root] provider[\"registry.terraform.io/hashicorp/aws\"]"
+}`,
+		},
+		{
+			name: "removed",
+			files: map[string]string{"outputs.tf": `output "lb" {
+  value = module.lb.dns_name
+}
+`},
+			want: terraform.Impact{Changed: []string{"output.web_ip"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{}
+			for name, contents := range old {
+				files[name] = contents
+			}
+
+			for name, contents := range tt.files {
+				files[name] = []byte(contents)
+			}
+
+			assert.Equal(t, tt.want, terraform.BlastRadius(old, files, tt.dot))
+		})
+	}
+}
+
+func TestBlastRadiusGraph(t *testing.T) {
+	old := map[string][]byte{"main.tf": []byte("resource \"aws_s3_bucket\" \"logs\" {\n  bucket = \"logs\"\n}\n")}
+	updated := map[string][]byte{"main.tf": []byte("resource \"aws_s3_bucket\" \"logs\" {\n  bucket = \"app-logs\"\n}\n")}
+
+	// The policy and the module are only in terraform graph, like the blocks of child modules
+	dot := `digraph G {
+  rankdir = "RL";
+  "aws_s3_bucket_policy.logs" [label="aws_s3_bucket_policy.logs"];
+  "aws_s3_bucket_policy.logs" -> "aws_s3_bucket.logs";
+  "module.app.aws_instance.app" -> "aws_s3_bucket_policy.logs";
+  "aws_s3_bucket.logs" -> "provider[\"registry.terraform.io/hashicorp/aws\"]";
+}`
+
+	assert.Equal(t, terraform.Impact{
+		Changed:  []string{"aws_s3_bucket.logs"},
+		Affected: []string{"aws_s3_bucket_policy.logs", "module.app"},
+	}, terraform.BlastRadius(old, updated, dot))
+}
+
+func TestGraphDownstream(t *testing.T) {
+	g := terraform.NewGraph()
+	g.AddConfig(map[string][]byte{"main.tf": []byte(`resource "aws_iam_role" "app" {
+  name = "app"
+}
+
+resource "aws_iam_role_policy_attachment" "app" {
+  role = aws_iam_role.app.name
+}
+
+resource "aws_lambda_function" "app" {
+  role = aws_iam_role.app.arn
+  environment {
+    variables = {
+      for k, v in var.env : k => v
+    }
+  }
+  depends_on = [aws_iam_role_policy_attachment.app]
+}
+
+variable "env" {}
+`)})
+
+	assert.Equal(t, []string{"aws_iam_role_policy_attachment.app", "aws_lambda_function.app"}, g.Downstream([]string{"aws_iam_role.app"}))
+	assert.Equal(t, []string{"aws_lambda_function.app"}, g.Downstream([]string{"var.env"}))
+	assert.Empty(t, g.Downstream([]string{"aws_lambda_function.app"}))
+}
+
+func TestGraph(t *testing.T) {
+	ter, err := terraform.NewTerraform(t.TempDir(), fakeTerraform(t, "1.5.7", fakeGraph))
+	require.NoError(t, err)
+
+	dot, err := ter.Graph(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, dot, `"aws_s3_bucket_policy.logs" -> "aws_s3_bucket.logs"`)
+}
@@ -3,45 +3,153 @@ package terraform
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	"github.com/pkg/errors"
 )
 
-// Init initializes the Terraform instance.
-// It starts a spinner, runs the Init command, and stops the spinner.
+const (
+	// importsFile and generatedFile are written to the working directory while config is generated
+	importsFile   = "terraform-assistant-imports.tf"
+	generatedFile = "terraform-assistant-generated.tf"
+)
+
+// ErrBackendChanged is returned by Init when the backend changed and neither migrating nor reconfiguring was asked for.
+var ErrBackendChanged = errors.New("backend configuration changed, migrate the state or reconfigure")
+
+var (
+	// minGenerateVersion is the first Terraform version with import blocks and -generate-config-out.
+	minGenerateVersion = version.Must(version.NewVersion("1.5.0"))
+	// minJSONApplyVersion is the first Terraform version with terraform apply -json.
+	minJSONApplyVersion = version.Must(version.NewVersion("0.15.3"))
+)
+
+// Init initializes the Terraform instance with the given options.
+// The output of terraform init is streamed to the output set with SetOutput.
+// Migrating the state uses -force-copy, so Terraform does not ask before copying it.
 // Returns an error if there was an error running Init.
-func (ter *Terraform) Init() error {
-	spin := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
-	spin.Start()
+func (ter *Terraform) Init(ctx context.Context, opts InitOptions) error {
+	initOpts := make([]tfexec.InitOption, 0, len(opts.BackendConfig)+2)
+	for _, value := range opts.BackendConfig {
+		initOpts = append(initOpts, tfexec.BackendConfig(value))
+	}
+
+	if opts.MigrateState {
+		initOpts = append(initOpts, tfexec.ForceCopy(true))
+	}
 
-	err := ter.Exec.Init(context.Background())
+	if opts.Reconfigure {
+		initOpts = append(initOpts, tfexec.Reconfigure(true))
+	}
+
+	// Init checks the version of terraform, read it first so the check is cached instead of written to the output
+	if _, _, err := ter.Exec.Version(ctx, false); err != nil {
+		return fmt.Errorf("error reading terraform version: %w", err)
+	}
+
+	ter.Exec.SetStdout(ter.out)
+	defer ter.Exec.SetStdout(nil)
+
+	err := ter.Exec.Init(ctx, initOpts...)
 	if err != nil {
-		spin.Stop()
+		if strings.Contains(err.Error(), "Backend configuration changed") {
+			return errors.Wrap(ErrBackendChanged, err.Error())
+		}
 
 		return fmt.Errorf("error running Init: %w", err)
 	}
 
-	spin.Stop()
-
 	return nil
 }
 
-// Apply applies the Terraform configuration.
-// It starts a spinner to indicate that the apply process is running.
-// It then calls the Exec.Apply method to execute the apply command.
-func (ter *Terraform) Apply() error {
-	spin := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
-	spin.Start()
+// Apply applies the Terraform configuration, only the resources and modules of the targets and what they depend on when given.
+// The progress of every resource is shown on the output set with SetOutput, read from terraform apply -json,
+// or from the human readable output of Terraform before 0.15.3, which is passed through.
+// Cancelling the context interrupts terraform, which finishes the changes in progress, saves the state
+// and releases the lock, and killing it, see WithKill, kills terraform. An InterruptedError then tells
+// which resources were applied until then.
+func (ter *Terraform) Apply(ctx context.Context, targets ...string) error {
+	tfVersion, _, err := ter.Exec.Version(ctx, false)
+	if err != nil {
+		return fmt.Errorf("error reading terraform version: %w", err)
+	}
+
+	args := []string{"apply", "-no-color", "-auto-approve", "-input=false", "-lock=true", "-parallelism=10", "-refresh=true"}
+	for _, target := range targets {
+		args = append(args, "-target="+target)
+	}
+
+	if !tfVersion.LessThan(minJSONApplyVersion) {
+		args = append(args, "-json")
+	}
+
+	progress := newProgress(ter.out, ter.terminal)
+	progress.start()
+
+	err = ter.command(ctx, progress, args...)
+
+	progress.stop()
 
-	err := ter.Exec.Apply(context.Background())
 	if err != nil {
-		spin.Stop()
+		if ctx.Err() != nil {
+			return &InterruptedError{Summary: progress.summary(), Killed: isKilled(ctx), Err: err}
+		}
 
 		return fmt.Errorf("error running Apply: %w", err)
 	}
 
+	return nil
+}
+
+// GenerateConfig generates the resource config for the given import blocks.
+// It writes the import blocks to the working directory and runs terraform plan -generate-config-out,
+// removing both files afterwards. It returns ErrGenerateUnsupported for Terraform before 1.5.
+func (ter *Terraform) GenerateConfig(ctx context.Context, imports string) (string, error) {
+	tfVersion, _, err := ter.Exec.Version(ctx, false)
+	if err != nil {
+		return "", fmt.Errorf("error reading terraform version: %w", err)
+	}
+
+	if tfVersion.LessThan(minGenerateVersion) {
+		return "", errors.Wrapf(ErrGenerateUnsupported, "found %s", tfVersion)
+	}
+
+	importsPath := filepath.Join(ter.WorkingDir, importsFile)
+	generatedPath := filepath.Join(ter.WorkingDir, generatedFile)
+
+	// Terraform refuses to overwrite an existing config file
+	if _, err := os.Stat(generatedPath); err == nil {
+		return "", fmt.Errorf("error generating config: %s already exists", generatedPath)
+	}
+
+	if err := os.WriteFile(importsPath, []byte(imports), 0o600); err != nil {
+		return "", fmt.Errorf("error writing imports: %w", err)
+	}
+	defer os.Remove(importsPath)
+	defer os.Remove(generatedPath)
+
+	spin := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+	spin.Start()
+
+	// The plan fails when the generated config needs fixing, but the config is still written
+	_, planErr := ter.Exec.Plan(ctx, tfexec.GenerateConfigOut(generatedPath))
+
 	spin.Stop()
 
-	return nil
+	generated, err := os.ReadFile(generatedPath)
+	if err != nil {
+		if planErr != nil {
+			return "", fmt.Errorf("error running Plan: %w", planErr)
+		}
+
+		return "", fmt.Errorf("error reading generated config: %w", err)
+	}
+
+	return string(generated), nil
 }
@@ -0,0 +1,83 @@
+package terraform_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInit fails to init with a changed backend config and succeeds otherwise.
+const fakeInit = `init*-backend-config=changed*)
+  echo "Error: Backend configuration changed" >&2
+  exit 1
+  ;;
+init*) echo "Terraform has been successfully initialized!" ;;
+`
+
+// fakeApply prints the apply.jsonl progress, as JSON with -json and as the plain messages without.
+const fakeApply = `apply*-json*) cat "$testdata/apply.jsonl" ;;
+apply*) sed -n 's/.*"@message":"\([^"]*\)".*"type":"apply_.*/\1/p' "$testdata/apply.jsonl" ;;
+`
+
+func TestInit(t *testing.T) {
+	ctx := context.Background()
+	execPath := fakeTerraform(t, "1.5.7", fakeInit)
+
+	ter, err := terraform.NewTerraform(t.TempDir(), execPath)
+	require.NoError(t, err)
+
+	require.NoError(t, ter.Init(ctx, terraform.InitOptions{}))
+	require.NoError(t, ter.Init(ctx, terraform.InitOptions{
+		BackendConfig: []string{"bucket=state", "backend.hcl"},
+		MigrateState:  true,
+	}))
+	require.NoError(t, ter.Init(ctx, terraform.InitOptions{Reconfigure: true}))
+
+	args := fakeArgs(t, execPath)
+	require.Len(t, args, 3)
+	assert.NotContains(t, args[0], "-backend-config")
+	assert.NotContains(t, args[0], "-force-copy")
+	assert.NotContains(t, args[0], "-reconfigure")
+	assert.Contains(t, args[1], "-backend-config=bucket=state -backend-config=backend.hcl")
+	assert.Contains(t, args[1], "-force-copy")
+	assert.Contains(t, args[2], "-reconfigure")
+
+	err = ter.Init(ctx, terraform.InitOptions{BackendConfig: []string{"changed=true"}})
+	assert.ErrorIs(t, err, terraform.ErrBackendChanged)
+}
+
+func TestInitOutput(t *testing.T) {
+	ter, err := terraform.NewTerraform(t.TempDir(), fakeTerraform(t, "1.5.7", fakeInit))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+
+	ter.SetOutput(&out, false)
+
+	require.NoError(t, ter.Init(context.Background(), terraform.InitOptions{}))
+	assert.Equal(t, "Terraform has been successfully initialized!\n", out.String())
+}
+
+func TestApplyTargets(t *testing.T) {
+	execPath := fakeTerraform(t, "1.5.7", fakeApply, fakePlan)
+
+	ter, err := terraform.NewTerraform(t.TempDir(), execPath)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+
+	ter.SetOutput(&out, false)
+
+	require.NoError(t, ter.Apply(context.Background(), "aws_s3_bucket.logs", "module.cdn"))
+
+	args := fakeArgs(t, execPath)
+	assert.Contains(t, args[0], "-target=aws_s3_bucket.logs -target=module.cdn")
+
+	_, err = ter.Plan(context.Background(), nil, "aws_s3_bucket.logs")
+	require.NoError(t, err)
+	assert.Contains(t, fakeArgs(t, execPath)[1], "-target=aws_s3_bucket.logs")
+}
@@ -0,0 +1,219 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var (
+	errImport = errors.New("invalid import block")
+
+	// ErrGenerateUnsupported is returned when the terraform binary cannot generate config for import blocks.
+	ErrGenerateUnsupported = errors.New("terraform does not support generating config, 1.5.0 or later is required")
+)
+
+var (
+	resourceType = regexp.MustCompile(`^[a-z][a-z0-9]*_[a-z0-9_]+$`)
+	invalidName  = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// Import is a Terraform 1.5+ import block: the resource address to import to and the cloud ID to import from.
+type Import struct {
+	To string
+	ID string
+}
+
+// IsResourceType reports whether s looks like a resource type such as aws_instance.
+func IsResourceType(s string) bool {
+	return resourceType.MatchString(s)
+}
+
+// ResourceName turns a cloud ID such as an ARN or bucket name into a valid resource name.
+func ResourceName(id string) string {
+	if i := strings.LastIndexAny(id, ":/"); i >= 0 && i < len(id)-1 {
+		id = id[i+1:]
+	}
+
+	name := strings.Trim(invalidName.ReplaceAllString(strings.ToLower(id), "_"), "_")
+
+	switch {
+	case name == "":
+		return "imported"
+	case name[0] >= '0' && name[0] <= '9':
+		return "r_" + name
+	default:
+		return name
+	}
+}
+
+// ImportBlocks renders the imports as Terraform import blocks.
+func ImportBlocks(imports []Import) string {
+	file := hclwrite.NewEmptyFile()
+
+	for i, imp := range imports {
+		if i > 0 {
+			file.Body().AppendNewline()
+		}
+
+		body := file.Body().AppendNewBlock("import", nil).Body()
+		body.SetAttributeTraversal("to", addressTraversal(imp.To))
+		body.SetAttributeValue("id", cty.StringVal(imp.ID))
+	}
+
+	return Format(string(file.Bytes()))
+}
+
+// ParseImports returns the import blocks of src. Every block needs a resource address in to and a string id.
+func ParseImports(src string) ([]Import, error) {
+	file, diags := hclsyntax.ParseConfig([]byte(src), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, errors.Wrapf(errImport, "parsing imports: %s", diags.Error())
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.Wrap(errImport, "unexpected body")
+	}
+
+	var imports []Import
+
+	for _, block := range body.Blocks {
+		if block.Type != "import" {
+			continue
+		}
+
+		imp, err := parseImport(block)
+		if err != nil {
+			return nil, err
+		}
+
+		imports = append(imports, imp)
+	}
+
+	if len(imports) == 0 {
+		return nil, errors.Wrap(errImport, "no import blocks found")
+	}
+
+	return imports, nil
+}
+
+// parseImport reads the to and id attributes of an import block.
+func parseImport(block *hclsyntax.Block) (Import, error) {
+	to, ok := block.Body.Attributes["to"]
+	if !ok {
+		return Import{}, errors.Wrap(errImport, "missing to")
+	}
+
+	traversal, diags := hcl.AbsTraversalForExpr(to.Expr)
+	if diags.HasErrors() {
+		return Import{}, errors.Wrapf(errImport, "to is not a resource address: %s", diags.Error())
+	}
+
+	address := traversalAddress(traversal)
+
+	parts := strings.Split(address, ".")
+	if len(parts) < 2 || !IsResourceType(parts[len(parts)-2]) {
+		return Import{}, errors.Wrapf(errImport, "to is not a resource address: %s", address)
+	}
+
+	id, ok := block.Body.Attributes["id"]
+	if !ok {
+		return Import{}, errors.Wrapf(errImport, "missing id for %s", address)
+	}
+
+	value, diags := id.Expr.Value(nil)
+	if diags.HasErrors() || value.Type() != cty.String || value.IsNull() {
+		return Import{}, errors.Wrapf(errImport, "id of %s must be a string", address)
+	}
+
+	return Import{To: address, ID: value.AsString()}, nil
+}
+
+// traversalAddress renders a traversal such as module.app.aws_instance.web as an address.
+func traversalAddress(traversal hcl.Traversal) string {
+	parts := make([]string, 0, len(traversal))
+
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			parts = append(parts, s.Name)
+		case hcl.TraverseAttr:
+			parts = append(parts, s.Name)
+		case hcl.TraverseIndex:
+			parts[len(parts)-1] += fmt.Sprintf("[%s]", indexKey(s.Key))
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// indexKey renders an index key the way Terraform addresses do.
+func indexKey(key cty.Value) string {
+	if key.Type() == cty.String {
+		return fmt.Sprintf("%q", key.AsString())
+	}
+
+	return key.AsBigFloat().String()
+}
+
+// addressTraversal turns a resource address back into a traversal.
+func addressTraversal(address string) hcl.Traversal {
+	traversal, diags := hclsyntax.ParseTraversalAbs([]byte(address), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return hcl.Traversal{hcl.TraverseRoot{Name: address}}
+	}
+
+	return traversal
+}
+
+// CleanGeneratedConfig removes the noise from config generated by terraform plan -generate-config-out:
+// the generated comments, attributes set to null or to empty collections and empty nested blocks.
+func CleanGeneratedConfig(src string) (string, error) {
+	lines := strings.Split(src, "\n")
+	kept := lines[:0]
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "# __generated__") || strings.HasPrefix(trimmed, "# Please review") {
+			continue
+		}
+
+		kept = append(kept, line)
+	}
+
+	file, diags := hclwrite.ParseConfig([]byte(strings.Join(kept, "\n")), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return "", errors.Wrapf(errTemplate, "generated config: %s", diags.Error())
+	}
+
+	for _, block := range file.Body().Blocks() {
+		cleanBody(block.Body())
+	}
+
+	return Format(string(file.Bytes())), nil
+}
+
+// cleanBody removes null and empty attributes and empty nested blocks from body.
+func cleanBody(body *hclwrite.Body) {
+	for name, attr := range body.Attributes() {
+		switch strings.Join(strings.Fields(string(attr.Expr().BuildTokens(nil).Bytes())), "") {
+		case "null", "[]", "{}":
+			body.RemoveAttribute(name)
+		}
+	}
+
+	for _, block := range body.Blocks() {
+		cleanBody(block.Body())
+
+		if len(block.Body().Attributes()) == 0 && len(block.Body().Blocks()) == 0 {
+			body.RemoveBlock(block)
+		}
+	}
+}
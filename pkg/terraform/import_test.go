@@ -0,0 +1,127 @@
+package terraform_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGenerateConfig writes the generated.tf fixture as the config it is asked to generate.
+const fakeGenerateConfig = `plan*-generate-config-out=*)
+  for arg in "$@"; do
+    case "$arg" in
+    -generate-config-out=*) cp "$testdata/generated.tf" "${arg#-generate-config-out=}" ;;
+    esac
+  done
+  ;;
+`
+
+const cleanedConfig = `resource "aws_s3_bucket" "logs_prod" {
+  bucket              = "logs-prod"
+  force_destroy       = false
+  object_lock_enabled = false
+  tags_all = {
+    Team = "platform"
+  }
+}
+`
+
+func TestResourceName(t *testing.T) {
+	assert.Equal(t, "logs_prod", terraform.ResourceName("logs-prod"))
+	assert.Equal(t, "i_0abc123", terraform.ResourceName("i-0abc123"))
+	assert.Equal(t, "deploy", terraform.ResourceName("arn:aws:iam::123456789012:role/Deploy"))
+	assert.Equal(t, "r_10_0_0_0_16", terraform.ResourceName("10.0.0.0/16/"))
+	assert.Equal(t, "imported", terraform.ResourceName("--"))
+}
+
+func TestIsResourceType(t *testing.T) {
+	assert.True(t, terraform.IsResourceType("aws_instance"))
+	assert.True(t, terraform.IsResourceType("google_compute_instance"))
+	assert.False(t, terraform.IsResourceType("instance"))
+	assert.False(t, terraform.IsResourceType("import the logs bucket"))
+}
+
+func TestImportBlocks(t *testing.T) {
+	imports := []terraform.Import{
+		{To: "aws_instance.web", ID: "i-0abc123"},
+		{To: `aws_s3_bucket.logs["prod"]`, ID: "logs-prod"},
+	}
+
+	src := terraform.ImportBlocks(imports)
+	assert.Equal(t, `import {
+  to = aws_instance.web
+  id = "i-0abc123"
+}
+
+import {
+  to = aws_s3_bucket.logs["prod"]
+  id = "logs-prod"
+}
+`, src)
+
+	parsed, err := terraform.ParseImports(src)
+	require.NoError(t, err)
+	assert.Equal(t, imports, parsed)
+}
+
+func TestParseImports(t *testing.T) {
+	parsed, err := terraform.ParseImports("import {\n  to = module.app.aws_instance.web\n  id = \"i-1\"\n}\nresource \"aws_instance\" \"web\" {}\n")
+	require.NoError(t, err)
+	assert.Equal(t, []terraform.Import{{To: "module.app.aws_instance.web", ID: "i-1"}}, parsed)
+
+	for _, src := range []string{
+		"",
+		"resource \"aws_instance\" \"web\" {}\n",
+		"import {\n  id = \"i-1\"\n}\n",
+		"import {\n  to = web\n  id = \"i-1\"\n}\n",
+		"import {\n  to = aws_instance.web\n}\n",
+		"import {\n  to = aws_instance.web\n  id = 1\n}\n",
+		"import {\n",
+	} {
+		_, err := terraform.ParseImports(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestCleanGeneratedConfig(t *testing.T) {
+	generated, err := os.ReadFile(filepath.Join("testdata", "generated.tf"))
+	require.NoError(t, err)
+
+	cleaned, err := terraform.CleanGeneratedConfig(string(generated))
+	require.NoError(t, err)
+	assert.Equal(t, cleanedConfig, cleaned)
+
+	_, err = terraform.CleanGeneratedConfig("resource {")
+	assert.Error(t, err)
+}
+
+func TestGenerateConfig(t *testing.T) {
+	ctx := context.Background()
+	workingDir := t.TempDir()
+
+	ter, err := terraform.NewTerraform(workingDir, fakeTerraform(t, "1.5.7", fakeGenerateConfig))
+	require.NoError(t, err)
+
+	generated, err := ter.GenerateConfig(ctx, "import {\n  to = aws_s3_bucket.logs_prod\n  id = \"logs-prod\"\n}\n")
+	require.NoError(t, err)
+	assert.Contains(t, generated, `resource "aws_s3_bucket" "logs_prod"`)
+
+	// The imports and generated config are not left behind
+	entries, err := os.ReadDir(workingDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestGenerateConfigUnsupported(t *testing.T) {
+	ctx := context.Background()
+	ter, err := terraform.NewTerraform(t.TempDir(), fakeTerraform(t, "1.4.6"))
+	require.NoError(t, err)
+
+	_, err = ter.GenerateConfig(ctx, "")
+	assert.ErrorIs(t, err, terraform.ErrGenerateUnsupported)
+}
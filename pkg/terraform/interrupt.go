@@ -0,0 +1,85 @@
+package terraform
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+)
+
+const (
+	// stopTimeout is how long an interrupted apply may take to finish the changes in progress before it is killed.
+	stopTimeout = 10 * time.Minute
+	// commandStopTimeout is how long the other commands may take to stop, they hold the state lock briefly if at all.
+	commandStopTimeout = 10 * time.Second
+)
+
+// killKey is the context key of the channel closed when the commands of the context are to be killed.
+type killKey struct{}
+
+// ApplySummary is what an apply did to the resources before it stopped.
+type ApplySummary struct {
+	// Applied are the addresses of the resources whose changes completed.
+	Applied []string
+	// Unfinished are the addresses of the resources whose changes started but did not complete.
+	Unfinished []string
+	// Pending are the addresses of the resources whose planned changes did not start.
+	Pending []string
+}
+
+// InterruptedError is returned by Apply when the context was cancelled before terraform finished.
+// It unwraps to the error of the cancelled command, which is context.Canceled.
+type InterruptedError struct {
+	// Summary is what was applied before terraform stopped.
+	Summary ApplySummary
+	// Killed is set when terraform was killed rather than stopped, it may have left the state locked.
+	Killed bool
+	// Err is the error of the interrupted command.
+	Err error
+}
+
+func (e *InterruptedError) Error() string {
+	return "apply interrupted: " + e.Err.Error()
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// WithKill returns a context whose terraform commands are killed when kill is called. Cancelling
+// the context only interrupts them, for terraform to stop gracefully and release the state lock.
+func WithKill(ctx context.Context) (context.Context, func()) {
+	ch := make(chan struct{})
+
+	var once sync.Once
+
+	return context.WithValue(ctx, killKey{}, ch), func() {
+		once.Do(func() { close(ch) })
+	}
+}
+
+// killed returns the channel closed when the commands of the context are to be killed, nil when they never are.
+func killed(ctx context.Context) <-chan struct{} {
+	ch, _ := ctx.Value(killKey{}).(chan struct{})
+
+	return ch
+}
+
+// isKilled returns whether the commands of the context were killed.
+func isKilled(ctx context.Context) bool {
+	select {
+	case <-killed(ctx):
+		return true
+	default:
+		return false
+	}
+}
+
+// stopGracefully makes cancelling a command run with terraform-exec interrupt terraform instead of killing it.
+// Only apply runs long while holding the state lock, and it runs with command, the others are killed after
+// commandStopTimeout.
+func stopGracefully(tf *tfexec.Terraform) {
+	// Windows has no interrupt to send, terraform is killed there
+	_ = tf.SetWaitDelay(commandStopTimeout)
+}
@@ -0,0 +1,85 @@
+package terraform_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingTerraform writes a terraform script whose apply plans three resources, creates one, starts another
+// and waits until it is interrupted, recording the interrupt in an interrupted file next to it.
+func hangingTerraform(t *testing.T) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake terraform is a shell script")
+	}
+
+	script := `#!/bin/sh
+dir="$(dirname "$0")"
+case "$1" in
+version)
+  echo '{"terraform_version":"1.5.7","platform":"linux_amd64","provider_selections":{},"terraform_outdated":false}'
+  ;;
+apply)
+  trap 'kill $sleeper; echo "Stopping operation..."; touch "$dir/interrupted"; exit 1' INT
+  for addr in aws_s3_bucket.logs aws_db_instance.main aws_route53_record.db; do
+    echo '{"type":"planned_change","change":{"resource":{"addr":"'$addr'"},"action":"create"}}'
+  done
+  echo '{"type":"apply_start","hook":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"create"}}'
+  echo '{"type":"apply_complete","hook":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"create","elapsed_seconds":1}}'
+  echo '{"type":"apply_start","hook":{"resource":{"addr":"aws_db_instance.main"},"action":"create"}}'
+  touch "$dir/started"
+  sleep 30 > /dev/null 2>&1 &
+  sleeper=$!
+  wait
+  ;;
+esac
+`
+
+	path := filepath.Join(t.TempDir(), "terraform")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o700))
+
+	return path
+}
+
+func TestApplyInterrupted(t *testing.T) {
+	execPath := hangingTerraform(t)
+
+	ter, err := terraform.NewTerraform(t.TempDir(), execPath)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		for {
+			if _, err := os.Stat(filepath.Join(filepath.Dir(execPath), "started")); err == nil {
+				cancel()
+
+				return
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	err = ter.Apply(ctx)
+
+	var interrupted *terraform.InterruptedError
+
+	require.True(t, errors.As(err, &interrupted), "got %v", err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"aws_s3_bucket.logs"}, interrupted.Summary.Applied)
+	assert.Equal(t, []string{"aws_db_instance.main"}, interrupted.Summary.Unfinished)
+	assert.Equal(t, []string{"aws_route53_record.db"}, interrupted.Summary.Pending)
+	assert.FileExists(t, filepath.Join(filepath.Dir(execPath), "interrupted"))
+}
@@ -0,0 +1,83 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Inventory returns the addresses of the resources, data sources and modules
+// declared in the .tf files of dir. Files that fail to parse are skipped.
+func Inventory(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing terraform files: %w", err)
+	}
+
+	var addresses []string
+
+	for _, name := range files {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", name, err)
+		}
+
+		file, diags := hclsyntax.ParseConfig(src, name, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			continue
+		}
+
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+
+		for _, block := range body.Blocks {
+			if address := blockAddress(block); address != "" {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+
+	return addresses, nil
+}
+
+// blockAddress returns the Terraform address of a resource, data or module block.
+func blockAddress(block *hclsyntax.Block) string {
+	switch {
+	case block.Type == "resource" && len(block.Labels) == 2:
+		return strings.Join(block.Labels, ".")
+	case block.Type == "data" && len(block.Labels) == 2:
+		return "data." + strings.Join(block.Labels, ".")
+	case block.Type == "module" && len(block.Labels) == 1:
+		return "module." + block.Labels[0]
+	default:
+		return ""
+	}
+}
+
+// Blocks returns the source of the resource, data and module blocks of the files by their address.
+// Files that fail to parse are skipped.
+func Blocks(files map[string][]byte) map[string]string {
+	blocks := map[string]string{}
+
+	for name, src := range files {
+		body, ok := parseBody(name, src)
+		if !ok {
+			continue
+		}
+
+		for _, block := range body.Blocks {
+			if address := blockAddress(block); address != "" {
+				r := block.Range()
+				blocks[address] = string(src[r.Start.Byte:r.End.Byte])
+			}
+		}
+	}
+
+	return blocks
+}
@@ -0,0 +1,279 @@
+package terraform
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// MovedFile is the file the moved blocks of renamed resources are added to.
+const MovedFile = "moved.tf"
+
+// Move is a resource or module whose address changed, which a moved block or terraform state mv records
+// so it is not destroyed and created again.
+type Move struct {
+	From string
+	To   string
+}
+
+// declaration is a resource or module block: its address, the resource type or "module", and its body
+// without the formatting.
+type declaration struct {
+	address string
+	kind    string
+	body    string
+}
+
+// Moves returns the resources and modules the new files rename in the old ones. A block that is gone
+// is paired with a new block of the same kind and the same body, or with the only new block of its kind
+// when it is the only one gone. The moves the new files already declare are left out.
+func Moves(oldFiles map[string][]byte, newFiles map[string][]byte) []Move {
+	return pairMoves(declarations(oldFiles), declarations(newFiles), declaredMoves(newFiles))
+}
+
+// StateMoves returns the resources and modules of the state the configuration renames. The addresses are
+// those of the state without instance keys, such as aws_instance.web and module.db. Without the bodies,
+// a resource of the state is only paired with the only new block of its type.
+func StateMoves(addresses []string, files map[string][]byte) []Move {
+	var decls []declaration
+
+	seen := map[string]bool{}
+
+	for _, address := range addresses {
+		if seen[address] {
+			continue
+		}
+
+		seen[address] = true
+
+		if kind := addressKind(address); kind != "" {
+			decls = append(decls, declaration{address: address, kind: kind})
+		}
+	}
+
+	return pairMoves(decls, declarations(files), declaredMoves(files))
+}
+
+// MovedBlocks renders the moves as Terraform moved blocks.
+func MovedBlocks(moves []Move) string {
+	file := hclwrite.NewEmptyFile()
+
+	for i, move := range moves {
+		if i > 0 {
+			file.Body().AppendNewline()
+		}
+
+		body := file.Body().AppendNewBlock("moved", nil).Body()
+		body.SetAttributeTraversal("from", addressTraversal(move.From))
+		body.SetAttributeTraversal("to", addressTraversal(move.To))
+	}
+
+	return Format(string(file.Bytes()))
+}
+
+// RemovedBlock renders a Terraform 1.7+ removed block that forgets the resource without destroying it,
+// which plans what terraform state rm does.
+func RemovedBlock(address string) string {
+	file := hclwrite.NewEmptyFile()
+
+	body := file.Body().AppendNewBlock("removed", nil).Body()
+	body.SetAttributeTraversal("from", addressTraversal(address))
+	body.AppendNewBlock("lifecycle", nil).Body().SetAttributeValue("destroy", cty.False)
+
+	return Format(string(file.Bytes()))
+}
+
+// pairMoves pairs the declarations that are gone with the new ones, skipping the moves already declared.
+func pairMoves(oldDecls []declaration, newDecls []declaration, declared map[string]string) []Move {
+	oldAddresses := map[string]bool{}
+	for _, decl := range oldDecls {
+		oldAddresses[decl.address] = true
+	}
+
+	newAddresses := map[string]bool{}
+	for _, decl := range newDecls {
+		newAddresses[decl.address] = true
+	}
+
+	movedTo := map[string]bool{}
+	for _, to := range declared {
+		movedTo[to] = true
+	}
+
+	var gone, added []declaration
+
+	for _, decl := range oldDecls {
+		if _, ok := declared[decl.address]; !ok && !newAddresses[decl.address] {
+			gone = append(gone, decl)
+		}
+	}
+
+	for _, decl := range newDecls {
+		if !movedTo[decl.address] && !oldAddresses[decl.address] {
+			added = append(added, decl)
+		}
+	}
+
+	var moves []Move
+
+	paired := map[string]bool{}
+
+	// Blocks that only changed their name keep their body
+	for _, from := range gone {
+		if from.body == "" {
+			continue
+		}
+
+		for _, to := range added {
+			if !paired[to.address] && to.kind == from.kind && to.body == from.body {
+				moves = append(moves, Move{From: from.address, To: to.address})
+				paired[from.address], paired[to.address] = true, true
+
+				break
+			}
+		}
+	}
+
+	// A block renamed and edited at once is only recognized when it is the only one of its kind
+	byKind := map[string][2][]declaration{}
+
+	for _, from := range gone {
+		if !paired[from.address] {
+			k := byKind[from.kind]
+			k[0] = append(k[0], from)
+			byKind[from.kind] = k
+		}
+	}
+
+	for _, to := range added {
+		if !paired[to.address] {
+			k := byKind[to.kind]
+			k[1] = append(k[1], to)
+			byKind[to.kind] = k
+		}
+	}
+
+	for _, k := range byKind {
+		if len(k[0]) == 1 && len(k[1]) == 1 {
+			moves = append(moves, Move{From: k[0][0].address, To: k[1][0].address})
+		}
+	}
+
+	sort.Slice(moves, func(i, j int) bool {
+		return moves[i].From < moves[j].From
+	})
+
+	return moves
+}
+
+// declarations returns the resource and module blocks of the files. Files that fail to parse are skipped.
+func declarations(files map[string][]byte) []declaration {
+	var decls []declaration
+
+	for _, name := range sortedFileNames(files) {
+		body, ok := parseBody(name, files[name])
+		if !ok {
+			continue
+		}
+
+		for _, block := range body.Blocks {
+			address := blockAddress(block)
+
+			kind := addressKind(address)
+			if kind == "" {
+				continue
+			}
+
+			src := files[name][block.Body.SrcRange.Start.Byte:block.Body.SrcRange.End.Byte]
+			decls = append(decls, declaration{address: address, kind: kind, body: strings.Join(strings.Fields(string(src)), " ")})
+		}
+	}
+
+	return decls
+}
+
+// declaredMoves returns the to address of every moved block of the files by its from address.
+func declaredMoves(files map[string][]byte) map[string]string {
+	moves := map[string]string{}
+
+	for name, src := range files {
+		body, ok := parseBody(name, src)
+		if !ok {
+			continue
+		}
+
+		for _, block := range body.Blocks {
+			if block.Type != "moved" {
+				continue
+			}
+
+			from, fromOK := block.Body.Attributes["from"]
+			to, toOK := block.Body.Attributes["to"]
+
+			if !fromOK || !toOK {
+				continue
+			}
+
+			fromTraversal, fromDiags := hcl.AbsTraversalForExpr(from.Expr)
+			toTraversal, toDiags := hcl.AbsTraversalForExpr(to.Expr)
+
+			if fromDiags.HasErrors() || toDiags.HasErrors() {
+				continue
+			}
+
+			moves[traversalAddress(fromTraversal)] = traversalAddress(toTraversal)
+		}
+	}
+
+	return moves
+}
+
+// addressKind returns the resource type of a managed resource address, or "module" for a module,
+// and nothing for data sources, which have nothing to move.
+func addressKind(address string) string {
+	parts := strings.Split(address, ".")
+
+	switch {
+	case len(parts) == 2 && parts[0] == "module":
+		return "module"
+	case len(parts) == 2 && parts[0] != "data":
+		return parts[0]
+	default:
+		return ""
+	}
+}
+
+// parseBody parses the file, reporting false when it is not valid HCL.
+func parseBody(name string, src []byte) (*hclsyntax.Body, bool) {
+	file, diags := hclsyntax.ParseConfig(src, name, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+
+	return body, ok
+}
+
+// sortedFileNames returns the names of the files in order, so blocks pair the same way every time.
+func sortedFileNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// IsAddress reports whether s is the address of a resource or module, such as aws_instance.web[0] or module.db.
+func IsAddress(s string) bool {
+	traversal, diags := hclsyntax.ParseTraversalAbs([]byte(s), "", hcl.Pos{Line: 1, Column: 1})
+
+	return !diags.HasErrors() && len(traversal) >= 2
+}
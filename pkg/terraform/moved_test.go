@@ -0,0 +1,166 @@
+package terraform_test
+
+import (
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoves(t *testing.T) {
+	old := map[string][]byte{
+		"main.tf": []byte(`resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = "assets"
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-123"
+}
+
+data "aws_region" "current" {}
+
+module "network" {
+  source = "./modules/network"
+}
+`),
+	}
+
+	tests := []struct {
+		name string
+		new  string
+		want []terraform.Move
+	}{
+		{
+			name: "unchanged",
+			new:  string(old["main.tf"]),
+		},
+		{
+			name: "renamed with the same body",
+			new: `resource "aws_s3_bucket" "audit_logs" {
+  bucket = "logs"
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket   = "assets"
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-123"
+}
+
+data "aws_region" "here" {}
+
+module "vpc" {
+  source = "./modules/network"
+}
+`,
+			want: []terraform.Move{
+				{From: "aws_s3_bucket.logs", To: "aws_s3_bucket.audit_logs"},
+				{From: "module.network", To: "module.vpc"},
+			},
+		},
+		{
+			name: "renamed and edited, the only one of its type",
+			new: `resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = "assets"
+}
+
+resource "aws_instance" "frontend" {
+  ami = "ami-456"
+}
+`,
+			want: []terraform.Move{{From: "aws_instance.web", To: "aws_instance.frontend"}},
+		},
+		{
+			name: "one of a type gone and one added",
+			new: `resource "aws_s3_bucket" "reports" {
+  bucket = "reports"
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = "assets"
+}
+`,
+			want: []terraform.Move{{From: "aws_s3_bucket.logs", To: "aws_s3_bucket.reports"}},
+		},
+		{
+			name: "already moved",
+			new: `resource "aws_instance" "frontend" {
+  ami = "ami-123"
+}
+
+moved {
+  from = aws_instance.web
+  to   = aws_instance.frontend
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, terraform.Moves(old, map[string][]byte{"main.tf": []byte(tt.new)}))
+		})
+	}
+}
+
+func TestStateMoves(t *testing.T) {
+	files := map[string][]byte{"main.tf": []byte(`resource "aws_instance" "web" {}
+resource "aws_instance" "frontend" {}
+resource "aws_subnet" "a" {}
+resource "aws_subnet" "b" {}
+module "database" {
+  source = "./modules/db"
+}
+`)}
+
+	moves := terraform.StateMoves([]string{"aws_instance.web", "aws_instance.api", "aws_subnet.old", "module.db", "module.db"}, files)
+
+	// The subnet could have become either of the new ones
+	assert.Equal(t, []terraform.Move{
+		{From: "aws_instance.api", To: "aws_instance.frontend"},
+		{From: "module.db", To: "module.database"},
+	}, moves)
+}
+
+func TestMovedBlocks(t *testing.T) {
+	assert.Equal(t, `moved {
+  from = aws_instance.web
+  to   = aws_instance.frontend
+}
+
+moved {
+  from = module.db
+  to   = module.database
+}
+`, terraform.MovedBlocks([]terraform.Move{
+		{From: "aws_instance.web", To: "aws_instance.frontend"},
+		{From: "module.db", To: "module.database"},
+	}))
+
+	assert.Equal(t, `removed {
+  from = aws_instance.legacy
+  lifecycle {
+    destroy = false
+  }
+}
+`, terraform.RemovedBlock("aws_instance.legacy"))
+}
+
+func TestIsAddress(t *testing.T) {
+	for _, address := range []string{"aws_instance.web", `aws_instance.web["a"]`, "module.db", "module.db[0].aws_db_instance.this"} {
+		assert.True(t, terraform.IsAddress(address), address)
+	}
+
+	for _, address := range []string{"", "web", "aws_instance web", "aws_instance.web;rm"} {
+		assert.False(t, terraform.IsAddress(address), address)
+	}
+}
@@ -1,6 +1,26 @@
 package terraform
 
+import (
+	"context"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Ops runs Terraform. Cancelling the context interrupts the running terraform command,
+// which stops gracefully and releases the state lock.
 type Ops interface {
-	Apply() error
-	Init() error
+	Apply(ctx context.Context, targets ...string) error
+	Init(ctx context.Context, opts InitOptions) error
+	GenerateConfig(ctx context.Context, imports string) (string, error)
+	Plan(ctx context.Context, files map[string][]byte, targets ...string) (string, error)
+	Graph(ctx context.Context) (string, error)
+	RefreshPlan(ctx context.Context) (*tfjson.Plan, error)
+	State(ctx context.Context) (*tfjson.State, error)
+	StateMove(ctx context.Context, from string, to string) (string, error)
+	StateRemove(ctx context.Context, address string) (string, error)
+	Workspaces(ctx context.Context) ([]string, string, error)
+	Workspace(ctx context.Context) (string, error)
+	SelectWorkspace(ctx context.Context, name string) error
+	NewWorkspace(ctx context.Context, name string) error
+	DeleteWorkspace(ctx context.Context, name string) error
 }
@@ -0,0 +1,148 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
+)
+
+// planFile is the plan written to the overlay directory.
+const planFile = "tfplan"
+
+var errOverlay = errors.New("invalid overlay file")
+
+// Plan runs terraform plan for the working directory with the given files added or replaced,
+// without changing the working directory, and returns the plan as terraform shows it. With targets,
+// only the resources and modules of the targets and what they depend on are planned.
+// The plan runs in a temporary directory that links to everything else in the working directory,
+// including the .terraform directory and local state, and does not lock the state.
+func (ter *Terraform) Plan(ctx context.Context, files map[string][]byte, targets ...string) (string, error) {
+	opts := make([]tfexec.PlanOption, 0, len(targets))
+	for _, target := range targets {
+		opts = append(opts, tfexec.Target(target))
+	}
+
+	tf, cleanup, err := ter.overlayPlan(ctx, files, opts...)
+	defer cleanup()
+
+	if err != nil {
+		return "", err
+	}
+
+	plan, err := tf.ShowPlanFileRaw(ctx, planFile)
+	if err != nil {
+		return "", fmt.Errorf("error running Show: %w", err)
+	}
+
+	return plan, nil
+}
+
+// RefreshPlan runs a refresh-only plan for the working directory, which compares the state with
+// the real infrastructure and changes neither, and returns it as terraform show -json reads it.
+func (ter *Terraform) RefreshPlan(ctx context.Context) (*tfjson.Plan, error) {
+	tf, cleanup, err := ter.overlayPlan(ctx, nil, tfexec.RefreshOnly(true))
+	defer cleanup()
+
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := tf.ShowPlanFile(ctx, planFile)
+	if err != nil {
+		return nil, fmt.Errorf("error running Show: %w", err)
+	}
+
+	return plan, nil
+}
+
+// overlayPlan plans the working directory with the files in a temporary overlay directory, see Plan,
+// and returns terraform for the overlay to show the plan file with. cleanup removes the overlay.
+func (ter *Terraform) overlayPlan(ctx context.Context, files map[string][]byte, opts ...tfexec.PlanOption) (*tfexec.Terraform, func(), error) {
+	dir, err := os.MkdirTemp("", "terraform-assistant-plan")
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("error creating plan directory: %w", err)
+	}
+
+	cleanup := func() { os.RemoveAll(dir) }
+
+	if err = overlay(ter.WorkingDir, dir, files); err != nil {
+		return nil, cleanup, err
+	}
+
+	tf, err := tfexec.NewTerraform(dir, ter.ExecDir)
+	if err != nil {
+		return nil, cleanup, fmt.Errorf("error new terraform: %w", err)
+	}
+
+	stopGracefully(tf)
+
+	if ter.logger != nil {
+		tf.SetLogger(printfer{logger: ter.logger})
+	}
+
+	// No spinner here, the plan may run behind the terminal UI
+	_, err = tf.Plan(ctx, append([]tfexec.PlanOption{tfexec.Out(planFile), tfexec.Lock(false)}, opts...)...)
+	if err != nil {
+		return nil, cleanup, fmt.Errorf("error running Plan: %w", err)
+	}
+
+	return tf, cleanup, nil
+}
+
+// State returns the state of the current workspace as terraform show -json reads it.
+func (ter *Terraform) State(ctx context.Context) (*tfjson.State, error) {
+	state, err := ter.Exec.Show(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error running Show: %w", err)
+	}
+
+	return state, nil
+}
+
+// overlay links every entry of workingDir into dir, except the files, which are written instead.
+func overlay(workingDir string, dir string, files map[string][]byte) error {
+	if workingDir == "" {
+		workingDir = "."
+	}
+
+	source, err := filepath.Abs(workingDir)
+	if err != nil {
+		return fmt.Errorf("error resolving working directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return fmt.Errorf("error reading working directory: %w", err)
+	}
+
+	for name := range files {
+		// Nested files would be written through the links into the working directory
+		if strings.ContainsAny(name, `/\`) {
+			return errors.Wrapf(errOverlay, "%s is not in the working directory itself", name)
+		}
+	}
+
+	for _, entry := range entries {
+		if _, ok := files[entry.Name()]; ok {
+			continue
+		}
+
+		if err := os.Symlink(filepath.Join(source, entry.Name()), filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("error linking %s: %w", entry.Name(), err)
+		}
+	}
+
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0o600); err != nil {
+			return fmt.Errorf("error writing %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
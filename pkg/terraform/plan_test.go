@@ -0,0 +1,88 @@
+package terraform_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePlan writes plans that list the working directory followed by main.tf, and refresh-only
+// plans that find the bucket deleted. It shows the plans, and a state with the bucket.
+const fakePlan = `plan*-refresh-only*)
+  for arg in "$@"; do
+    case "$arg" in
+    -out=*) echo '{"format_version":"1.2","resource_drift":[{"address":"aws_s3_bucket.logs","mode":"managed","type":"aws_s3_bucket","name":"logs","change":{"actions":["delete"]}}]}' > "${arg#-out=}" ;;
+    esac
+  done
+  ;;
+plan*)
+  for arg in "$@"; do
+    case "$arg" in
+    -out=*) ls > "${arg#-out=}"; cat main.tf >> "${arg#-out=}" ;;
+    esac
+  done
+  ;;
+show*)
+  for last in "$@"; do :; done
+  case "$last" in
+  -*) echo '{"format_version":"1.0","values":{"root_module":{"resources":[{"address":"aws_s3_bucket.logs","mode":"managed","type":"aws_s3_bucket","name":"logs","values":{"bucket":"logs"}}]}}}' ;;
+  *) cat "$last" ;;
+  esac
+  ;;
+`
+
+func TestPlan(t *testing.T) {
+	ctx := context.Background()
+	workingDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "main.tf"), []byte("# applied\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "variables.tf"), []byte("# variables\n"), 0o600))
+
+	ter, err := terraform.NewTerraform(workingDir, fakeTerraform(t, "1.5.7", fakePlan))
+	require.NoError(t, err)
+
+	plan, err := ter.Plan(ctx, map[string][]byte{"main.tf": []byte("# pending\n"), "outputs.tf": []byte("# outputs\n")})
+	require.NoError(t, err)
+	assert.Equal(t, "main.tf\noutputs.tf\ntfplan\nvariables.tf\n# pending\n", plan)
+
+	// The working directory is left as it was
+	applied, err := os.ReadFile(filepath.Join(workingDir, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# applied\n", string(applied))
+
+	entries, err := os.ReadDir(workingDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	_, err = ter.Plan(ctx, map[string][]byte{"modules/vpc/main.tf": nil})
+	assert.Error(t, err)
+}
+
+func TestRefreshPlan(t *testing.T) {
+	execPath := fakeTerraform(t, "1.5.7", fakePlan)
+
+	ter, err := terraform.NewTerraform(t.TempDir(), execPath)
+	require.NoError(t, err)
+
+	plan, err := ter.RefreshPlan(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, plan.ResourceDrift, 1)
+	assert.Equal(t, "aws_s3_bucket.logs", plan.ResourceDrift[0].Address)
+	assert.Contains(t, fakeArgs(t, execPath)[0], "-refresh-only")
+}
+
+func TestState(t *testing.T) {
+	ter, err := terraform.NewTerraform(t.TempDir(), fakeTerraform(t, "1.5.7", fakePlan))
+	require.NoError(t, err)
+
+	s, err := ter.State(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, s.Values.RootModule.Resources, 1)
+	assert.Equal(t, "aws_s3_bucket.logs", s.Values.RootModule.Resources[0].Address)
+}
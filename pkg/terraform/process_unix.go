@@ -0,0 +1,24 @@
+//go:build !windows
+
+package terraform
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// newProcessGroup starts the command in a process group of its own, which the interrupt of the terminal does not reach.
+func newProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interruptProcess sends the process an interrupt.
+func interruptProcess(p *os.Process) error {
+	return p.Signal(os.Interrupt)
+}
+
+// killProcess kills the process group of the process, the process and its children such as providers.
+func killProcess(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
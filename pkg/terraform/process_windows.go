@@ -0,0 +1,19 @@
+package terraform
+
+import (
+	"os"
+	"os/exec"
+)
+
+// newProcessGroup does nothing, Windows has no interrupt to keep from terraform.
+func newProcessGroup(_ *exec.Cmd) {}
+
+// interruptProcess kills the process, Windows has no interrupt to send.
+func interruptProcess(p *os.Process) error {
+	return p.Kill()
+}
+
+// killProcess kills the process.
+func killProcess(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,358 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redrawInterval is how often the elapsed times of the resources being changed are updated on a terminal.
+const redrawInterval = time.Second
+
+// applyProgress matches the lines terraform apply prints when it starts and completes the change of a resource.
+var applyProgress = regexp.MustCompile(`^(\S+): (?:(Creating|Modifying|Destroying|Importing|Reading)\.\.\.|(Creation|Modifications|Destruction|Import|Read) complete)`)
+
+// resourceStatus is how far the change of a resource got.
+type resourceStatus int
+
+const (
+	statusPending resourceStatus = iota
+	statusRunning
+	statusDone
+	statusFailed
+)
+
+// actionVerbs are the verbs of a planned, running and completed change for the actions of terraform's JSON output.
+var actionVerbs = map[string][3]string{
+	"create":  {"create", "creating", "created"},
+	"update":  {"modify", "modifying", "modified"},
+	"delete":  {"destroy", "destroying", "destroyed"},
+	"replace": {"replace", "replacing", "replaced"},
+	"read":    {"read", "reading", "read"},
+}
+
+// resourceProgress is the change of a resource during an apply.
+type resourceProgress struct {
+	addr    string
+	action  string
+	status  resourceStatus
+	start   time.Time
+	elapsed time.Duration
+}
+
+// uiMessage is a line of terraform's machine readable output, with the fields the progress uses.
+type uiMessage struct {
+	Type    string `json:"type"`
+	Message string `json:"@message"`
+	Hook    struct {
+		Resource struct {
+			Addr string `json:"addr"`
+		} `json:"resource"`
+		Action         string  `json:"action"`
+		ElapsedSeconds float64 `json:"elapsed_seconds"`
+	} `json:"hook"`
+	Change struct {
+		Resource struct {
+			Addr string `json:"addr"`
+		} `json:"resource"`
+		Action string `json:"action"`
+	} `json:"change"`
+	Diagnostic struct {
+		Detail string `json:"detail"`
+	} `json:"diagnostic"`
+}
+
+// progress follows the output of terraform apply, the machine readable lines of -json or the human readable ones.
+// On a terminal it prints every completed change with its elapsed time, below which the changes in progress
+// and how many are applied are redrawn in place. Otherwise it passes the messages of terraform through.
+type progress struct {
+	out      io.Writer
+	terminal bool
+
+	mu        sync.Mutex
+	partial   []byte
+	resources []*resourceProgress
+	byAddr    map[string]*resourceProgress
+	// drawn is the number of lines of the last drawing, which the next one replaces.
+	drawn int
+	done  chan struct{}
+	now   func() time.Time
+}
+
+// newProgress returns the progress of an apply written to out, nil to only follow it.
+func newProgress(out io.Writer, terminal bool) *progress {
+	if out == nil {
+		out, terminal = io.Discard, false
+	}
+
+	return &progress{
+		out:      out,
+		terminal: terminal,
+		byAddr:   map[string]*resourceProgress{},
+		now:      time.Now,
+	}
+}
+
+// start redraws the elapsed times every second on a terminal, until stop.
+func (p *progress) start() {
+	if !p.terminal {
+		return
+	}
+
+	done := make(chan struct{})
+	p.done = done
+
+	go func() {
+		ticker := time.NewTicker(redrawInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.mu.Lock()
+				p.draw()
+				p.mu.Unlock()
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// stop handles the rest of the output and stops redrawing, leaving the last drawing on the terminal.
+func (p *progress) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.partial) > 0 {
+		p.handle(string(p.partial))
+		p.partial = nil
+	}
+
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+
+	p.draw()
+}
+
+// Write handles the complete lines of the output.
+func (p *progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.partial = append(p.partial, b...)
+
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := string(p.partial[:i])
+		p.partial = p.partial[i+1:]
+
+		p.handle(line)
+	}
+
+	return len(b), nil
+}
+
+// handle follows a line of the output.
+func (p *progress) handle(line string) {
+	if p.terminal && strings.TrimSpace(line) == "" {
+		return
+	}
+
+	var msg uiMessage
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &msg) != nil {
+		p.handleText(line)
+
+		return
+	}
+
+	switch msg.Type {
+	case "version":
+		return
+	case "planned_change":
+		if msg.Change.Action != "noop" {
+			p.resource(msg.Change.Resource.Addr, msg.Change.Action)
+		}
+	case "apply_start":
+		r := p.resource(msg.Hook.Resource.Addr, msg.Hook.Action)
+		r.status, r.start = statusRunning, p.now()
+	case "apply_progress":
+		p.resource(msg.Hook.Resource.Addr, msg.Hook.Action).elapsed = seconds(msg.Hook.ElapsedSeconds)
+	case "apply_complete", "apply_errored":
+		r := p.resource(msg.Hook.Resource.Addr, msg.Hook.Action)
+		r.status, r.elapsed = statusDone, seconds(msg.Hook.ElapsedSeconds)
+
+		if msg.Type == "apply_errored" {
+			r.status = statusFailed
+		}
+
+		if p.terminal {
+			p.print(r.line(p.now()))
+
+			return
+		}
+	case "diagnostic", "change_summary":
+		// Errors and the totals are printed on a terminal too, with the details of errors
+		text := msg.Message
+		if msg.Diagnostic.Detail != "" {
+			text += "\n\n" + msg.Diagnostic.Detail
+		}
+
+		p.print(text)
+
+		return
+	}
+
+	if !p.terminal {
+		fmt.Fprintln(p.out, msg.Message)
+
+		return
+	}
+
+	p.draw()
+}
+
+// handleText follows a human readable line, the output of terraform before -json existed, which is passed through.
+func (p *progress) handleText(line string) {
+	p.clear()
+	p.terminal = false
+
+	if match := applyProgress.FindStringSubmatch(line); match != nil {
+		r := p.resource(match[1], "")
+		if match[3] != "" {
+			r.status = statusDone
+		} else {
+			r.status = statusRunning
+		}
+	}
+
+	fmt.Fprintln(p.out, line)
+}
+
+// resource returns the progress of the resource at the address, pending until its change starts.
+func (p *progress) resource(addr string, action string) *resourceProgress {
+	r, ok := p.byAddr[addr]
+	if !ok {
+		r = &resourceProgress{addr: addr}
+		p.byAddr[addr] = r
+		p.resources = append(p.resources, r)
+	}
+
+	if action != "" {
+		r.action = action
+	}
+
+	return r
+}
+
+// print writes the text, above the drawing on a terminal.
+func (p *progress) print(text string) {
+	if p.terminal {
+		p.clear()
+		fmt.Fprintln(p.out, text)
+		p.draw()
+
+		return
+	}
+
+	fmt.Fprintln(p.out, text)
+}
+
+// clear removes the last drawing from the terminal.
+func (p *progress) clear() {
+	if p.drawn > 0 {
+		fmt.Fprintf(p.out, "\x1b[%dA\x1b[J", p.drawn)
+		p.drawn = 0
+	}
+}
+
+// draw replaces the last drawing with the changes in progress and how many changes are applied.
+func (p *progress) draw() {
+	if !p.terminal || len(p.resources) == 0 {
+		return
+	}
+
+	var (
+		b       strings.Builder
+		applied int
+	)
+
+	for _, r := range p.resources {
+		switch r.status {
+		case statusRunning:
+			b.WriteString(r.line(p.now()))
+			b.WriteByte('\n')
+		case statusDone:
+			applied++
+		}
+	}
+
+	fmt.Fprintf(&b, "  %d of %d changes applied\n", applied, len(p.resources))
+
+	p.clear()
+	fmt.Fprint(p.out, b.String())
+	p.drawn = strings.Count(b.String(), "\n")
+}
+
+// line describes the change of the resource with its status and elapsed time.
+func (r *resourceProgress) line(now time.Time) string {
+	verbs, ok := actionVerbs[r.action]
+	if !ok {
+		verbs = [3]string{r.action, r.action, r.action}
+	}
+
+	elapsed := r.elapsed
+	if r.status == statusRunning && !r.start.IsZero() {
+		elapsed = max(elapsed, now.Sub(r.start))
+	}
+
+	elapsed = elapsed.Round(time.Second)
+
+	switch r.status {
+	case statusRunning:
+		return fmt.Sprintf("  … %s %s %s", r.addr, verbs[1], elapsed)
+	case statusDone:
+		return fmt.Sprintf("  ✔ %s %s after %s", r.addr, verbs[2], elapsed)
+	case statusFailed:
+		return fmt.Sprintf("  ✘ %s failed to %s after %s", r.addr, verbs[0], elapsed)
+	default:
+		return fmt.Sprintf("  · %s to %s", r.addr, verbs[0])
+	}
+}
+
+// summary returns which resources were applied and which were not.
+func (p *progress) summary() ApplySummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var summary ApplySummary
+
+	for _, r := range p.resources {
+		switch r.status {
+		case statusDone:
+			summary.Applied = append(summary.Applied, r.addr)
+		case statusRunning, statusFailed:
+			summary.Unfinished = append(summary.Unfinished, r.addr)
+		case statusPending:
+			summary.Pending = append(summary.Pending, r.addr)
+		}
+	}
+
+	return summary
+}
+
+// seconds returns the elapsed seconds of terraform's output as a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
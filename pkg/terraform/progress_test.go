@@ -0,0 +1,74 @@
+package terraform_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		terminal bool
+		want     []string
+	}{
+		{
+			name:    "passes the messages through",
+			version: "1.5.7",
+			want: []string{
+				"aws_s3_bucket.logs: Plan to create\n",
+				"aws_instance.web: Still modifying... [id=i-0abc123, 10s elapsed]\n",
+				"Apply complete! Resources: 1 added, 1 changed, 0 destroyed.\n",
+			},
+		},
+		{
+			name:     "redraws the resources on a terminal",
+			version:  "1.5.7",
+			terminal: true,
+			want: []string{
+				"  ✔ aws_s3_bucket.logs created after 2s\n",
+				"  ✔ aws_instance.web modified after 12s\n",
+				"Apply complete! Resources: 1 added, 1 changed, 0 destroyed.\n",
+				"  2 of 2 changes applied\n",
+				"\x1b[",
+			},
+		},
+		{
+			name:     "passes the output of terraform without json through",
+			version:  "0.14.11",
+			terminal: true,
+			want: []string{
+				"aws_s3_bucket.logs: Creating...\n",
+				"aws_instance.web: Modifications complete after 12s [id=i-0abc123]\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execPath := fakeTerraform(t, tt.version, fakeApply)
+
+			ter, err := terraform.NewTerraform(t.TempDir(), execPath)
+			require.NoError(t, err)
+
+			var out bytes.Buffer
+
+			ter.SetOutput(&out, tt.terminal)
+
+			require.NoError(t, ter.Apply(context.Background()))
+
+			for _, want := range tt.want {
+				assert.Contains(t, out.String(), want)
+			}
+
+			if !tt.terminal {
+				assert.NotContains(t, out.String(), "Terraform 1.5.7")
+			}
+		})
+	}
+}
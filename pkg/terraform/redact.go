@@ -0,0 +1,47 @@
+package terraform
+
+import (
+	"regexp"
+	"slices"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/state"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// secretAttribute matches the names of attributes that usually hold secrets, such as password,
+// master_password, secret_key, auth_token or private_key.
+var secretAttribute = regexp.MustCompile(`(?i)(password|passwd|secret|token|private_key|access_key|api_key|credential)`)
+
+// RedactBlock returns the source of a block with the literal values of its secret attributes replaced
+// by state.Redacted, in nested blocks too, and of the sensitive attributes given by name. Values that
+// refer to variables, resources or data sources are kept, they hold no secret themselves.
+// A block that fails to parse is redacted whole.
+func RedactBlock(src string, sensitive []string) string {
+	file, diags := hclwrite.ParseConfig([]byte(src), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return state.Redacted
+	}
+
+	redactBody(file.Body(), sensitive)
+
+	return string(file.Bytes())
+}
+
+// redactBody redacts the literal values of the secret and sensitive attributes of the body and its blocks.
+func redactBody(body *hclwrite.Body, sensitive []string) {
+	for name, attr := range body.Attributes() {
+		if !secretAttribute.MatchString(name) && !slices.Contains(sensitive, name) {
+			continue
+		}
+
+		if len(attr.Expr().Variables()) == 0 {
+			body.SetAttributeValue(name, cty.StringVal(state.Redacted))
+		}
+	}
+
+	for _, block := range body.Blocks() {
+		redactBody(block.Body(), sensitive)
+	}
+}
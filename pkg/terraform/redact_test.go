@@ -0,0 +1,38 @@
+package terraform_test
+
+import (
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactBlock(t *testing.T) {
+	block := `resource "aws_db_instance" "main" {
+  engine          = "postgres"
+  password        = "hunter2"
+  master_username = var.username
+  api_token       = var.token
+  user_data       = "#!/bin/sh"
+
+  credentials {
+    secret_key = "AKIA"
+    region     = "us-east-1"
+  }
+}`
+
+	assert.Equal(t, `resource "aws_db_instance" "main" {
+  engine          = "postgres"
+  password        = "(sensitive)"
+  master_username = var.username
+  api_token       = var.token
+  user_data       = "(sensitive)"
+
+  credentials {
+    secret_key = "(sensitive)"
+    region     = "us-east-1"
+  }
+}`, terraform.RedactBlock(block, []string{"user_data"}))
+
+	assert.Equal(t, "(sensitive)", terraform.RedactBlock(`resource "aws_db_instance" "main" {`, nil))
+}
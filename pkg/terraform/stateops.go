@@ -0,0 +1,88 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// backupFormat names the backups of the state like terraform names its own, which .gitignore files
+// for terraform already leave out.
+const backupFormat = "terraform.tfstate.%s.backup"
+
+// StateMove moves the resource or module at from to the address to in the state of the current workspace,
+// after writing a backup of the state to the working directory. It returns the path of the backup.
+func (ter *Terraform) StateMove(ctx context.Context, from string, to string) (string, error) {
+	backup, err := ter.backupState(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err = ter.Exec.StateMv(ctx, from, to); err != nil {
+		return backup, fmt.Errorf("error running StateMv: %w", err)
+	}
+
+	return backup, nil
+}
+
+// StateRemove makes terraform forget the resource or module at the address without destroying it,
+// after writing a backup of the state to the working directory. It returns the path of the backup.
+func (ter *Terraform) StateRemove(ctx context.Context, address string) (string, error) {
+	backup, err := ter.backupState(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err = ter.Exec.StateRm(ctx, address); err != nil {
+		return backup, fmt.Errorf("error running StateRm: %w", err)
+	}
+
+	return backup, nil
+}
+
+// backupState writes the state of the current workspace, wherever its backend keeps it, to a new file
+// in the working directory and returns its path.
+func (ter *Terraform) backupState(ctx context.Context) (string, error) {
+	state, err := ter.Exec.StatePull(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error running StatePull: %w", err)
+	}
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	for i := 0; ; i++ {
+		name := fmt.Sprintf(backupFormat, stamp)
+		if i > 0 {
+			name = fmt.Sprintf(backupFormat, fmt.Sprintf("%s-%d", stamp, i))
+		}
+
+		path := filepath.Join(ter.WorkingDir, name)
+
+		// Never overwrite an earlier backup
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("error creating state backup: %w", err)
+		}
+
+		if _, err = file.WriteString(state); err != nil {
+			file.Close()
+
+			return "", fmt.Errorf("error writing state backup: %w", err)
+		}
+
+		if err = file.Close(); err != nil {
+			return "", fmt.Errorf("error writing state backup: %w", err)
+		}
+
+		return path, nil
+	}
+}
@@ -0,0 +1,64 @@
+package terraform_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStateOps pulls the state as serial 7 and fails to remove a missing resource.
+const fakeStateOps = `"state pull"*) echo '{"version":4,"serial":7}' ;;
+"state rm"*missing*)
+  echo "Error: Invalid target address" >&2
+  exit 1
+  ;;
+`
+
+func TestStateMove(t *testing.T) {
+	ctx := context.Background()
+	execPath := fakeTerraform(t, "1.5.7", fakeStateOps)
+	workingDir := t.TempDir()
+
+	ter, err := terraform.NewTerraform(workingDir, execPath)
+	require.NoError(t, err)
+
+	first, err := ter.StateMove(ctx, "aws_instance.web", "aws_instance.frontend")
+	require.NoError(t, err)
+
+	second, err := ter.StateRemove(ctx, "aws_instance.legacy")
+	require.NoError(t, err)
+
+	// Every operation gets its own backup of the state it changed
+	assert.NotEqual(t, first, second)
+
+	for _, backup := range []string{first, second} {
+		assert.Equal(t, workingDir, filepath.Dir(backup))
+		assert.Regexp(t, `^terraform\.tfstate\.\d{8}T\d{6}Z(-\d+)?\.backup$`, filepath.Base(backup))
+
+		contents, err := os.ReadFile(backup)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"version": 4, "serial": 7}`, string(contents))
+	}
+
+	args := fakeArgs(t, execPath)
+	require.Len(t, args, 4)
+	assert.Equal(t, "state pull", args[0])
+	assert.Contains(t, args[1], "state mv")
+	assert.Contains(t, args[1], "aws_instance.web aws_instance.frontend")
+	assert.Contains(t, args[3], "aws_instance.legacy")
+}
+
+func TestStateRemoveFails(t *testing.T) {
+	ter, err := terraform.NewTerraform(t.TempDir(), fakeTerraform(t, "1.5.7", fakeStateOps))
+	require.NoError(t, err)
+
+	// The backup is kept when the operation fails, so it is reported
+	backup, err := ter.StateRemove(context.Background(), "aws_instance.missing")
+	require.ErrorContains(t, err, "Invalid target address")
+	assert.FileExists(t, backup)
+}
@@ -0,0 +1,84 @@
+package terraform
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxPlanAttributes is the number of attributes CompactPlan keeps for every resource.
+const maxPlanAttributes = 15
+
+var (
+	// planHeader matches the line terraform plan output starts the change of a resource with,
+	// such as "# aws_instance.web will be created".
+	planHeader = regexp.MustCompile(`^\s*# (\S+) (?:will|must) be (.+)$`)
+	// planAttribute matches an attribute the change sets, such as `+ bucket = "logs"`.
+	planAttribute = regexp.MustCompile(`^\s*(?:[-+~]|-/\+|\+/-)?\s*("?[\w.-]+"?) += (.*)$`)
+)
+
+// CompactPlan returns the resource changes of terraform plan output with the values they set, leaving out
+// the values only known after apply and everything but the totals, for the model to check the plan with.
+func CompactPlan(plan string) string {
+	var (
+		b          strings.Builder
+		attributes int
+		inResource bool
+	)
+
+	for _, line := range strings.Split(plan, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if match := planHeader.FindStringSubmatch(line); match != nil {
+			b.WriteString(match[1] + " will be " + match[2] + "\n")
+
+			attributes, inResource = 0, true
+
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "Plan:") || strings.HasPrefix(trimmed, "No changes.") || strings.HasPrefix(trimmed, "Changes to Outputs") {
+			b.WriteString(trimmed + "\n")
+
+			inResource = false
+
+			continue
+		}
+
+		if !inResource || strings.Contains(trimmed, "(known after apply)") {
+			continue
+		}
+
+		match := planAttribute.FindStringSubmatch(line)
+		if match == nil || strings.HasSuffix(match[2], "{") || strings.HasSuffix(match[2], "[") || strings.HasSuffix(match[2], "(") {
+			continue
+		}
+
+		if attributes++; attributes <= maxPlanAttributes {
+			b.WriteString("  " + match[1] + " = " + match[2] + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// OutsideTargets returns the addresses of the resource changes of terraform plan output that are not
+// in the targets: resources, with or without their instance keys, data sources and modules.
+func OutsideTargets(plan string, targets []string) []string {
+	var outside []string
+
+	for _, line := range strings.Split(plan, "\n") {
+		match := planHeader.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		if !slices.ContainsFunc(targets, func(target string) bool {
+			return match[1] == target || strings.HasPrefix(match[1], target+"[") || strings.HasPrefix(match[1], target+".")
+		}) {
+			outside = append(outside, match[1])
+		}
+	}
+
+	return outside
+}
@@ -0,0 +1,86 @@
+package terraform_test
+
+import (
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactPlan(t *testing.T) {
+	plan := `Terraform used the selected providers to generate the following execution plan.
+
+Terraform will perform the following actions:
+
+  # aws_iam_role.extra will be created
+  + resource "aws_iam_role" "extra" {
+      + arn                = (known after apply)
+      + name               = "extra"
+      + assume_role_policy = jsonencode(
+            {
+              + Version = "2012-10-17"
+            }
+        )
+    }
+
+  # aws_instance.web will be updated in-place
+  ~ resource "aws_instance" "web" {
+        id            = "i-123"
+      ~ instance_type = "t2.micro" -> "t3.micro"
+        # (30 unchanged attributes hidden)
+    }
+
+  # aws_db_instance.main must be replaced
+-/+ resource "aws_db_instance" "main" {
+      ~ engine_version = "13" -> "15" # forces replacement
+    }
+
+Plan: 1 to add, 1 to change, 1 to destroy.
+`
+
+	assert.Equal(t, `aws_iam_role.extra will be created
+  name = "extra"
+  Version = "2012-10-17"
+aws_instance.web will be updated in-place
+  id = "i-123"
+  instance_type = "t2.micro" -> "t3.micro"
+aws_db_instance.main will be replaced
+  engine_version = "13" -> "15" # forces replacement
+Plan: 1 to add, 1 to change, 1 to destroy.
+`, terraform.CompactPlan(plan))
+
+	assert.Equal(t, "No changes. Your infrastructure matches the configuration.\n",
+		terraform.CompactPlan("\nNo changes. Your infrastructure matches the configuration.\n"))
+}
+
+func TestOutsideTargets(t *testing.T) {
+	plan := `Terraform will perform the following actions:
+
+  # aws_s3_bucket.logs will be created
+  + resource "aws_s3_bucket" "logs" {
+      + bucket = "logs"
+    }
+
+  # aws_s3_bucket_versioning.logs["main"] will be created
+  + resource "aws_s3_bucket_versioning" "logs" {
+    }
+
+  # module.cdn.aws_cloudfront_distribution.this will be updated in-place
+  ~ resource "aws_cloudfront_distribution" "this" {
+    }
+
+  # aws_instance.web must be replaced
+-/+ resource "aws_instance" "web" {
+    }
+
+  # aws_s3_bucket.logs_archive will be destroyed
+  - resource "aws_s3_bucket" "logs_archive" {
+    }
+
+Plan: 3 to add, 1 to change, 2 to destroy.
+`
+
+	assert.Equal(t, []string{"aws_instance.web", "aws_s3_bucket.logs_archive"},
+		terraform.OutsideTargets(plan, []string{"aws_s3_bucket.logs", "aws_s3_bucket_versioning.logs", "module.cdn"}))
+	assert.Empty(t, terraform.OutsideTargets("No changes. Your infrastructure matches the configuration.\n", []string{"aws_s3_bucket.logs"}))
+}
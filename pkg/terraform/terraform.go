@@ -2,6 +2,8 @@ package terraform
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 
 	"github.com/hashicorp/terraform-exec/tfexec"
 )
@@ -10,6 +12,12 @@ type Terraform struct {
 	WorkingDir string
 	ExecDir    string
 	Exec       *tfexec.Terraform
+	// logger logs the terraform commands, see SetLogger.
+	logger *slog.Logger
+	// out receives the output of init and the progress of apply, see SetOutput.
+	out io.Writer
+	// terminal specifies whether out is a terminal the progress is redrawn on.
+	terminal bool
 }
 
 
@@ -22,6 +30,8 @@ func NewTerraform(workingDir string, execDir string) (*Terraform, error) {
 		return nil, fmt.Errorf("error new terraform: %w", err)
 	}
 
+	stopGracefully(tf)
+
 	// Create a new Terraform struct with the provided working directory, execution directory, and tfexec.Terraform instance.
 	return &Terraform{
 		WorkingDir: workingDir,
@@ -29,3 +39,10 @@ func NewTerraform(workingDir string, execDir string) (*Terraform, error) {
 		Exec:       tf,
 	}, nil
 }
+
+// SetOutput streams the output of init and the progress of apply to out. On a terminal, the progress
+// of every resource is redrawn in place, otherwise the output of terraform is passed through.
+func (ter *Terraform) SetOutput(out io.Writer, terminal bool) {
+	ter.out = out
+	ter.terminal = terminal
+}
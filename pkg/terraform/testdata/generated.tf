@@ -0,0 +1,18 @@
+# __generated__ by Terraform
+# Please review these resources and move them into your main configuration files.
+
+# __generated__ by Terraform from "logs-prod"
+resource "aws_s3_bucket" "logs_prod" {
+  bucket              = "logs-prod"
+  bucket_prefix       = null
+  force_destroy       = false
+  object_lock_enabled = false
+  tags                = {}
+  tags_all = {
+    Team = "platform"
+  }
+  timeouts {
+    create = null
+    delete = null
+  }
+}
@@ -0,0 +1,172 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/trace"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// SetLogger logs the terraform commands that are run at debug level, including the plans of Plan.
+func (ter *Terraform) SetLogger(logger *slog.Logger) {
+	ter.logger = logger
+	ter.Exec.SetLogger(printfer{logger: logger})
+}
+
+// printfer adapts a structured logger to the printf logger of tfexec.
+type printfer struct {
+	logger *slog.Logger
+}
+
+// Printf logs the message of tfexec at debug level.
+func (p printfer) Printf(format string, v ...any) {
+	p.logger.Debug(strings.TrimSpace(fmt.Sprintf(format, v...)), "component", "terraform")
+}
+
+// tracedOps records every operation with its duration and error.
+type tracedOps struct {
+	ops    Ops
+	tracer *trace.Tracer
+	logger *slog.Logger
+}
+
+// Traced returns ops that record every terraform invocation to the tracer and log it at debug level.
+func Traced(ops Ops, tracer *trace.Tracer, logger *slog.Logger) Ops {
+	return &tracedOps{ops: ops, tracer: tracer, logger: logger}
+}
+
+// record traces the operation that started at start.
+func (t *tracedOps) record(name string, start time.Time, request string, response string, err error) {
+	event := trace.Event{
+		Kind:       trace.KindTerraform,
+		Name:       name,
+		DurationMS: trace.Since(start),
+		Request:    request,
+		Response:   response,
+		Error:      trace.ErrorString(err),
+	}
+
+	t.logger.Debug("terraform "+name, "duration_ms", event.DurationMS, "error", event.Error)
+	t.tracer.Record(event)
+}
+
+func (t *tracedOps) Apply(ctx context.Context, targets ...string) error {
+	start := time.Now()
+	err := t.ops.Apply(ctx, targets...)
+	t.record("apply", start, strings.Join(targets, " "), "", err)
+
+	return err
+}
+
+func (t *tracedOps) Init(ctx context.Context, opts InitOptions) error {
+	start := time.Now()
+	err := t.ops.Init(ctx, opts)
+	t.record("init", start, strings.Join(opts.Flags(), " "), "", err)
+
+	return err
+}
+
+func (t *tracedOps) GenerateConfig(ctx context.Context, imports string) (string, error) {
+	start := time.Now()
+	config, err := t.ops.GenerateConfig(ctx, imports)
+	t.record("generate-config", start, imports, config, err)
+
+	return config, err
+}
+
+func (t *tracedOps) Plan(ctx context.Context, files map[string][]byte, targets ...string) (string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	start := time.Now()
+	plan, err := t.ops.Plan(ctx, files, targets...)
+	t.record("plan", start, strings.Join(append(names, targets...), " "), plan, err)
+
+	return plan, err
+}
+
+func (t *tracedOps) Graph(ctx context.Context) (string, error) {
+	start := time.Now()
+	dot, err := t.ops.Graph(ctx)
+	t.record("graph", start, "", "", err)
+
+	return dot, err
+}
+
+func (t *tracedOps) RefreshPlan(ctx context.Context) (*tfjson.Plan, error) {
+	start := time.Now()
+	plan, err := t.ops.RefreshPlan(ctx)
+	t.record("plan -refresh-only", start, "", "", err)
+
+	return plan, err
+}
+
+func (t *tracedOps) State(ctx context.Context) (*tfjson.State, error) {
+	start := time.Now()
+	state, err := t.ops.State(ctx)
+	t.record("show", start, "", "", err)
+
+	return state, err
+}
+
+func (t *tracedOps) StateMove(ctx context.Context, from string, to string) (string, error) {
+	start := time.Now()
+	backup, err := t.ops.StateMove(ctx, from, to)
+	t.record("state mv", start, from+" "+to, backup, err)
+
+	return backup, err
+}
+
+func (t *tracedOps) StateRemove(ctx context.Context, address string) (string, error) {
+	start := time.Now()
+	backup, err := t.ops.StateRemove(ctx, address)
+	t.record("state rm", start, address, backup, err)
+
+	return backup, err
+}
+
+func (t *tracedOps) Workspaces(ctx context.Context) ([]string, string, error) {
+	start := time.Now()
+	workspaces, current, err := t.ops.Workspaces(ctx)
+	t.record("workspace list", start, "", strings.Join(workspaces, " "), err)
+
+	return workspaces, current, err
+}
+
+func (t *tracedOps) Workspace(ctx context.Context) (string, error) {
+	start := time.Now()
+	current, err := t.ops.Workspace(ctx)
+	t.record("workspace show", start, "", current, err)
+
+	return current, err
+}
+
+func (t *tracedOps) SelectWorkspace(ctx context.Context, name string) error {
+	start := time.Now()
+	err := t.ops.SelectWorkspace(ctx, name)
+	t.record("workspace select", start, name, "", err)
+
+	return err
+}
+
+func (t *tracedOps) NewWorkspace(ctx context.Context, name string) error {
+	start := time.Now()
+	err := t.ops.NewWorkspace(ctx, name)
+	t.record("workspace new", start, name, "", err)
+
+	return err
+}
+
+func (t *tracedOps) DeleteWorkspace(ctx context.Context, name string) error {
+	start := time.Now()
+	err := t.ops.DeleteWorkspace(ctx, name)
+	t.record("workspace delete", start, name, "", err)
+
+	return err
+}
@@ -0,0 +1,61 @@
+package terraform_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/akhilsharma90/terraform-assistant/pkg/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraced(t *testing.T) {
+	ctx := context.Background()
+	execPath := fakeTerraform(t, "1.5.7", fakeInit, fakeWorkspaces)
+
+	ter, err := terraform.NewTerraform(t.TempDir(), execPath)
+	require.NoError(t, err)
+
+	var traced, logged bytes.Buffer
+
+	logger := slog.New(slog.NewTextHandler(&logged, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ter.SetLogger(logger)
+
+	ops := terraform.Traced(ter, trace.New(&traced), logger)
+
+	require.NoError(t, ops.Init(ctx, terraform.InitOptions{Reconfigure: true}))
+	assert.Error(t, ops.Init(ctx, terraform.InitOptions{BackendConfig: []string{"changed"}}))
+
+	current, err := ops.Workspace(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "staging", current)
+
+	lines := strings.Split(strings.TrimSpace(traced.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var events []trace.Event
+
+	for _, line := range lines {
+		var e trace.Event
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+
+		events = append(events, e)
+	}
+
+	assert.Equal(t, trace.KindTerraform, events[0].Kind)
+	assert.Equal(t, "init", events[0].Name)
+	assert.Equal(t, "-reconfigure", events[0].Request)
+	assert.Empty(t, events[0].Error)
+	assert.Contains(t, events[1].Error, "Backend configuration changed")
+	assert.Equal(t, "workspace show", events[2].Name)
+	assert.Equal(t, "staging", events[2].Response)
+
+	// tfexec logs the commands it runs
+	assert.Contains(t, logged.String(), "running Terraform command")
+	assert.Contains(t, logged.String(), "terraform init")
+}
@@ -22,3 +22,15 @@ func CheckTemplate(completion string) error {
 
 	return nil
 }
+
+// Diagnostics returns the syntax errors of the template, each with the file name and position.
+func Diagnostics(name string, template string) []string {
+	_, parseDiags := hclsyntax.ParseConfig([]byte(template), name, hcl.Pos{Line: 1, Column: 1})
+
+	diagnostics := make([]string, 0, len(parseDiags))
+	for _, diag := range parseDiags {
+		diagnostics = append(diagnostics, diag.Error())
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,18 @@
+package terraform_test
+
+import (
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnostics(t *testing.T) {
+	assert.Empty(t, terraform.Diagnostics("main.tf", "resource \"aws_instance\" \"web\" {\n  ami = \"ami-123\"\n}\n"))
+
+	diagnostics := terraform.Diagnostics("main.tf", "resource \"aws_instance\" \"web\" {\n  ami = \n}\n")
+	require.NotEmpty(t, diagnostics)
+	assert.Contains(t, diagnostics[0], "main.tf:2,")
+	assert.NotContains(t, diagnostics[0], "aws_instance")
+}
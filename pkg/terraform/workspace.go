@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// DefaultWorkspace is the workspace every working directory starts with.
+const DefaultWorkspace = "default"
+
+// protectedWorkspace matches workspace names like prod, production or eu-prd-1.
+var protectedWorkspace = regexp.MustCompile(`(?i)(^|[-_.])(prod|production|prd|live)([-_.]|\d|$)`)
+
+// IsProtectedWorkspace reports whether destructive operations in the workspace need an extra confirmation.
+func IsProtectedWorkspace(name string) bool {
+	return protectedWorkspace.MatchString(name)
+}
+
+// Workspaces lists the workspaces and returns the current one.
+func (ter *Terraform) Workspaces(ctx context.Context) ([]string, string, error) {
+	workspaces, current, err := ter.Exec.WorkspaceList(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("error running WorkspaceList: %w", err)
+	}
+
+	return workspaces, current, nil
+}
+
+// Workspace returns the current workspace.
+func (ter *Terraform) Workspace(ctx context.Context) (string, error) {
+	current, err := ter.Exec.WorkspaceShow(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error running WorkspaceShow: %w", err)
+	}
+
+	return current, nil
+}
+
+// SelectWorkspace makes the workspace the current one.
+func (ter *Terraform) SelectWorkspace(ctx context.Context, name string) error {
+	if err := ter.Exec.WorkspaceSelect(ctx, name); err != nil {
+		return fmt.Errorf("error running WorkspaceSelect: %w", err)
+	}
+
+	return nil
+}
+
+// NewWorkspace creates the workspace and makes it the current one.
+func (ter *Terraform) NewWorkspace(ctx context.Context, name string) error {
+	if err := ter.Exec.WorkspaceNew(ctx, name); err != nil {
+		return fmt.Errorf("error running WorkspaceNew: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteWorkspace deletes the workspace. Terraform refuses to delete the current workspace
+// and workspaces that still manage resources.
+func (ter *Terraform) DeleteWorkspace(ctx context.Context, name string) error {
+	if err := ter.Exec.WorkspaceDelete(ctx, name); err != nil {
+		return fmt.Errorf("error running WorkspaceDelete: %w", err)
+	}
+
+	return nil
+}
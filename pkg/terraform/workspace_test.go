@@ -0,0 +1,53 @@
+package terraform_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWorkspaces lists the default, staging and prod workspaces, with staging selected.
+const fakeWorkspaces = `"workspace list"*) printf '  default\n* staging\n  prod\n' ;;
+"workspace show"*) echo staging ;;
+`
+
+func TestIsProtectedWorkspace(t *testing.T) {
+	for _, name := range []string{"prod", "PROD", "production", "eu-prod", "prod-eu-1", "prd", "prod2", "live"} {
+		assert.True(t, terraform.IsProtectedWorkspace(name), name)
+	}
+
+	for _, name := range []string{"default", "staging", "dev", "product-team", "preprod", "delivery"} {
+		assert.False(t, terraform.IsProtectedWorkspace(name), name)
+	}
+}
+
+func TestWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	execPath := fakeTerraform(t, "1.5.7", fakeWorkspaces)
+
+	ter, err := terraform.NewTerraform(t.TempDir(), execPath)
+	require.NoError(t, err)
+
+	workspaces, current, err := ter.Workspaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "staging", "prod"}, workspaces)
+	assert.Equal(t, "staging", current)
+
+	current, err = ter.Workspace(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "staging", current)
+
+	require.NoError(t, ter.SelectWorkspace(ctx, "prod"))
+	require.NoError(t, ter.NewWorkspace(ctx, "dev"))
+	require.NoError(t, ter.DeleteWorkspace(ctx, "dev"))
+
+	args := fakeArgs(t, execPath)
+	require.Len(t, args, 5)
+	assert.Contains(t, args[2], "workspace select")
+	assert.Contains(t, args[2], "prod")
+	assert.Contains(t, args[3], "workspace new")
+	assert.Contains(t, args[4], "workspace delete")
+}
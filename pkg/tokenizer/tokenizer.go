@@ -0,0 +1,88 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Chat messages are framed with special tokens that count against the context window.
+// See https://github.com/openai/openai-cookbook/blob/main/examples/How_to_count_tokens_with_tiktoken.ipynb
+const (
+	// tokensPerMessage is the framing overhead of every chat message.
+	tokensPerMessage = 3
+	// legacyTokensPerMessage is the framing overhead of every chat message for the 0301 snapshots.
+	legacyTokensPerMessage = 4
+	// tokensPerReply primes every reply with <|start|>assistant<|message|>.
+	tokensPerReply = 3
+)
+
+var (
+	encodings = map[string]Tokenizer{}
+	mu        sync.Mutex
+)
+
+func init() {
+	// Load the BPE ranks from the embedded files instead of downloading them on first use.
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// Tokenizer counts tokens for one encoding.
+type Tokenizer interface {
+	// Count returns the number of tokens in text.
+	Count(text string) int
+}
+
+// Message is a chat message whose tokens are counted with its framing.
+type Message struct {
+	Role    string
+	Content string
+}
+
+type tiktokenTokenizer struct {
+	encoding *tiktoken.Tiktoken
+}
+
+func (t *tiktokenTokenizer) Count(text string) int {
+	return len(t.encoding.Encode(text, nil, nil))
+}
+
+// New returns the tokenizer for the given encoding, e.g. "cl100k_base".
+// Encodings are loaded once and shared.
+func New(encoding string) (Tokenizer, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if tk, ok := encodings[encoding]; ok {
+		return tk, nil
+	}
+
+	enc, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("error loading encoding %q: %w", encoding, err)
+	}
+
+	tk := &tiktokenTokenizer{encoding: enc}
+	encodings[encoding] = tk
+
+	return tk, nil
+}
+
+// CountMessages returns the number of prompt tokens the chat messages use for the given model,
+// including the per-message framing and the tokens that prime the reply.
+func CountMessages(tk Tokenizer, model string, messages []Message) int {
+	perMessage := tokensPerMessage
+	if strings.HasSuffix(model, "-0301") {
+		perMessage = legacyTokensPerMessage
+	}
+
+	total := tokensPerReply
+	for _, m := range messages {
+		total += perMessage + tk.Count(m.Role) + tk.Count(m.Content)
+	}
+
+	return total
+}
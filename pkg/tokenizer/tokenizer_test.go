@@ -0,0 +1,44 @@
+package tokenizer_test
+
+import (
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/tokenizer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCount(t *testing.T) {
+	cases := []struct {
+		encoding string
+		text     string
+		expected int
+	}{
+		{"cl100k_base", "hello world", 2},
+		{"cl100k_base", "tiktoken is great!", 6},
+		{"o200k_base", "hello world", 2},
+		{"r50k_base", "tiktoken is great!", 6},
+	}
+
+	for _, c := range cases {
+		tk, err := tokenizer.New(c.encoding)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, tk.Count(c.text), "%s: %q", c.encoding, c.text)
+	}
+}
+
+func TestNewUnknownEncoding(t *testing.T) {
+	_, err := tokenizer.New("unknown_base")
+	assert.Error(t, err)
+}
+
+func TestCountMessages(t *testing.T) {
+	tk, err := tokenizer.New("cl100k_base")
+	require.NoError(t, err)
+
+	messages := []tokenizer.Message{{Role: "user", Content: "hello world"}}
+
+	// 3 reply priming + 3 message framing + 1 role + 2 content
+	assert.Equal(t, 9, tokenizer.CountMessages(tk, "gpt-4o", messages))
+	assert.Equal(t, 10, tokenizer.CountMessages(tk, "gpt-3.5-turbo-0301", messages))
+}
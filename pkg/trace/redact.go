@@ -0,0 +1,28 @@
+package trace
+
+import "regexp"
+
+// redacted replaces secrets in traces and logs.
+const redacted = "[REDACTED]"
+
+// secrets match API keys and tokens: OpenAI keys, bearer tokens and api-key or api_key fields
+// of JSON, headers and query strings.
+var secrets = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_-]{8,}`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`),
+	regexp.MustCompile(`(?i)("api[-_]?key"\s*:\s*")[^"]*`),
+	regexp.MustCompile(`(?i)(api[-_]?key[=:]\s*)[^\s&"]+`),
+}
+
+// Redact replaces the API keys and tokens in s.
+func Redact(s string) string {
+	for _, secret := range secrets {
+		if secret.NumSubexp() == 0 {
+			s = secret.ReplaceAllString(s, redacted)
+		} else {
+			s = secret.ReplaceAllString(s, "${1}"+redacted)
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,109 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Kind is what an event traces.
+type Kind string
+
+const (
+	// KindModel is a completion of the model, with the prompt and the completion.
+	KindModel Kind = "model"
+	// KindHTTP is an HTTP request to the model API, with the request and response bodies.
+	KindHTTP Kind = "http"
+	// KindValidation is a check of a generated template.
+	KindValidation Kind = "validation"
+	// KindTerraform is a terraform invocation.
+	KindTerraform Kind = "terraform"
+)
+
+// Event is a line of the trace.
+type Event struct {
+	Time       time.Time      `json:"time"`
+	Kind       Kind           `json:"kind"`
+	Name       string         `json:"name"`
+	DurationMS float64        `json:"duration_ms,omitempty"`
+	Request    string         `json:"request,omitempty"`
+	Response   string         `json:"response,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Attrs      map[string]any `json:"attrs,omitempty"`
+}
+
+// Tracer writes events as JSON lines. A nil Tracer drops the events, so callers don't need to check
+// whether tracing is enabled.
+type Tracer struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// New returns a tracer that writes to w.
+func New(w io.Writer) *Tracer {
+	return &Tracer{enc: json.NewEncoder(w)}
+}
+
+// Open returns a tracer that appends to the file at path, creating it when it does not exist.
+func Open(path string) (*Tracer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening trace file: %w", err)
+	}
+
+	t := New(file)
+	t.closer = file
+
+	return t, nil
+}
+
+// Record writes the event, with the time set to now when it is zero. Requests, responses and errors
+// are redacted. Failing to write is logged rather than failing the traced operation.
+func (t *Tracer) Record(e Event) {
+	if t == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	e.Request = Redact(e.Request)
+	e.Response = Redact(e.Response)
+	e.Error = Redact(e.Error)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.enc.Encode(e); err != nil {
+		slog.Warn("error writing trace", "error", err)
+	}
+}
+
+// Close closes the trace file.
+func (t *Tracer) Close() error {
+	if t == nil || t.closer == nil {
+		return nil
+	}
+
+	return t.closer.Close()
+}
+
+// Since returns the milliseconds since start, for Event.DurationMS.
+func Since(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
+
+// ErrorString returns the message of err, or an empty string when it is nil.
+func ErrorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,121 @@
+package trace_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	for input, expected := range map[string]string{
+		"key sk-abcdefghijklmnop used":             "key [REDACTED] used",
+		"Authorization: Bearer abc.def-123":        "Authorization: Bearer [REDACTED]",
+		`{"api_key": "secret", "model": "x"}`:      `{"api_key": "[REDACTED]", "model": "x"}`,
+		"https://example.com/?api-key=secret&a=b":  "https://example.com/?api-key=[REDACTED]&a=b",
+		"resource \"aws_s3_bucket\" \"skills\" {}": "resource \"aws_s3_bucket\" \"skills\" {}",
+	} {
+		assert.Equal(t, expected, trace.Redact(input), input)
+	}
+}
+
+func readEvents(t *testing.T, r io.Reader) []trace.Event {
+	t.Helper()
+
+	var events []trace.Event
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var e trace.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+
+		events = append(events, e)
+	}
+
+	require.NoError(t, scanner.Err())
+
+	return events
+}
+
+func TestTracer(t *testing.T) {
+	var tracer *trace.Tracer
+
+	// A nil tracer drops the events
+	tracer.Record(trace.Event{Kind: trace.KindModel})
+	require.NoError(t, tracer.Close())
+
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+
+	tracer, err := trace.Open(path)
+	require.NoError(t, err)
+
+	tracer.Record(trace.Event{Kind: trace.KindModel, Name: "gpt-4o", Request: "key sk-abcdefghijklmnop", Response: "ok"})
+	tracer.Record(trace.Event{Kind: trace.KindValidation, Name: "check template", Error: "invalid"})
+	require.NoError(t, tracer.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	events := readEvents(t, file)
+	require.Len(t, events, 2)
+	assert.Equal(t, trace.KindModel, events[0].Kind)
+	assert.Equal(t, "key [REDACTED]", events[0].Request)
+	assert.Equal(t, "ok", events[0].Response)
+	assert.False(t, events[0].Time.IsZero())
+	assert.Equal(t, "invalid", events[1].Error)
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"prompt":"hi","api_key":"sk-abcdefghijklmnop"}`, string(body))
+		assert.Equal(t, "Bearer sk-abcdefghijklmnop", r.Header.Get("Authorization"))
+
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	var traced, logged bytes.Buffer
+
+	client := &http.Client{Transport: &trace.Transport{
+		Tracer: trace.New(&traced),
+		Logger: slog.New(slog.NewTextHandler(&logged, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/completions", strings.NewReader(`{"prompt":"hi","api_key":"sk-abcdefghijklmnop"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer sk-abcdefghijklmnop")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The response can still be read
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"text":"hello"}`, string(body))
+
+	events := readEvents(t, &traced)
+	require.Len(t, events, 1)
+	assert.Equal(t, trace.KindHTTP, events[0].Kind)
+	assert.Equal(t, "POST /v1/completions", events[0].Name)
+	assert.Equal(t, `{"prompt":"hi","api_key":"[REDACTED]"}`, events[0].Request)
+	assert.Equal(t, `{"text":"hello"}`, events[0].Response)
+	assert.EqualValues(t, http.StatusTeapot, events[0].Attrs["status"])
+
+	assert.Contains(t, logged.String(), "model API request")
+	assert.NotContains(t, logged.String(), "sk-abcdefghijklmnop")
+}
@@ -0,0 +1,93 @@
+package trace
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Transport is an http.RoundTripper that logs the requests and responses at debug level and traces them,
+// both redacted. Headers are left out, they carry the API keys.
+type Transport struct {
+	// Base sends the requests. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// Tracer records the requests, nil drops them.
+	Tracer *Tracer
+	// Logger logs the requests. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	logger := t.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	request, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+
+	event := Event{
+		Kind:    KindHTTP,
+		Name:    req.Method + " " + req.URL.Path,
+		Request: request,
+		Attrs:   map[string]any{"url": Redact(req.URL.String())},
+	}
+
+	if err == nil {
+		var response string
+		if response, err = readBody(&resp.Body); err != nil {
+			resp.Body.Close()
+			err = fmt.Errorf("error reading response body: %w", err)
+			resp = nil
+		}
+
+		event.Response = response
+		if resp != nil {
+			event.Attrs["status"] = resp.StatusCode
+		}
+	}
+
+	event.DurationMS = Since(start)
+	event.Error = ErrorString(err)
+
+	logger.Debug("model API request",
+		"method", req.Method,
+		"url", event.Attrs["url"],
+		"status", event.Attrs["status"],
+		"duration_ms", event.DurationMS,
+		"request", Redact(event.Request),
+		"response", Redact(event.Response),
+		"error", event.Error,
+	)
+	t.Tracer.Record(event)
+
+	return resp, err
+}
+
+// readBody reads the body and replaces it with a copy, so it can still be read.
+func readBody(body *io.ReadCloser) (string, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+
+	contents, err := io.ReadAll(*body)
+	(*body).Close()
+
+	*body = io.NopCloser(bytes.NewReader(contents))
+
+	return string(contents), err
+}
@@ -0,0 +1,8 @@
+package tui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// EditedMsg returns the message the editor sends when it exits after editing name in the file at path.
+func EditedMsg(name string, path string) tea.Msg {
+	return editedMsg{name: name, path: path}
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// style is the chroma style templates are highlighted with.
+const style = "monokai"
+
+// planChange matches the resource changes of terraform plan output, such as
+// "# aws_instance.web will be created".
+var planChange = regexp.MustCompile(`^\s*# (\S+) (?:will be|must be) (created|destroyed|updated in-place|replaced|read during apply)`)
+
+// changeSymbols are the symbols terraform uses for each kind of change.
+var changeSymbols = map[string]string{
+	"created":           "+",
+	"destroyed":         "-",
+	"updated in-place":  "~",
+	"replaced":          "-/+",
+	"read during apply": "<=",
+}
+
+// Highlight returns the contents with terminal colors for the syntax of the file name,
+// such as HCL for .tf files and Markdown for .md files. Unknown files are returned as they are.
+func Highlight(name string, contents string) string {
+	lexer := lexers.Match(name)
+	if lexer == nil {
+		return contents
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, contents)
+	if err != nil {
+		return contents
+	}
+
+	var b strings.Builder
+	if err := formatters.Get("terminal256").Format(&b, styles.Get(style), iterator); err != nil {
+		return contents
+	}
+
+	return b.String()
+}
+
+// PlanSummary returns the resource changes of terraform plan output, one line per resource
+// with the symbol terraform uses for the change, followed by the plan totals.
+func PlanSummary(plan string) []string {
+	var summary []string
+
+	for _, line := range strings.Split(plan, "\n") {
+		if match := planChange.FindStringSubmatch(line); match != nil {
+			summary = append(summary, changeSymbols[match[2]]+" "+match[1])
+
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Plan:") || strings.HasPrefix(trimmed, "No changes.") {
+			summary = append(summary, trimmed)
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,517 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/editor"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Action is what the user decided to do with the reviewed files.
+type Action int
+
+const (
+	// Discard drops the files.
+	Discard Action = iota
+	// Apply stores and applies the files.
+	Apply
+	// Reprompt asks the model again with the reprompt text.
+	Reprompt
+)
+
+const (
+	treeWidth   = 30
+	maxPlanRows = 8
+	// maxVerifyRows is the number of rows of the verdict on the plan shown below it.
+	maxVerifyRows = 4
+	// maxImpactRows is the number of rows of the impact of the files shown below their diagnostics.
+	maxImpactRows = 4
+	help          = "a apply • r reprompt • e edit • p plan • tab next file • ↑/↓ scroll • d discard"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("63"))
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	okStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// Review is what the user reviews: the generated files and how to check them.
+type Review struct {
+	// Title is shown above the files.
+	Title string
+	// Main is the file that is shown first.
+	Main  string
+	Files map[string][]byte
+	// Validate returns the diagnostics of the files, which must be fixed before they can be applied.
+	Validate func(files map[string][]byte) []string
+	// Impact returns what the files affect, shown below the diagnostics. It runs in the background when
+	// the screen starts and after every edit.
+	Impact func(files map[string][]byte) []string
+	// Plan runs terraform plan with the files. Planning is unavailable when it is nil.
+	Plan func(files map[string][]byte) (string, error)
+	// Verify checks the plan once it is done and returns the verdict to show below it, nothing when
+	// it has nothing to say. Plans are not verified when it is nil.
+	Verify func(plan string) []string
+}
+
+// Result is the decision of the user and the files, with the edits the user made.
+type Result struct {
+	Action   Action
+	Reprompt string
+	Files    map[string][]byte
+	// Edited are the names of the files the user edited.
+	Edited []string
+}
+
+// planMsg carries the output of a plan and its verdict, and the generation of the files it planned.
+type planMsg struct {
+	generation int
+	plan       string
+	verdict    []string
+	err        error
+}
+
+// impactMsg carries the impact of the files, and the generation of the files it is of.
+type impactMsg struct {
+	generation int
+	impact     []string
+}
+
+// editedMsg is sent when the editor exits.
+type editedMsg struct {
+	name string
+	path string
+	err  error
+}
+
+// Model is the bubbletea model of the review screen.
+type Model struct {
+	review   Review
+	names    []string
+	selected int
+	preview  viewport.Model
+	input    textinput.Model
+	// reprompting is set while the reprompt text is typed.
+	reprompting bool
+	diagnostics []string
+	impact      []string
+	plan        []string
+	verdict     []string
+	planning    bool
+	status      string
+	width       int
+	height      int
+	result      Result
+	// generation counts the edits, a plan of an older generation is of files that changed since.
+	generation int
+}
+
+// Run shows the review screen until the user applies, reprompts or discards the files.
+func Run(r Review) (Result, error) {
+	final, err := tea.NewProgram(NewModel(r), tea.WithAltScreen()).Run()
+	if err != nil {
+		return Result{}, fmt.Errorf("error running terminal UI: %w", err)
+	}
+
+	m, ok := final.(*Model)
+	if !ok {
+		return Result{}, fmt.Errorf("error running terminal UI: unexpected model %T", final)
+	}
+
+	return m.Result(), nil
+}
+
+// NewModel creates the review screen with the main file selected.
+func NewModel(r Review) *Model {
+	files := make(map[string][]byte, len(r.Files))
+	names := make([]string, 0, len(r.Files))
+
+	for name, contents := range r.Files {
+		files[name] = contents
+		names = append(names, name)
+	}
+
+	// The main file comes first, the others in order
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == r.Main) != (names[j] == r.Main) {
+			return names[i] == r.Main
+		}
+
+		return names[i] < names[j]
+	})
+
+	input := textinput.New()
+	input.Placeholder = "What should change?"
+
+	m := &Model{
+		review:  r,
+		names:   names,
+		preview: viewport.New(80, 20),
+		input:   input,
+		result:  Result{Action: Discard, Files: files},
+	}
+
+	m.validate()
+	m.showSelected()
+
+	return m
+}
+
+// Result returns the decision of the user so far.
+func (m *Model) Result() Result {
+	return m.result
+}
+
+// Init implements tea.Model.
+func (m *Model) Init() tea.Cmd {
+	return m.runImpact()
+}
+
+// Update implements tea.Model.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+
+		return m, nil
+	case planMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+
+		m.planning = false
+		if msg.err != nil {
+			m.plan = []string{errorStyle.Render(msg.err.Error())}
+		} else {
+			m.plan = PlanSummary(msg.plan)
+		}
+
+		m.verdict = msg.verdict
+
+		return m, nil
+	case impactMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+
+		m.impact = msg.impact
+		m.resize()
+
+		return m, nil
+	case editedMsg:
+		return m, m.edited(msg)
+	case tea.KeyMsg:
+		if m.reprompting {
+			return m.updateReprompt(msg)
+		}
+
+		return m.updateReview(msg)
+	}
+
+	var cmd tea.Cmd
+	m.preview, cmd = m.preview.Update(msg)
+
+	return m, cmd
+}
+
+// updateReview handles the keys of the review screen.
+func (m *Model) updateReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "a":
+		if len(m.diagnostics) > 0 {
+			m.status = "Fix the diagnostics before applying."
+
+			return m, nil
+		}
+
+		m.result.Action = Apply
+
+		return m, tea.Quit
+	case "r":
+		m.reprompting = true
+		m.status = ""
+
+		return m, m.input.Focus()
+	case "e":
+		return m, m.edit()
+	case "p":
+		return m, m.runPlan()
+	case "tab":
+		m.selected = (m.selected + 1) % len(m.names)
+		m.showSelected()
+
+		return m, nil
+	case "shift+tab":
+		m.selected = (m.selected + len(m.names) - 1) % len(m.names)
+		m.showSelected()
+
+		return m, nil
+	case "d", "q", "esc", "ctrl+c":
+		m.result.Action = Discard
+
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.preview, cmd = m.preview.Update(msg)
+
+	return m, cmd
+}
+
+// updateReprompt handles the keys while the reprompt text is typed.
+func (m *Model) updateReprompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if strings.TrimSpace(m.input.Value()) == "" {
+			return m, nil
+		}
+
+		m.result.Action = Reprompt
+		m.result.Reprompt = strings.TrimSpace(m.input.Value())
+
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.reprompting = false
+		m.input.Blur()
+		m.input.Reset()
+
+		return m, nil
+	case tea.KeyCtrlC:
+		m.result.Action = Discard
+
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+
+	return m, cmd
+}
+
+// edit opens the selected file in the user's editor, suspending the screen until it exits.
+func (m *Model) edit() tea.Cmd {
+	name := m.names[m.selected]
+
+	path, err := editor.TempFile(name, string(m.result.Files[name]))
+	if err != nil {
+		m.status = err.Error()
+
+		return nil
+	}
+
+	return tea.ExecProcess(editor.Command(path), func(err error) tea.Msg {
+		return editedMsg{name: name, path: path, err: err}
+	})
+}
+
+// edited takes over the edits of a file, validates the files again and returns the command
+// computing their impact.
+func (m *Model) edited(msg editedMsg) tea.Cmd {
+	defer os.Remove(msg.path)
+
+	if msg.err != nil {
+		m.status = fmt.Sprintf("error running editor: %s", msg.err)
+
+		return nil
+	}
+
+	contents, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.status = fmt.Sprintf("error reading edited file: %s", err)
+
+		return nil
+	}
+
+	if string(contents) == string(m.result.Files[msg.name]) {
+		m.status = "No changes."
+
+		return nil
+	}
+
+	m.result.Files[msg.name] = contents
+	m.result.Edited = appendUnique(m.result.Edited, msg.name)
+	m.status = fmt.Sprintf("Edited %s.", msg.name)
+
+	// The plan and the impact no longer match the files, and the ones in progress are dropped when they are done
+	m.plan, m.verdict, m.impact = nil, nil, nil
+	m.planning = false
+	m.generation++
+	m.validate()
+	m.resize()
+	m.showSelected()
+
+	return m.runImpact()
+}
+
+// runPlan plans the files in the background.
+func (m *Model) runPlan() tea.Cmd {
+	if m.review.Plan == nil || m.planning {
+		return nil
+	}
+
+	m.planning = true
+	// The plan runs in the background while the files may be edited
+	files, generation := maps.Clone(m.result.Files), m.generation
+	plan, verify := m.review.Plan, m.review.Verify
+
+	return func() tea.Msg {
+		output, err := plan(files)
+		if err != nil || verify == nil {
+			return planMsg{generation: generation, plan: output, err: err}
+		}
+
+		return planMsg{generation: generation, plan: output, verdict: verify(output)}
+	}
+}
+
+// runImpact computes the impact of the files in the background, it may run terraform graph.
+func (m *Model) runImpact() tea.Cmd {
+	if m.review.Impact == nil {
+		return nil
+	}
+
+	files, generation, impact := maps.Clone(m.result.Files), m.generation, m.review.Impact
+
+	return func() tea.Msg {
+		return impactMsg{generation: generation, impact: impact(files)}
+	}
+}
+
+// validate refreshes the diagnostics of the files.
+func (m *Model) validate() {
+	m.diagnostics = nil
+	if m.review.Validate != nil {
+		m.diagnostics = m.review.Validate(m.result.Files)
+	}
+}
+
+// showSelected shows the selected file in the preview.
+func (m *Model) showSelected() {
+	if len(m.names) == 0 {
+		return
+	}
+
+	name := m.names[m.selected]
+	m.preview.SetContent(Highlight(name, string(m.result.Files[name])))
+	m.preview.GotoTop()
+}
+
+// resize fits the panes to the terminal.
+func (m *Model) resize() {
+	// Borders, the title, the diagnostics, impact, plan and verdict rows, the status and help lines
+	verifyRows := 0
+	if m.review.Verify != nil {
+		verifyRows = maxVerifyRows
+	}
+
+	m.preview.Width = max(m.width-treeWidth-4, 20)
+	m.preview.Height = max(m.height-maxPlanRows-verifyRows-len(m.diagnostics)-len(m.impactView())-9, 5)
+	m.input.Width = max(m.width-4, 20)
+}
+
+// View implements tea.Model.
+func (m *Model) View() string {
+	tree := make([]string, 0, len(m.names))
+
+	for i, name := range m.names {
+		if i == m.selected {
+			tree = append(tree, selectedStyle.Render("> "+name))
+		} else {
+			tree = append(tree, "  "+name)
+		}
+	}
+
+	panes := lipgloss.JoinHorizontal(lipgloss.Top,
+		paneStyle.Width(treeWidth-2).Height(m.preview.Height).Render(strings.Join(tree, "\n")),
+		paneStyle.Render(m.preview.View()),
+	)
+
+	rows := []string{titleStyle.Render(m.review.Title), panes}
+
+	if len(m.diagnostics) == 0 {
+		rows = append(rows, okStyle.Render("✓ valid"))
+	} else {
+		for _, d := range m.diagnostics {
+			rows = append(rows, errorStyle.Render("✗ "+d))
+		}
+	}
+
+	rows = append(rows, m.impactView()...)
+	rows = append(rows, m.planView()...)
+	rows = append(rows, m.verdictView()...)
+
+	if m.status != "" {
+		rows = append(rows, m.status)
+	}
+
+	if m.reprompting {
+		rows = append(rows, m.input.View(), helpStyle.Render("enter reprompt • esc cancel"))
+	} else {
+		rows = append(rows, helpStyle.Render(help))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// planView renders the plan summary, at most maxPlanRows rows.
+func (m *Model) planView() []string {
+	switch {
+	case m.review.Plan == nil:
+		return nil
+	case m.planning:
+		return []string{"Planning..."}
+	case m.plan == nil:
+		return []string{helpStyle.Render("Press p to plan.")}
+	case len(m.plan) == 0:
+		return []string{"The plan has no changes."}
+	case len(m.plan) > maxPlanRows:
+		rows := append([]string{}, m.plan[:maxPlanRows-1]...)
+
+		return append(rows, fmt.Sprintf("... and %d more, %s", len(m.plan)-maxPlanRows, m.plan[len(m.plan)-1]))
+	default:
+		return m.plan
+	}
+}
+
+// impactView renders the impact of the files, at most maxImpactRows rows.
+func (m *Model) impactView() []string {
+	if len(m.impact) <= maxImpactRows {
+		return m.impact
+	}
+
+	rows := append([]string{}, m.impact[:maxImpactRows-1]...)
+
+	return append(rows, fmt.Sprintf("... and %d more", len(m.impact)-maxImpactRows+1))
+}
+
+// verdictView renders the verdict on the plan, at most maxVerifyRows rows.
+func (m *Model) verdictView() []string {
+	if len(m.verdict) <= maxVerifyRows {
+		return m.verdict
+	}
+
+	rows := append([]string{}, m.verdict[:maxVerifyRows-1]...)
+
+	return append(rows, fmt.Sprintf("... and %d more", len(m.verdict)-maxVerifyRows+1))
+}
+
+func appendUnique(names []string, name string) []string {
+	for _, n := range names {
+		if n == name {
+			return names
+		}
+	}
+
+	return append(names, name)
+}
@@ -0,0 +1,284 @@
+package tui_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akhilsharma90/terraform-assistant/pkg/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const plan = `Terraform will perform the following actions:
+
+  # aws_instance.web will be created
+  + resource "aws_instance" "web" {
+      + ami = "ami-123"
+    }
+
+  # aws_s3_bucket.logs will be updated in-place
+  ~ resource "aws_s3_bucket" "logs" {
+    }
+
+  # aws_db_instance.main must be replaced
+-/+ resource "aws_db_instance" "main" {
+    }
+
+  # module.vpc.aws_vpc.this will be destroyed
+  - resource "aws_vpc" "this" {
+    }
+
+Plan: 2 to add, 1 to change, 2 to destroy.
+`
+
+func review() tui.Review {
+	return tui.Review{
+		Title: "Review",
+		Main:  "web.tf",
+		Files: map[string][]byte{
+			"web.tf":       []byte("resource \"aws_instance\" \"web\" {}\n"),
+			"variables.tf": []byte("variable \"ami\" {}\n"),
+		},
+	}
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	default:
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+}
+
+// press sends the keys to the model and reports whether the last one quit the program.
+func press(m *tui.Model, keys ...string) bool {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		_, cmd = m.Update(key(k))
+	}
+
+	if cmd == nil {
+		return false
+	}
+
+	_, quit := cmd().(tea.QuitMsg)
+
+	return quit
+}
+
+func TestPlanSummary(t *testing.T) {
+	assert.Equal(t, []string{
+		"+ aws_instance.web",
+		"~ aws_s3_bucket.logs",
+		"-/+ aws_db_instance.main",
+		"- module.vpc.aws_vpc.this",
+		"Plan: 2 to add, 1 to change, 2 to destroy.",
+	}, tui.PlanSummary(plan))
+
+	assert.Equal(t, []string{"No changes. Your infrastructure matches the configuration."},
+		tui.PlanSummary("\nNo changes. Your infrastructure matches the configuration.\n"))
+}
+
+func TestHighlight(t *testing.T) {
+	highlighted := tui.Highlight("main.tf", "resource \"aws_instance\" \"web\" {}\n")
+	assert.Contains(t, highlighted, "\x1b[")
+	assert.Contains(t, highlighted, "aws_instance")
+
+	assert.Equal(t, "plain text", tui.Highlight("notes.unknown-extension", "plain text"))
+}
+
+func TestApply(t *testing.T) {
+	m := tui.NewModel(review())
+	assert.True(t, press(m, "a"))
+	assert.Equal(t, tui.Apply, m.Result().Action)
+	assert.Equal(t, review().Files, m.Result().Files)
+	assert.Empty(t, m.Result().Edited)
+}
+
+func TestApplyWithDiagnostics(t *testing.T) {
+	r := review()
+	r.Validate = func(files map[string][]byte) []string {
+		return []string{"web.tf: invalid template"}
+	}
+
+	m := tui.NewModel(r)
+	assert.Contains(t, m.View(), "✗ web.tf: invalid template")
+	assert.False(t, press(m, "a"))
+	assert.Contains(t, m.View(), "Fix the diagnostics before applying.")
+
+	assert.True(t, press(m, "d"))
+	assert.Equal(t, tui.Discard, m.Result().Action)
+}
+
+func TestImpact(t *testing.T) {
+	r := review()
+	r.Impact = func(files map[string][]byte) []string {
+		require.Contains(t, files, "web.tf")
+
+		return []string{"💥 Blast radius: changing aws_instance.web may update or replace:", "  ~ output.a", "  ~ output.b", "  ~ output.c", "  ~ output.d"}
+	}
+
+	m := tui.NewModel(r)
+	assert.NotContains(t, m.View(), "Blast radius")
+
+	// The impact is computed in the background
+	cmd := m.Init()
+	require.NotNil(t, cmd)
+
+	m.Update(cmd())
+
+	view := m.View()
+	assert.Contains(t, view, "changing aws_instance.web may update or replace:")
+	assert.Contains(t, view, "~ output.b")
+	assert.NotContains(t, view, "~ output.c")
+	assert.Contains(t, view, "... and 2 more")
+}
+
+func TestImpactWhileEditing(t *testing.T) {
+	r := review()
+	r.Impact = func(files map[string][]byte) []string {
+		return []string{"💥 Blast radius: " + strings.TrimSpace(string(files["web.tf"]))}
+	}
+
+	m := tui.NewModel(r)
+	stale := m.Init()
+
+	path := filepath.Join(t.TempDir(), "web.tf")
+	require.NoError(t, os.WriteFile(path, []byte("resource \"aws_instance\" \"edited\" {}\n"), 0o600))
+
+	_, cmd := m.Update(tui.EditedMsg("web.tf", path))
+	require.NotNil(t, cmd)
+
+	// The impact of the files before the edit is dropped
+	m.Update(stale())
+	assert.NotContains(t, m.View(), "Blast radius")
+
+	m.Update(cmd())
+	assert.Contains(t, m.View(), `Blast radius: resource "aws_instance" "edited" {}`)
+}
+
+func TestReprompt(t *testing.T) {
+	m := tui.NewModel(review())
+
+	// An empty reprompt is ignored and esc goes back to the review
+	assert.False(t, press(m, "r", "enter"))
+	assert.False(t, press(m, "esc"))
+	assert.Contains(t, m.View(), "a apply")
+
+	assert.True(t, press(m, "r", "use t3.micro", "enter"))
+	assert.Equal(t, tui.Reprompt, m.Result().Action)
+	assert.Equal(t, "use t3.micro", m.Result().Reprompt)
+}
+
+func TestSelectFile(t *testing.T) {
+	m := tui.NewModel(review())
+	assert.Contains(t, m.View(), "> web.tf")
+
+	press(m, "tab")
+	assert.Contains(t, m.View(), "> variables.tf")
+
+	press(m, "tab")
+	assert.Contains(t, m.View(), "> web.tf")
+}
+
+func TestPlan(t *testing.T) {
+	r := review()
+	m := tui.NewModel(r)
+	assert.NotContains(t, m.View(), "Press p to plan.")
+
+	calls := 0
+	r.Plan = func(files map[string][]byte) (string, error) {
+		calls++
+		require.Contains(t, files, "web.tf")
+
+		if calls > 1 {
+			return "", errors.New("plan failed")
+		}
+
+		return plan, nil
+	}
+
+	m = tui.NewModel(r)
+	assert.Contains(t, m.View(), "Press p to plan.")
+
+	_, cmd := m.Update(key("p"))
+	require.NotNil(t, cmd)
+	assert.Contains(t, m.View(), "Planning...")
+
+	m.Update(cmd())
+	view := m.View()
+	assert.Contains(t, view, "+ aws_instance.web")
+	assert.Contains(t, view, "Plan: 2 to add, 1 to change, 2 to destroy.")
+
+	_, cmd = m.Update(key("p"))
+	m.Update(cmd())
+	assert.True(t, strings.Contains(m.View(), "plan failed"))
+}
+
+func TestPlanWhileEditing(t *testing.T) {
+	var planned []string
+
+	r := review()
+	r.Plan = func(files map[string][]byte) (string, error) {
+		planned = append(planned, string(files["web.tf"]))
+
+		return plan, nil
+	}
+	r.Verify = func(_ string) []string {
+		return []string{"✔ The plan matches the request."}
+	}
+
+	m := tui.NewModel(r)
+
+	_, stale := m.Update(key("p"))
+	require.NotNil(t, stale)
+
+	// The editor returns while the plan is in progress
+	path := filepath.Join(t.TempDir(), "web.tf")
+	require.NoError(t, os.WriteFile(path, []byte("resource \"aws_instance\" \"edited\" {}\n"), 0o600))
+	m.Update(tui.EditedMsg("web.tf", path))
+
+	// The plan of the files before the edit is dropped
+	m.Update(stale())
+	assert.Equal(t, []string{"resource \"aws_instance\" \"web\" {}\n"}, planned)
+	assert.Contains(t, m.View(), "Press p to plan.")
+	assert.NotContains(t, m.View(), "The plan matches the request.")
+
+	// Planning again plans the edited files
+	_, cmd := m.Update(key("p"))
+	require.NotNil(t, cmd)
+
+	m.Update(cmd())
+	assert.Equal(t, "resource \"aws_instance\" \"edited\" {}\n", planned[1])
+	assert.Contains(t, m.View(), "The plan matches the request.")
+}
+
+func TestPlanVerify(t *testing.T) {
+	r := review()
+	r.Plan = func(_ map[string][]byte) (string, error) {
+		return plan, nil
+	}
+	r.Verify = func(p string) []string {
+		require.Equal(t, plan, p)
+
+		return []string{"⚠️ The plan may not match the request.", "  ✘ Suspicious destroy: module.vpc.aws_vpc.this"}
+	}
+
+	m := tui.NewModel(r)
+
+	_, cmd := m.Update(key("p"))
+	m.Update(cmd())
+
+	view := m.View()
+	assert.Contains(t, view, "Plan: 2 to add, 1 to change, 2 to destroy.")
+	assert.Contains(t, view, "Suspicious destroy: module.vpc.aws_vpc.this")
+}